@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"seed"
+)
+
+// check reports the handlers and middlewares of the service file that are no
+// longer referenced by the service descriptor, and the ones that are
+// referenced but not implemented.
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+
+	var (
+		projectName string
+		deprecate   bool
+	)
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.BoolVar(&deprecate, "deprecate", false, "Specify this flag to move orphan methods into a deprecated "+
+		"block at the end of the service file, instead of only reporting them.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required")
+	}
+
+	report, err := seed.Check(projectName, deprecate)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range report.Orphans {
+		fmt.Printf("orphan: %s is not referenced by the service descriptor\n", name)
+	}
+
+	for _, name := range report.Deprecated {
+		fmt.Printf("deprecated: %s is in the deprecated block\n", name)
+	}
+
+	for _, name := range report.Missing {
+		fmt.Printf("missing: %s is not implemented\n", name)
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("found %d orphan and %d missing methods", len(report.Orphans), len(report.Missing))
+	}
+
+	return nil
+}
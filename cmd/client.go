@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"seed"
+	"seed/files"
+)
+
+// client prints a client of a project, built from its service descriptor, or
+// writes it to a file. The Go client is part of the project itself.
+func client(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a language: ts")
+	}
+
+	switch args[0] {
+	case "ts":
+		return clientTS(args[1:])
+	default:
+		return fmt.Errorf("unknown language %q, expected: ts", args[0])
+	}
+}
+
+func clientTS(args []string) error {
+	fs := flag.NewFlagSet("client ts", flag.ExitOnError)
+
+	var projectName, out string
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&out, "o", "", "Specify the .ts file to write the client to, instead of the standard output.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required")
+	}
+
+	b, err := seed.TypeScriptClient(projectName)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	return ioutil.WriteFile(out, b, files.DefaultPerm)
+}
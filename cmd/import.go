@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"seed"
+	"seed/consts"
+	"seed/metadata"
+	"seed/openapi"
+)
+
+// importSpec creates a project from an existing API specification.
+func importSpec(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a format: openapi")
+	}
+
+	switch args[0] {
+	case "openapi":
+		return importOpenAPI(args[1:])
+	default:
+		return fmt.Errorf("unknown format %q, expected: openapi", args[0])
+	}
+}
+
+func importOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("import openapi", flag.ExitOnError)
+
+	var (
+		projectName string
+		opts        seed.InitOptions
+	)
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name. Defaults to the title of the document.")
+	fs.StringVar(&opts.Module, "module", "", "Specify the Go module path of the project, e.g. "+
+		"github.com/org/service. Defaults to the module of an existing go.mod, or to the project's name.")
+	fs.BoolVar(&opts.Force, "force", false, "Specify this flag to overwrite the files of an existing project.")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Specify this flag to print a diff of what each file of the "+
+		"project would become, without writing anything.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of import openapi: [flags] <file>\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected the path of an OpenAPI document")
+	}
+
+	src, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	doc, err := openapi.Parse(src)
+	if err != nil {
+		return err
+	}
+
+	md, warnings := openapi.ToMetadata(doc)
+
+	for _, w := range warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+
+	if projectName == "" {
+		projectName = md.Name
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required, as the title of the document does not make one")
+	}
+
+	opts.Descriptor = &md
+	opts.Files = map[string]func(metadata.Metadata) ([]byte, error){
+		consts.TypesFile: doc.TypesFile,
+	}
+
+	return seed.InitProjectWith(projectName, opts)
+}
@@ -9,16 +9,51 @@ import (
 
 var (
 	initialize bool
+	force      bool
+	dryRun     bool
 	name       string
+	module     string
 )
 
+// commands holds the subcommands of seed, keyed by their name. Each of them
+// receives the arguments following the subcommand's name.
+var commands = map[string]func(args []string) error{
+	"check":      check,
+	"client":     client,
+	"import":     importSpec,
+	"middleware": middleware,
+	"openapi":    openAPI,
+	"regen":      regen,
+	"route":      route,
+	"validate":   validate,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			err := command(os.Args[2:])
+			if err != nil {
+				fmt.Printf("%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
 	flag.BoolVar(
 		&initialize, "i", false, "Specify this flag to initialize a project.")
 	flag.StringVar(&name, "n", "example2", "Specify the project's name. A new folder will be created "+
 		"with this name where the poject will be initialized. If '.' is specified, the project name will be derived "+
 		"from the directory name, and the project will be initialized in the same folder.")
 
+	flag.StringVar(&module, "module", "", "Specify the Go module path of the project, e.g. github.com/org/service. "+
+		"Defaults to the module of an existing go.mod, or to the project's name.")
+
+	flag.BoolVar(&force, "force", false, "Specify this flag to overwrite the files of an existing project.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Specify this flag to print a diff of what each file of the project "+
+		"would become, without writing anything.")
+
 	flag.Parse()
 
 	if !initialize {
@@ -27,7 +62,11 @@ func main() {
 		os.Exit(1)
 	}
 
-	err := seed.InitProject(name)
+	err := seed.InitProjectWith(name, seed.InitOptions{
+		Force:  force,
+		DryRun: dryRun,
+		Module: module,
+	})
 	if err != nil {
 		fmt.Printf("Failed initializing the project: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"seed"
+	"seed/metadata"
+)
+
+// middleware adds or removes middlewares in the service descriptor of a
+// project.
+func middleware(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a subcommand: add, rm")
+	}
+
+	switch args[0] {
+	case "add":
+		return middlewareAdd(args[1:])
+	case "rm":
+		return middlewareRm(args[1:])
+	default:
+		return fmt.Errorf("unknown subcommand %q, expected: add, rm", args[0])
+	}
+}
+
+func middlewareAdd(args []string) error {
+	fs := flag.NewFlagSet("middleware add", flag.ExitOnError)
+
+	var (
+		projectName string
+		paths       string
+		mw          metadata.Middleware
+	)
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&mw.HandlerName, "handler", "", "Specify the name of the middleware method.")
+	fs.StringVar(&paths, "paths", "*", "Specify a comma separated list of route paths the middleware "+
+		"should be applied on. '*' applies it on all routes.")
+	fs.IntVar(&mw.Priority, "priority", 0, "Specify the priority of the middleware. Middlewares are invoked "+
+		"from highest to lowest priority.")
+	fs.StringVar(&mw.Name, "name", "", "Specify a simple name for the middleware.")
+	fs.StringVar(&mw.Summary, "summary", "", "Specify a short description of the middleware.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" || mw.HandlerName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name and handler are required")
+	}
+
+	mw.Paths = splitList(paths)
+
+	return seed.AddMiddleware(projectName, mw)
+}
+
+func middlewareRm(args []string) error {
+	fs := flag.NewFlagSet("middleware rm", flag.ExitOnError)
+
+	var projectName, handlerName string
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&handlerName, "handler", "", "Specify the name of the middleware method.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" || handlerName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name and handler are required")
+	}
+
+	return seed.RemoveMiddleware(projectName, handlerName)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"seed"
+	"seed/files"
+	"seed/openapi"
+)
+
+// openAPI prints the OpenAPI document built from the service descriptor of a
+// project, or writes it to a file.
+func openAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+
+	var projectName, format, out, version string
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&format, "format", openapi.FormatYAML, "Specify the format of the document: yaml or json.")
+	fs.StringVar(&out, "o", "", "Specify the file to write the document to, instead of the standard output.")
+	fs.StringVar(&version, "version", openapi.DefaultVersion, "Specify the version of the API.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required")
+	}
+
+	doc, err := seed.OpenAPI(projectName)
+	if err != nil {
+		return err
+	}
+
+	doc.Info.Version = version
+
+	b, err := doc.Marshal(format)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	return ioutil.WriteFile(out, b, files.DefaultPerm)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"seed"
+)
+
+// regen re-renders the gen folder of an existing project from its service
+// descriptor.
+func regen(args []string) error {
+	fs := flag.NewFlagSet("regen", flag.ExitOnError)
+
+	var projectName string
+	fs.StringVar(&projectName, "n", "", "Specify the project's name, which is also the name of the folder "+
+		"the project was initialized in.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required")
+	}
+
+	return seed.Regenerate(projectName)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"seed"
+	"seed/metadata"
+	"strings"
+)
+
+// route adds or removes routes in the service descriptor of a project.
+func route(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a subcommand: add, rm")
+	}
+
+	switch args[0] {
+	case "add":
+		return routeAdd(args[1:])
+	case "rm":
+		return routeRm(args[1:])
+	default:
+		return fmt.Errorf("unknown subcommand %q, expected: add, rm", args[0])
+	}
+}
+
+func routeAdd(args []string) error {
+	fs := flag.NewFlagSet("route add", flag.ExitOnError)
+
+	var (
+		projectName string
+		methods     string
+		r           metadata.Route
+		request     metadata.Body
+		response    metadata.Body
+	)
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&r.Path, "path", "", "Specify the path of the route, relative to the root URL.")
+	fs.StringVar(&methods, "methods", "GET", "Specify a comma separated list of HTTP methods served on the path.")
+	fs.StringVar(&r.HandlerName, "handler", "", "Specify the name of the method that handles the route.")
+	fs.BoolVar(&r.StrictSlash, "strict", false, "Specify this flag to redirect requests with a trailing slash "+
+		"mismatch to the path.")
+	fs.StringVar(&r.Name, "name", "", "Specify a simple name for the route.")
+	fs.StringVar(&r.Summary, "summary", "", "Specify a short description of the route.")
+	fs.Var((*paramList)(&r.Params), "param", "Specify a parameter of the route as in:name[:type], where in is "+
+		"path, query or header, followed by '!' if it is required or '=value' to set its default, "+
+		"e.g. query:limit:int=10. Can be repeated.")
+	fs.StringVar(&request.Type, "request", "", "Specify the type of the request body, declared in the service "+
+		"package, e.g. User or []User. Makes the handler typed.")
+	fs.Var((*fieldList)(&request.Fields), "request-field", "Specify a field of the request body as name[:type], "+
+		"followed by '!' if it is required, e.g. email!. Makes the handler typed. Can be repeated.")
+	fs.StringVar(&response.Type, "response", "", "Specify the type of the response body, declared in the "+
+		"service package, e.g. User or []User. Makes the handler typed.")
+	fs.Var((*fieldList)(&response.Fields), "response-field", "Specify a field of the response body as "+
+		"name[:type], e.g. id:uuid. Makes the handler typed. Can be repeated.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" || r.Path == "" || r.HandlerName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name, path and handler are required")
+	}
+
+	r.HttpMethods = splitList(strings.ToUpper(methods))
+
+	if request.Type != "" || len(request.Fields) > 0 {
+		r.Request = &request
+	}
+
+	if response.Type != "" || len(response.Fields) > 0 {
+		r.Response = &response
+	}
+
+	return seed.AddRoute(projectName, r)
+}
+
+func routeRm(args []string) error {
+	fs := flag.NewFlagSet("route rm", flag.ExitOnError)
+
+	var projectName, handlerName string
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+	fs.StringVar(&handlerName, "handler", "", "Specify the name of the method that handles the route.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" || handlerName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name and handler are required")
+	}
+
+	return seed.RemoveRoute(projectName, handlerName)
+}
+
+// splitList splits a comma separated list, dropping empty elements and
+// surrounding whitespace.
+func splitList(list string) []string {
+	var result []string
+
+	for _, elem := range strings.Split(list, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+
+		result = append(result, elem)
+	}
+
+	return result
+}
+
+// paramList is a flag.Value collecting route parameters written as
+// in:name[:type], followed by "!" for required parameters, or "=value" for
+// parameters with a default.
+type paramList []metadata.Param
+
+func (l *paramList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	var params []string
+
+	for _, p := range *l {
+		params = append(params, p.In+":"+p.Name+":"+p.ParamType())
+	}
+
+	return strings.Join(params, ",")
+}
+
+func (l *paramList) Set(value string) error {
+	var p metadata.Param
+
+	if i := strings.Index(value, "="); i >= 0 {
+		value, p.Default = value[:i], value[i+1:]
+	} else if strings.HasSuffix(value, "!") {
+		value, p.Required = strings.TrimSuffix(value, "!"), true
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("expected in:name[:type], got %q", value)
+	}
+
+	p.In, p.Name = parts[0], parts[1]
+	if len(parts) == 3 {
+		p.Type = parts[2]
+	}
+
+	*l = append(*l, p)
+
+	return nil
+}
+
+// fieldList is a flag.Value collecting body fields written as name[:type],
+// followed by "!" for required fields.
+type fieldList []metadata.Field
+
+func (l *fieldList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	var fields []string
+
+	for _, f := range *l {
+		elem, list := f.ElemType()
+		if list {
+			elem = "[]" + elem
+		}
+
+		fields = append(fields, f.Name+":"+elem)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+func (l *fieldList) Set(value string) error {
+	var f metadata.Field
+
+	if strings.HasSuffix(value, "!") {
+		value, f.Required = strings.TrimSuffix(value, "!"), true
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 2 {
+		return fmt.Errorf("expected name[:type], got %q", value)
+	}
+
+	f.Name = parts[0]
+	if len(parts) == 2 {
+		f.Type = parts[1]
+	}
+
+	*l = append(*l, f)
+
+	return nil
+}
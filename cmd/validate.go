@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"seed"
+	"seed/metadata"
+)
+
+// validate reports every problem found in the service descriptor of a
+// project, each on its own line prefixed with its position.
+func validate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	var projectName string
+
+	fs.StringVar(&projectName, "n", "", "Specify the project's name.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fs.Usage()
+		return fmt.Errorf("project name is required")
+	}
+
+	err = seed.Validate(projectName)
+	if errs, ok := err.(metadata.ValidationErrors); ok {
+		for _, e := range errs {
+			fmt.Println(e)
+		}
+
+		return fmt.Errorf("found %d problems", len(errs))
+	}
+
+	return err
+}
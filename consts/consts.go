@@ -3,7 +3,13 @@ package consts
 const (
 	CmdFolder     = "cmd"
 	GenFolder     = "gen"
+	ApiFolder     = "api"
+	ParamsFile    = "params.go"
+	TypesFile     = "types.go"
+	ClientFolder  = "client"
+	ClientFile    = "client.go"
 	InterfaceFile = "interface.go"
 	BootstrapFile = "bootstrap.go"
+	ConfigFile    = "config.go"
 	MainFile      = "main.go"
 )
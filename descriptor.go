@@ -0,0 +1,201 @@
+package seed
+
+import (
+	"bytes"
+	"fmt"
+	"seed/files"
+	"seed/generate"
+	"seed/metadata"
+	"seed/openapi"
+)
+
+// AddRoute adds the route to the service descriptor of the project,
+// regenerates the gen folder, and adds a stub for the handler to the service
+// file.
+func (g *Generator) AddRoute(projectName string, route metadata.Route) error {
+	return g.updateDescriptor(projectName, func(md *metadata.Metadata) error {
+		return md.AddRoute(route)
+	})
+}
+
+// RemoveRoute removes the route served by handlerName from the service
+// descriptor of the project, and regenerates the gen folder.
+func (g *Generator) RemoveRoute(projectName, handlerName string) error {
+	return g.updateDescriptor(projectName, func(md *metadata.Metadata) error {
+		return md.RemoveRoute(handlerName)
+	})
+}
+
+// AddMiddleware adds the middleware to the service descriptor of the
+// project, regenerates the gen folder, and adds a stub for the middleware to
+// the service file.
+func (g *Generator) AddMiddleware(projectName string, mw metadata.Middleware) error {
+	return g.updateDescriptor(projectName, func(md *metadata.Metadata) error {
+		return md.AddMiddleware(mw)
+	})
+}
+
+// RemoveMiddleware removes the middleware called handlerName from the service
+// descriptor of the project, and regenerates the gen folder.
+func (g *Generator) RemoveMiddleware(projectName, handlerName string) error {
+	return g.updateDescriptor(projectName, func(md *metadata.Metadata) error {
+		return md.RemoveMiddleware(handlerName)
+	})
+}
+
+// updateDescriptor loads the service descriptor of the project, applies the
+// update on it, then saves it, regenerates the gen folder and adds the
+// missing stubs to the service file. Nothing is written if the update fails,
+// or if the updated descriptor is not valid.
+func (g *Generator) updateDescriptor(projectName string, update func(*metadata.Metadata) error) error {
+	p, err := g.project(projectName)
+	if err != nil {
+		return err
+	}
+
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		return err
+	}
+
+	err = update(&md)
+	if err != nil {
+		return err
+	}
+
+	err = md.Validate()
+	if err != nil {
+		return err
+	}
+
+	b, err := md.Marshal()
+	if err != nil {
+		return err
+	}
+
+	err = g.FS.WriteFile(p.descriptorPath(), b, files.DefaultPerm)
+	if err != nil {
+		return fmt.Errorf("saving descriptor: %v", err)
+	}
+
+	err = g.regenerate(p)
+	if err != nil {
+		return err
+	}
+
+	return g.addStubs(p, md)
+}
+
+// Validate validates the service descriptor of the project. The returned
+// metadata.ValidationErrors carry the position of each problem in the
+// descriptor.
+func (g *Generator) Validate(projectName string) error {
+	p, err := g.project(projectName)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.loadDescriptor(p, true)
+
+	return err
+}
+
+// Check compares the handler and middleware methods implemented in the
+// service file of the project against its service descriptor. If deprecate
+// is set, orphan methods are moved into the deprecated block of the service
+// file, and the returned report reflects the updated file.
+func (g *Generator) Check(projectName string, deprecate bool) (generate.Report, error) {
+	var report generate.Report
+
+	p, err := g.project(projectName)
+	if err != nil {
+		return report, err
+	}
+
+	md, err := g.loadDescriptor(p, false)
+	if err != nil {
+		return report, err
+	}
+
+	path := p.serviceFilePath()
+
+	src, err := g.FS.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("reading service file: %v", err)
+	}
+
+	if deprecate {
+		updated, err := generate.DeprecateOrphans(src, md)
+		if err != nil {
+			return report, err
+		}
+
+		err = g.FS.WriteFile(path, updated, files.DefaultPerm)
+		if err != nil {
+			return report, fmt.Errorf("writing service file: %v", err)
+		}
+
+		src = updated
+	}
+
+	return generate.CheckServiceFile(src, md)
+}
+
+// OpenAPI returns the OpenAPI document describing the routes of the project,
+// built from its service descriptor, which must be valid.
+func (g *Generator) OpenAPI(projectName string) (*openapi.Document, error) {
+	p, err := g.project(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return openapi.FromMetadata(md), nil
+}
+
+// TypeScriptClient returns the TypeScript module calling the routes of the
+// project, built from its service descriptor, which must be valid.
+func (g *Generator) TypeScriptClient(projectName string) ([]byte, error) {
+	p, err := g.project(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return generate.TypeScriptClient(md)
+}
+
+// addStubs appends a stub to the service file of the project for each
+// handler and middleware of the descriptor that is not implemented yet.
+func (g *Generator) addStubs(p project, md metadata.Metadata) error {
+	path := p.serviceFilePath()
+
+	src, err := g.FS.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading service file: %v", err)
+	}
+
+	updated, err := generate.UpdateServiceFile(src, md)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(src, updated) {
+		return nil
+	}
+
+	err = g.FS.WriteFile(path, updated, files.DefaultPerm)
+	if err != nil {
+		return fmt.Errorf("writing service file: %v", err)
+	}
+
+	return nil
+}
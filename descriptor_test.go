@@ -0,0 +1,247 @@
+package seed
+
+import (
+	"net/http"
+	"path/filepath"
+	"seed/files"
+	"seed/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRoute_RemoveRoute(t *testing.T) {
+	const projectName = "routetest"
+
+	g := memoryGenerator(t, projectName)
+	p := project{name: projectName, dir: filepath.Join(g.Root, projectName)}
+
+	route := metadata.Route{
+		Path:        "/users",
+		HttpMethods: []string{http.MethodGet},
+		HandlerName: "Users",
+	}
+
+	err := g.AddRoute(projectName, route)
+	if err != nil {
+		t.Fatalf("AddRoute() failed = %v", err)
+	}
+
+	collision := route
+	collision.HandlerName = "OtherUsers"
+
+	err = g.AddRoute(projectName, collision)
+	assert.Error(t, err, "path and method collision should be reported")
+
+	md, err := g.loadDescriptor(p, false)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Len(t, md.Routes, 2)
+	assert.Equal(t, route, md.Routes[1])
+
+	service, err := g.FS.ReadFile(p.serviceFilePath())
+	if err != nil {
+		t.Fatalf("reading service file: %v", err)
+	}
+
+	assert.Contains(t, string(service), "func (s *Server) Users() http.HandlerFunc {")
+	assert.Contains(t, string(service), `[]byte("I'm alive!")`, "existing code should be kept")
+
+	err = g.RemoveRoute(projectName, "Users")
+	if err != nil {
+		t.Fatalf("RemoveRoute() failed = %v", err)
+	}
+
+	err = g.RemoveRoute(projectName, "Users")
+	assert.Error(t, err, "removing a missing route should fail")
+
+	md, err = g.loadDescriptor(p, false)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Len(t, md.Routes, 1)
+
+	report, err := g.Check(projectName, false)
+	if err != nil {
+		t.Fatalf("Check() failed = %v", err)
+	}
+
+	assert.Equal(t, []string{"Users"}, report.Orphans)
+
+	report, err = g.Check(projectName, true)
+	if err != nil {
+		t.Fatalf("Check() with deprecate failed = %v", err)
+	}
+
+	assert.True(t, report.OK())
+	assert.Equal(t, []string{"Users"}, report.Deprecated)
+}
+
+func TestRemoveRoute_middlewarePaths(t *testing.T) {
+	const projectName = "routemwtest"
+
+	g := memoryGenerator(t, projectName)
+	p := project{name: projectName, dir: filepath.Join(g.Root, projectName)}
+
+	for _, route := range []metadata.Route{
+		{Path: "/users", HttpMethods: []string{http.MethodGet}, HandlerName: "Users"},
+		{Path: "/status", HttpMethods: []string{http.MethodGet}, HandlerName: "Status"},
+	} {
+		if err := g.AddRoute(projectName, route); err != nil {
+			t.Fatalf("AddRoute() failed = %v", err)
+		}
+	}
+
+	for _, mw := range []metadata.Middleware{
+		{HandlerName: "AuthMw", Paths: []string{"/users"}},
+		{HandlerName: "AuditMw", Paths: []string{"/users", "/status"}},
+	} {
+		if err := g.AddMiddleware(projectName, mw); err != nil {
+			t.Fatalf("AddMiddleware() failed = %v", err)
+		}
+	}
+
+	err := g.RemoveRoute(projectName, "Users")
+	assert.EqualError(t, err, `middleware "AuthMw" only applies to route "/users", remove it first with "seed middleware rm -handler AuthMw"`)
+
+	err = g.RemoveMiddleware(projectName, "AuthMw")
+	if err != nil {
+		t.Fatalf("RemoveMiddleware() failed = %v", err)
+	}
+
+	err = g.RemoveRoute(projectName, "Users")
+	if err != nil {
+		t.Fatalf("RemoveRoute() failed = %v", err)
+	}
+
+	md, err := g.loadDescriptor(p, false)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Len(t, md.Routes, 2)
+	if assert.Len(t, md.Middlwares, 1) {
+		assert.Equal(t, []string{"/status"}, md.Middlwares[0].Paths)
+	}
+}
+
+func TestAddMiddleware_RemoveMiddleware(t *testing.T) {
+	const projectName = "middlewaretest"
+
+	g := memoryGenerator(t, projectName)
+	p := project{name: projectName, dir: filepath.Join(g.Root, projectName)}
+
+	mw := metadata.Middleware{
+		HandlerName: "AuthMw",
+		Paths:       []string{"/"},
+		Priority:    10,
+	}
+
+	err := g.AddMiddleware(projectName, mw)
+	if err != nil {
+		t.Fatalf("AddMiddleware() failed = %v", err)
+	}
+
+	err = g.AddMiddleware(projectName, mw)
+	assert.Error(t, err, "handler name collision should be reported")
+
+	md, err := g.loadDescriptor(p, false)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Equal(t, []metadata.Middleware{mw}, md.Middlwares)
+
+	service, err := g.FS.ReadFile(p.serviceFilePath())
+	if err != nil {
+		t.Fatalf("reading service file: %v", err)
+	}
+
+	assert.Contains(t, string(service), "func (s *Server) AuthMw(next http.Handler) http.Handler {")
+
+	err = g.RemoveMiddleware(projectName, "AuthMw")
+	if err != nil {
+		t.Fatalf("RemoveMiddleware() failed = %v", err)
+	}
+
+	md, err = g.loadDescriptor(p, false)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Empty(t, md.Middlwares)
+}
+
+func TestOpenAPI(t *testing.T) {
+	const projectName = "openapitest"
+
+	g := memoryGenerator(t, projectName)
+
+	err := g.AddRoute(projectName, metadata.Route{
+		Path:        "/users/{id:[0-9]+}",
+		HttpMethods: []string{http.MethodGet},
+		HandlerName: "User",
+	})
+	if err != nil {
+		t.Fatalf("AddRoute() failed = %v", err)
+	}
+
+	doc, err := g.OpenAPI(projectName)
+	if err != nil {
+		t.Fatalf("OpenAPI() failed = %v", err)
+	}
+
+	assert.Equal(t, projectName, doc.Info.Title)
+	assert.Equal(t, "Index", doc.Paths["/"].Get.OperationID)
+	assert.Equal(t, "User", doc.Paths["/users/{id}"].Get.OperationID)
+
+	_, err = g.OpenAPI("missing")
+	assert.Error(t, err)
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	const projectName = "tsclienttest"
+
+	g := memoryGenerator(t, projectName)
+
+	err := g.AddRoute(projectName, metadata.Route{
+		Path:        "/users/{id:[0-9]+}",
+		HttpMethods: []string{http.MethodGet},
+		HandlerName: "User",
+	})
+	if err != nil {
+		t.Fatalf("AddRoute() failed = %v", err)
+	}
+
+	ts, err := g.TypeScriptClient(projectName)
+	if err != nil {
+		t.Fatalf("TypeScriptClient() failed = %v", err)
+	}
+
+	assert.Contains(t, string(ts), "export async function index(client: Client): Promise<Response> {")
+	assert.Contains(t, string(ts), "export async function user(client: Client, id: string): Promise<Response> {")
+
+	_, err = g.TypeScriptClient("missing")
+	assert.Error(t, err)
+}
+
+// memoryGenerator returns a Generator backed by an in-memory filesystem, in
+// which the project was initialized.
+func memoryGenerator(t *testing.T, projectName string) *Generator {
+	g := &Generator{Root: "/projects", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	err = g.InitProject(projectName, InitOptions{})
+	if err != nil {
+		t.Fatalf("InitProject(%q) failed = %v", projectName, err)
+	}
+
+	return g
+}
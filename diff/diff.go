@@ -0,0 +1,208 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// op is a single line of an edit script.
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns the unified diff turning a into b, using the given names in
+// the header. It returns an empty string if the contents are equal.
+func Unified(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	ops := edits(splitLines(a), splitLines(b))
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+
+	for _, h := range hunks(ops) {
+		h.write(&buf, ops)
+	}
+
+	return buf.String()
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// edits returns the shortest edit script turning a into b, based on their
+// longest common subsequence.
+func edits(a, b []string) []op {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+
+	return ops
+}
+
+// hunk is a range of the edit script, along with the line numbers it starts
+// at in both files.
+type hunk struct {
+	start, end     int
+	aStart, bStart int
+}
+
+// hunks groups the changes of the edit script, keeping the given number of
+// unchanged lines around them. Changes closer than twice that are merged into
+// a single hunk.
+func hunks(ops []op) []hunk {
+	var (
+		result []hunk
+		aLine  = 1
+		bLine  = 1
+	)
+
+	// lines[k] holds the line numbers in a and b before ops[k].
+	lines := make([][2]int, len(ops)+1)
+
+	for k, o := range ops {
+		lines[k] = [2]int{aLine, bLine}
+
+		if o.kind != '+' {
+			aLine++
+		}
+
+		if o.kind != '-' {
+			bLine++
+		}
+	}
+
+	lines[len(ops)] = [2]int{aLine, bLine}
+
+	for k := 0; k < len(ops); k++ {
+		if ops[k].kind == ' ' {
+			continue
+		}
+
+		start := k - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while the next change is close enough.
+		end := k
+		for next := k; next < len(ops); next++ {
+			if ops[next].kind == ' ' {
+				if next-end > 2*context {
+					break
+				}
+
+				continue
+			}
+
+			end = next
+		}
+
+		end += context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		result = append(result, hunk{
+			start:  start,
+			end:    end,
+			aStart: lines[start][0],
+			bStart: lines[start][1],
+		})
+
+		k = end
+	}
+
+	return result
+}
+
+func (h hunk) write(buf *bytes.Buffer, ops []op) {
+	aCount, bCount := 0, 0
+
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != '+' {
+			aCount++
+		}
+
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(h.aStart, aCount), hunkRange(h.bStart, bCount))
+
+	for _, o := range ops[h.start:h.end] {
+		buf.WriteByte(o.kind)
+		buf.WriteString(o.line)
+
+		if !strings.HasSuffix(o.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the range of a hunk in one of the files. Empty ranges
+// refer to the line before them, as in GNU diff.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
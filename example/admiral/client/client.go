@@ -0,0 +1,140 @@
+// Package client calls the endpoints of the admiral service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the endpoints of the admiral service. Its fields can be changed
+// until the first call.
+type Client struct {
+	// BaseURL is the URL the service is served at, e.g.
+	// "http://localhost:8080". Paths of the routes are appended to it.
+	BaseURL string
+
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+}
+
+// New returns a Client calling the service served at baseURL, using
+// http.DefaultClient.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is returned for the responses of the service with a status code
+// outside of the 2xx range.
+type Error struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Param, In and Message describe the error written by the service, if
+	// the body of the response holds one.
+	Param   string
+	In      string
+	Message string
+
+	// Body is the body of the response.
+	Body []byte
+}
+
+func (e *Error) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+
+	switch {
+	case e.Message == "":
+		return status
+	case e.Param == "":
+		return status + ": " + e.Message
+	default:
+		return fmt.Sprintf("%s: %s %s %s", status, e.In, e.Param, e.Message)
+	}
+}
+
+// Index calls the GET / route of the service.
+// The caller is responsible for closing the body of the response.
+func (c *Client) Index(ctx context.Context) (*http.Response, error) {
+	r, err := c.newRequest(ctx, http.MethodGet, "/", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(r)
+}
+
+// newRequest returns a request to the path of the service, holding the query
+// if it is not nil, and the body encoded as JSON if it is not nil.
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request body: %v", err)
+		}
+
+		reader = bytes.NewReader(b)
+	}
+
+	r, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	return r.WithContext(ctx), nil
+}
+
+// send sends the request, and returns an *Error if the status code of the
+// response is outside of the 2xx range.
+func (c *Client) send(r *http.Request) (*http.Response, error) {
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+
+	e := &Error{StatusCode: res.StatusCode}
+
+	e.Body, err = ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%v, reading body: %v", e, err)
+	}
+
+	var body struct {
+		Error struct {
+			Param   string `json:"param"`
+			In      string `json:"in"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if json.Unmarshal(e.Body, &body) == nil {
+		e.Param, e.In, e.Message = body.Error.Param, body.Error.In, body.Error.Message
+	}
+
+	return nil, e
+}
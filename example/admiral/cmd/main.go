@@ -1,14 +1,89 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"seed/example/admiral"
 	"seed/example/admiral/gen"
+	"syscall"
+	"time"
 )
 
 func main() {
-	service := gen.New(&admiral.Server{})
+	cfg, err := gen.LoadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	log.Fatal(http.ListenAndServe(":8080", service))
+	service := gen.New(&admiral.Server{}, cfg)
+
+	server := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      service,
+		IdleTimeout:  cfg.IdleTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	err = run(server, service, cfg.ShutdownTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the service until SIGINT or SIGTERM is received, then stops
+// accepting connections and waits for in-flight requests to complete, for at
+// most drain. The lifecycle hooks of the service run before serving and after
+// draining, or once the server failed if it did.
+func run(server *http.Server, service *gen.Service, drain time.Duration) error {
+	err := service.OnStart(context.Background())
+	if err != nil {
+		return fmt.Errorf("starting service: %v", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", server.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errs:
+		ctx, cancel := context.WithTimeout(context.Background(), drain)
+		defer cancel()
+
+		if shutdownErr := service.OnShutdown(ctx); shutdownErr != nil {
+			log.Printf("shutting down service: %v", shutdownErr)
+		}
+
+		return fmt.Errorf("serving: %v", err)
+	case sig := <-stop:
+		log.Printf("received %v, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("draining connections: %v", err)
+	}
+
+	err = service.OnShutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("shutting down service: %v", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	mux "github.com/gorilla/mux"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Error describes a parameter or a field of a request which is missing or
+// malformed, or any other reason a request failed.
+type Error struct {
+	// Param is the name of the parameter or field, if any.
+	Param string `json:"param,omitempty"`
+
+	// In is where the parameter is found: "path", "query", "header" or
+	// "body".
+	In string `json:"in,omitempty"`
+
+	// Message explains what is wrong.
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Param == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s parameter %q: %s", e.In, e.Param, e.Message)
+}
+
+// WriteError answers the request with the status code, and the error encoded
+// as JSON in the body.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Message: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error *Error `json:"error"`
+	}{e})
+}
+
+// lookup returns the raw value of a parameter, and whether the request holds it.
+func lookup(r *http.Request, in, name string) (string, bool) {
+	switch in {
+	case "path":
+		value, ok := mux.Vars(r)[name]
+		return value, ok
+	case "query":
+		values := r.URL.Query()[name]
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	default:
+		values := r.Header[http.CanonicalHeaderKey(name)]
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	}
+}
+func missing(in, name string) error {
+	return &Error{
+		In:      in,
+		Message: "is required",
+		Param:   name,
+	}
+}
+func invalid(in, name string, err error) error {
+	return &Error{
+		In:      in,
+		Message: err.Error(),
+		Param:   name,
+	}
+}
+func parseString(raw string) (string, error) {
+	return raw, nil
+}
+func parseInt(raw string) (int, error) {
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", raw)
+	}
+	return v, nil
+}
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+func parseUUID(raw string) (string, error) {
+	if !uuidPattern.MatchString(raw) {
+		return "", fmt.Errorf("%q is not a UUID", raw)
+	}
+	return raw, nil
+}
+func parseBool(raw string) (bool, error) {
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a boolean", raw)
+	}
+	return v, nil
+}
+func parseTime(raw string) (time.Time, error) {
+	v, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time", raw)
+	}
+	return v, nil
+}
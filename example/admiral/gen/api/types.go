@@ -0,0 +1,13 @@
+package api
+
+// HTTPError is an error returned by a typed handler to answer the request with
+// a specific status code. Any other error is answered with a 500 Internal
+// Server Error, without exposing the error's message.
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
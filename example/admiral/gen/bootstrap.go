@@ -1,15 +1,32 @@
 package gen
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	mux "github.com/gorilla/mux"
+	"io"
+	"net"
 	"net/http"
-
-	"github.com/gorilla/mux"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Service is the struct that will be exposed to serve HTTP traffic.
 type Service struct {
-	router      *mux.Router
-	serviceImpl AdmiralService
+	router       *mux.Router
+	serviceImpl  AdmiralService
+	config       Config
+	accessLogger AccessLogger
 }
 
 // ServeHTTP is what ultimately allows this service to be used by the standard library's
@@ -21,49 +38,341 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // Route is a struct that holds the path, the handler to be called when that path is hit, and
 // which list of methods it should serve.
 type route struct {
-	path    string
-	handler http.HandlerFunc
-	methods []string
+	name        string
+	path        string
+	handler     http.HandlerFunc
+	methods     []string
+	strictSlash bool
 }
 
 // New returns a new service implementation, using the service as a dependency. It also sets up the routes
-// and the middlewares.
-func New(service AdmiralService) *Service {
+// and the middlewares. The settings of the service are available through Config.
+func New(service AdmiralService, cfg Config) *Service {
 	s := &Service{
-		router:      mux.NewRouter(),
-		serviceImpl: service,
+		accessLogger: accessLoggerOf(service),
+		config:       cfg,
+		router:       mux.NewRouter(),
+		serviceImpl:  service,
 	}
 
 	s.routes()
-	s.middlewares()
 
 	return s
 }
 
-// routes sets up the routes to be served by the service
+// Config returns the settings the service was created with.
+func (s *Service) Config() Config {
+	return s.config
+}
+
+// OnStart runs the start hook of the service implementation, if it implements
+// StartHook. It is meant to be called before the server starts accepting
+// connections.
+func (s *Service) OnStart(ctx context.Context) error {
+	if h, ok := s.serviceImpl.(StartHook); ok {
+		return h.OnStart(ctx)
+	}
+
+	return nil
+}
+
+// OnShutdown runs the shutdown hook of the service implementation, if it
+// implements ShutdownHook. It is meant to be called once the server stopped
+// serving requests, with a context holding the deadline of the shutdown.
+func (s *Service) OnShutdown(ctx context.Context) error {
+	if h, ok := s.serviceImpl.(ShutdownHook); ok {
+		return h.OnShutdown(ctx)
+	}
+
+	return nil
+}
+
+// routes sets up the routes to be served by the service, wrapping each handler
+// in the middlewares that apply to its path
 func (s *Service) routes() {
 	routes := []route{
 		{
-			path:    "/",
-			handler: s.serviceImpl.Index(),
-			methods: []string{http.MethodGet},
+			handler:     s.serviceImpl.Index(),
+			methods:     []string{http.MethodGet},
+			name:        "Index",
+			path:        "/",
+			strictSlash: true,
 		},
 	}
 
+	mws := s.middlewares()
+
 	for _, route := range routes {
-		s.router.StrictSlash(true).
-			HandleFunc(route.path, route.handler).
-			Methods(route.methods...)
+		var handler http.Handler = route.handler
+
+		// Wrapping starts with the lowest priority middleware, so that the
+		// highest priority one ends up being the outermost.
+		for i := len(mws) - 1; i >= 0; i-- {
+			if appliesTo(mws[i].paths, route.path) {
+				handler = mws[i].handler(handler)
+			}
+		}
+
+		// The requests are logged and measured outside of the middlewares, so
+		// that the ones rejected by them are accounted for too.
+		handler = s.accessLog(route.name, handler)
+
+		s.router.StrictSlash(route.strictSlash).Handle(route.path, handler).Methods(route.methods...)
+	}
+}
+
+// middleware is a struct that holds a middleware, the paths of the routes it
+// should be applied on, and its priority.
+type middleware struct {
+	handler  mux.MiddlewareFunc
+	paths    []string
+	priority int
+}
+
+// middlewares returns the middlewares of the service, ordered from highest to
+// lowest priority. Middlewares with the same priority keep their declaration order.
+func (s *Service) middlewares() []middleware {
+	mws := []middleware{}
+
+	sort.SliceStable(mws, func(i, j int) bool {
+		return mws[i].priority > mws[j].priority
+	})
+
+	return mws
+}
+
+// appliesTo reports whether any of the paths matches the path template of a
+// route. "*" matches all the routes, a path ending with "*" matches the routes
+// starting with what precedes it, and any other path is matched either exactly
+// or as a glob.
+func appliesTo(paths []string, template string) bool {
+	for _, p := range paths {
+		if p == "*" || p == template {
+			return true
+		}
+
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(template, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+
+		if ok, _ := path.Match(p, template); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestIDHeader is the header holding the id of a request.
+const requestIDHeader = "X-Request-Id"
+
+// accessLog wraps the handler of a route, logging its requests to the access
+// logger of the service once answered. Requests whose handler panicked are
+// logged with a 500 Internal Server Error.
+func (s *Service) accessLog(handler string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(requestIDHeader, id)
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		served := false
+
+		defer func() {
+			status := rec.Status()
+			if !served {
+				status = http.StatusInternalServerError
+			}
+
+			s.accessLogger.LogAccess(AccessEntry{
+				Bytes:      rec.bytes,
+				Duration:   time.Since(start),
+				Handler:    handler,
+				Level:      accessLevel(status),
+				Method:     r.Method,
+				RemoteAddr: r.RemoteAddr,
+				RequestID:  id,
+				Status:     status,
+				Time:       start,
+				URI:        r.RequestURI,
+			})
+		}()
+
+		next.ServeHTTP(rec, r)
+		served = true
+	})
+}
+
+// accessLevel returns the level of the entry of a request answered with status.
+func accessLevel(status int) string {
+	switch {
+	case status >= 500:
+		return "error"
+	case status >= 400:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// newRequestID returns a random id for a request which has none.
+func newRequestID() string {
+	b := make([]byte, 8)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// accessLoggerOf returns the service implementation if it implements
+// AccessLogger, or an access logger writing to the standard error otherwise.
+func accessLoggerOf(service interface{}) AccessLogger {
+	if l, ok := service.(AccessLogger); ok {
+		return l
+	}
+
+	return NewAccessLogger(os.Stderr)
+}
+
+// NewAccessLogger returns an AccessLogger writing each entry to w on its own
+// line, in the json format.
+func NewAccessLogger(w io.Writer) AccessLogger {
+	return &writerLogger{w: w}
+}
+
+// writerLogger is an AccessLogger writing the entries to a writer, one at a
+// time.
+type writerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// LogAccess writes the entry on its own line.
+func (l *writerLogger) LogAccess(entry AccessEntry) {
+	line := formatEntry(entry)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.w.Write(line)
+}
+
+// accessField is a field of the entries of the access log.
+type accessField struct {
+	name  string
+	value interface{}
+}
+
+// accessFields returns the fields of the entry in the order they are written.
+// The duration is written in seconds.
+func accessFields(entry AccessEntry) []accessField {
+	return []accessField{
+		{"time", entry.Time.UTC().Format(time.RFC3339Nano)},
+		{"level", entry.Level},
+		{"request_id", entry.RequestID},
+		{"handler", entry.Handler},
+		{"method", entry.Method},
+		{"uri", entry.URI},
+		{"remote_addr", entry.RemoteAddr},
+		{"status", entry.Status},
+		{"bytes", entry.Bytes},
+		{"duration", entry.Duration.Seconds()},
+	}
+}
+
+// formatEntry returns the line of the entry as a JSON object.
+func formatEntry(entry AccessEntry) []byte {
+	var b bytes.Buffer
+
+	b.WriteByte('{')
+	for i, field := range accessFields(entry) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(field.name)
+		value, _ := json.Marshal(field.value)
+
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
 	}
+	b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+// responseRecorder is an http.ResponseWriter recording the status and the size
+// of the response written through it. It forwards http.Flusher and
+// http.Hijacker to the underlying http.ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status of the response, and writes it.
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes b to the response, and records its size.
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+
+	return n, err
 }
 
-// middlewares sets up the middlewares to be set up by the service
-func (s *Service) middlewares() {
-	mws := []mux.MiddlewareFunc{
-		s.serviceImpl.LoggerMw,
+// Flush sends the response written so far to the client, if the underlying
+// http.ResponseWriter is an http.Flusher.
+func (rec *responseRecorder) Flush() {
+	flusher, ok := rec.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	if rec.status == 0 {
+		rec.status = http.StatusOK
 	}
 
-	for _, mw := range mws {
-		s.router.Use(mw)
+	flusher.Flush()
+}
+
+// Hijack lets the handler take over the connection, if the underlying
+// http.ResponseWriter is an http.Hijacker.
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
 	}
+
+	return hijacker.Hijack()
+}
+
+// Status returns the status of the response, which is a 200 OK if the handler
+// wrote nothing.
+func (rec *responseRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+
+	return rec.status
 }
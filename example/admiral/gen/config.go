@@ -0,0 +1,225 @@
+package gen
+
+import (
+	"flag"
+	"fmt"
+	yaml "github.com/go-yaml/yaml"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the settings of the service, see LoadConfig.
+type Config struct {
+	// Address the service listens on.
+	Addr string
+
+	// Maximum duration for reading a whole request, including its body.
+	ReadTimeout time.Duration
+
+	// Maximum duration for writing a response.
+	WriteTimeout time.Duration
+
+	// Maximum duration to wait for the next request on a keep-alive connection.
+	IdleTimeout time.Duration
+
+	// Maximum duration for in-flight requests to complete on shutdown.
+	ShutdownTimeout time.Duration
+}
+
+// setting describes how a field of Config is loaded.
+type setting struct {
+	name, flag, env, def, usage string
+	required, isBool            bool
+	parse                       func(cfg *Config, value string) error
+}
+
+// settings are the settings of Config, in declaration order.
+var settings = []setting{
+	{
+		name:  "addr",
+		flag:  "addr",
+		env:   "ADMIRAL_ADDR",
+		def:   ":8080",
+		usage: "Address the service listens on.",
+		parse: func(cfg *Config, value string) error {
+			cfg.Addr = value
+			return nil
+		},
+	},
+	{
+		name:  "read_timeout",
+		flag:  "read-timeout",
+		env:   "ADMIRAL_READ_TIMEOUT",
+		def:   "5s",
+		usage: "Maximum duration for reading a whole request, including its body.",
+		parse: func(cfg *Config, value string) (err error) {
+			cfg.ReadTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name:  "write_timeout",
+		flag:  "write-timeout",
+		env:   "ADMIRAL_WRITE_TIMEOUT",
+		def:   "10s",
+		usage: "Maximum duration for writing a response.",
+		parse: func(cfg *Config, value string) (err error) {
+			cfg.WriteTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name:  "idle_timeout",
+		flag:  "idle-timeout",
+		env:   "ADMIRAL_IDLE_TIMEOUT",
+		def:   "2m",
+		usage: "Maximum duration to wait for the next request on a keep-alive connection.",
+		parse: func(cfg *Config, value string) (err error) {
+			cfg.IdleTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name:  "shutdown_timeout",
+		flag:  "shutdown-timeout",
+		env:   "ADMIRAL_SHUTDOWN_TIMEOUT",
+		def:   "30s",
+		usage: "Maximum duration for in-flight requests to complete on shutdown.",
+		parse: func(cfg *Config, value string) (err error) {
+			cfg.ShutdownTimeout, err = time.ParseDuration(value)
+			return err
+		},
+	},
+}
+
+// LoadConfig loads the settings of the service from, by order of precedence, the
+// command line flags in args, the environment variables, the YAML file given by
+// the -config flag or the ADMIRAL_CONFIG environment variable, and their defaults.
+// It fails listing the required settings which are set nowhere.
+func LoadConfig(args []string) (Config, error) {
+	var cfg Config
+
+	fs := flag.NewFlagSet("admiral", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("ADMIRAL_CONFIG"), "YAML file holding settings, which environment variables and flags override.")
+
+	flags := make([]*flagValue, len(settings))
+	for i, s := range settings {
+		flags[i] = &flagValue{
+			isBool: s.isBool,
+			value:  s.def,
+		}
+		fs.Var(flags[i], s.flag, s.usage)
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return cfg, err
+	}
+
+	fromFile := make(map[string]string)
+	if *file != "" {
+		fromFile, err = readConfigFile(*file)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	var missing []string
+
+	for i, s := range settings {
+		value, from, set := s.def, "default", s.def != ""
+
+		if v, ok := fromFile[s.name]; ok {
+			value, from, set = v, *file, true
+		}
+
+		if v := os.Getenv(s.env); v != "" {
+			value, from, set = v, "$"+s.env, true
+		}
+
+		if flags[i].set {
+			value, from, set = flags[i].value, "-"+s.flag, true
+		}
+
+		if !set {
+			if s.required {
+				missing = append(missing, fmt.Sprintf("%s (-%s or $%s)", s.name, s.flag, s.env))
+			}
+
+			continue
+		}
+
+		err := s.parse(&cfg, value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s from %s: %v", s.name, from, err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return cfg, fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))
+	}
+
+	return cfg, nil
+}
+
+// flagValue is a flag.Value recording whether the flag was set, so that flags
+// only override the other sources when given.
+type flagValue struct {
+	value       string
+	set, isBool bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value, v.set = value, true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// readConfigFile reads the YAML file at path, which maps the names of settings
+// to their values.
+func readConfigFile(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %v", err)
+	}
+
+	var raw map[string]interface{}
+
+	err = yaml.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %v", path, err)
+	}
+
+	known := make(map[string]bool)
+	for _, s := range settings {
+		known[s.name] = true
+	}
+
+	values := make(map[string]string)
+
+	for name, v := range raw {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown setting %q in %s", name, path)
+		}
+
+		switch v.(type) {
+		case nil:
+			continue
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("setting %q in %s is not a single value", name, path)
+		}
+
+		values[name] = fmt.Sprint(v)
+	}
+
+	return values, nil
+}
@@ -1,6 +1,10 @@
 package gen
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
 // AdmiralService encapsulates the handler interface, which holds all the methods to be called
 // by the server, and middleware interface, which contains all the middlewares to be added to the service.
@@ -16,6 +20,49 @@ type AdmiralHandler interface {
 }
 
 // AdmiralMiddleware is the interface for all the middlewares that will be added to all of the paths.
-type AdmiralMiddleware interface {
-	LoggerMw(http.Handler) http.Handler
+type AdmiralMiddleware interface{}
+
+// StartHook is implemented by services which need to run something before the
+// server starts accepting connections, see Service.OnStart.
+type StartHook interface {
+	OnStart(ctx context.Context) error
+}
+
+// ShutdownHook is implemented by services which need to release resources once
+// the server stopped serving requests, see Service.OnShutdown.
+type ShutdownHook interface {
+	OnShutdown(ctx context.Context) error
+}
+
+// AccessEntry is the entry of the access log for a request served by a route.
+type AccessEntry struct {
+	// Time is when the request was received.
+	Time time.Time
+
+	// Level is "error" for 5xx responses, "warn" for 4xx ones and "info"
+	// otherwise.
+	Level string
+
+	// RequestID is the X-Request-Id header of the request, or a random id if
+	// it had none. It is set on the response too.
+	RequestID string
+
+	// Handler is the name of the handler of the route serving the request.
+	Handler string
+
+	// Method, URI and RemoteAddr are the ones of the request.
+	Method, URI, RemoteAddr string
+
+	// Status and Bytes are the status and the size of the body of the response.
+	Status, Bytes int
+
+	// Duration is how long the request took to be served.
+	Duration time.Duration
+}
+
+// AccessLogger is implemented by services which write the access log
+// themselves, e.g. to their own logger. Otherwise the entries are written to
+// the standard error in the json format, see NewAccessLogger.
+type AccessLogger interface {
+	LogAccess(entry AccessEntry)
 }
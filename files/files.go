@@ -1,7 +1,6 @@
 package files
 
 import (
-	"fmt"
 	"os"
 	"runtime"
 )
@@ -12,7 +11,6 @@ const (
 )
 
 var (
-	Pwd         string
 	DefaultPerm os.FileMode
 )
 
@@ -23,11 +21,4 @@ func init() {
 	default:
 		DefaultPerm = PermLinux
 	}
-
-	var err error
-
-	Pwd, err = os.Getwd()
-	if err != nil {
-		panic(fmt.Sprintf("setting up pwd: %v", err))
-	}
 }
@@ -0,0 +1,61 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FS is the filesystem projects are read from and written to. Its methods
+// behave like their counterparts in the os, io/ioutil and path/filepath
+// packages.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Stat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	RemoveAll(path string) error
+	TempDir(dir, pattern string) (string, error)
+	Walk(root string, fn filepath.WalkFunc) error
+}
+
+// OS is the FS backed by the operating system's filesystem.
+type OS struct{}
+
+func (OS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (OS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(name, data, perm)
+}
+
+func (OS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (OS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (OS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (OS) TempDir(dir, pattern string) (string, error) {
+	return ioutil.TempDir(dir, pattern)
+}
+
+func (OS) Walk(root string, fn filepath.WalkFunc) error {
+	return filepath.Walk(root, fn)
+}
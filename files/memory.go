@@ -0,0 +1,342 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an FS that holds everything in memory. It is safe for concurrent
+// use. The zero value is an empty filesystem with only the root folder.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]*memEntry
+	temps   int
+}
+
+type memEntry struct {
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// NewMemory returns an empty in-memory filesystem.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) init() {
+	if m.entries == nil {
+		m.entries = map[string]*memEntry{
+			string(filepath.Separator): {mode: os.ModeDir | 0755},
+		}
+	}
+}
+
+// clean returns the absolute, cleaned form of the path, which is used as the
+// key of the entries. Relative paths are relative to the root.
+func clean(name string) string {
+	return filepath.Join(string(filepath.Separator), name)
+}
+
+func pathError(op, name string, err error) error {
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	e, ok := m.entries[clean(name)]
+	if !ok {
+		return nil, pathError("open", name, os.ErrNotExist)
+	}
+
+	if e.mode.IsDir() {
+		return nil, pathError("read", name, fmt.Errorf("is a directory"))
+	}
+
+	return append([]byte{}, e.data...), nil
+}
+
+func (m *Memory) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	key := clean(name)
+
+	parent, ok := m.entries[filepath.Dir(key)]
+	if !ok || !parent.mode.IsDir() {
+		return pathError("open", name, os.ErrNotExist)
+	}
+
+	e, ok := m.entries[key]
+	if ok && e.mode.IsDir() {
+		return pathError("open", name, fmt.Errorf("is a directory"))
+	}
+
+	mode := perm.Perm()
+	if ok {
+		mode = e.mode
+	}
+
+	m.entries[key] = &memEntry{
+		data:    append([]byte{}, data...),
+		mode:    mode,
+		modTime: time.Now(),
+	}
+
+	return nil
+}
+
+func (m *Memory) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	return m.mkdirAll(clean(path), perm)
+}
+
+func (m *Memory) mkdirAll(key string, perm os.FileMode) error {
+	e, ok := m.entries[key]
+	if ok {
+		if !e.mode.IsDir() {
+			return pathError("mkdir", key, fmt.Errorf("not a directory"))
+		}
+
+		return nil
+	}
+
+	err := m.mkdirAll(filepath.Dir(key), perm)
+	if err != nil {
+		return err
+	}
+
+	m.entries[key] = &memEntry{mode: os.ModeDir | perm.Perm(), modTime: time.Now()}
+
+	return nil
+}
+
+func (m *Memory) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	e, ok := m.entries[clean(name)]
+	if !ok {
+		return pathError("chmod", name, os.ErrNotExist)
+	}
+
+	e.mode = e.mode&os.ModeType | mode.Perm()
+
+	return nil
+}
+
+func (m *Memory) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	key := clean(name)
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, pathError("stat", name, os.ErrNotExist)
+	}
+
+	return memInfo{name: filepath.Base(key), entry: *e}, nil
+}
+
+func (m *Memory) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	oldKey, newKey := clean(oldpath), clean(newpath)
+
+	e, ok := m.entries[oldKey]
+	if !ok {
+		return pathError("rename", oldpath, os.ErrNotExist)
+	}
+
+	parent, ok := m.entries[filepath.Dir(newKey)]
+	if !ok || !parent.mode.IsDir() {
+		return pathError("rename", newpath, os.ErrNotExist)
+	}
+
+	if existing, ok := m.entries[newKey]; ok {
+		if existing.mode.IsDir() != e.mode.IsDir() || (existing.mode.IsDir() && len(m.children(newKey)) > 0) {
+			return pathError("rename", newpath, os.ErrExist)
+		}
+	}
+
+	for _, child := range m.children(oldKey) {
+		m.entries[newKey+strings.TrimPrefix(child, oldKey)] = m.entries[child]
+		delete(m.entries, child)
+	}
+
+	m.entries[newKey] = e
+	delete(m.entries, oldKey)
+
+	return nil
+}
+
+func (m *Memory) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	key := clean(path)
+
+	for _, child := range m.children(key) {
+		delete(m.entries, child)
+	}
+
+	delete(m.entries, key)
+
+	return nil
+}
+
+func (m *Memory) TempDir(dir, pattern string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	for {
+		m.temps++
+
+		name := filepath.Join(dir, fmt.Sprintf("%s%d", pattern, m.temps))
+		if _, ok := m.entries[clean(name)]; ok {
+			continue
+		}
+
+		err := m.mkdirAll(clean(name), 0700)
+		if err != nil {
+			return "", err
+		}
+
+		return name, nil
+	}
+}
+
+// Walk walks the tree rooted at root in lexical order, just like
+// filepath.Walk. The paths passed to fn are built on root, as given.
+func (m *Memory) Walk(root string, fn filepath.WalkFunc) error {
+	m.mu.Lock()
+	m.init()
+
+	rootKey := clean(root)
+
+	e, ok := m.entries[rootKey]
+	if !ok {
+		m.mu.Unlock()
+		return fn(root, nil, pathError("lstat", root, os.ErrNotExist))
+	}
+
+	keys := append([]string{rootKey}, m.children(rootKey)...)
+	infos := make(map[string]memInfo, len(keys))
+
+	for _, key := range keys {
+		infos[key] = memInfo{name: filepath.Base(key), entry: *m.entries[key]}
+	}
+
+	infos[rootKey] = memInfo{name: filepath.Base(rootKey), entry: *e}
+	m.mu.Unlock()
+
+	// Sorting by path components gives the same order as filepath.Walk.
+	sort.Slice(keys, func(i, j int) bool {
+		return lessPath(keys[i], keys[j])
+	})
+
+	var skipped []string
+
+	for _, key := range keys {
+		if isSkipped(key, skipped) {
+			continue
+		}
+
+		path := filepath.Join(root, strings.TrimPrefix(key, rootKey))
+		info := infos[key]
+
+		err := fn(path, info, nil)
+		if err == filepath.SkipDir {
+			if info.IsDir() {
+				skipped = append(skipped, key)
+				continue
+			}
+
+			skipped = append(skipped, filepath.Dir(key))
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// children returns the keys of every entry under the folder, at any depth.
+func (m *Memory) children(key string) []string {
+	prefix := key + string(filepath.Separator)
+	if key == string(filepath.Separator) {
+		prefix = key
+	}
+
+	var result []string
+
+	for k := range m.entries {
+		if k != key && strings.HasPrefix(k, prefix) {
+			result = append(result, k)
+		}
+	}
+
+	return result
+}
+
+func lessPath(a, b string) bool {
+	as := strings.Split(a, string(filepath.Separator))
+	bs := strings.Split(b, string(filepath.Separator))
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+
+	return len(as) < len(bs)
+}
+
+func isSkipped(key string, skipped []string) bool {
+	for _, dir := range skipped {
+		if key == dir || strings.HasPrefix(key, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memInfo is the os.FileInfo of a Memory entry.
+type memInfo struct {
+	name  string
+	entry memEntry
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.entry.data)) }
+func (i memInfo) Mode() os.FileMode  { return i.entry.mode }
+func (i memInfo) ModTime() time.Time { return i.entry.modTime }
+func (i memInfo) IsDir() bool        { return i.entry.mode.IsDir() }
+func (i memInfo) Sys() interface{}   { return nil }
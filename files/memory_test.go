@@ -0,0 +1,100 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemory_matchesOS runs the same operations on a Memory and on a folder
+// of the operating system's filesystem, and compares the resulting trees.
+func TestMemory_matchesOS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files-test-")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, fs := range []FS{OS{}, NewMemory()} {
+		root := filepath.Join(dir, "root")
+
+		assert.NoError(t, fs.MkdirAll(filepath.Join(root, "a", "b"), DefaultPerm))
+		assert.NoError(t, fs.MkdirAll(filepath.Join(root, "a-b"), DefaultPerm))
+		assert.NoError(t, fs.WriteFile(filepath.Join(root, "a", "b", "c.go"), []byte("c"), DefaultPerm))
+		assert.NoError(t, fs.WriteFile(filepath.Join(root, "a-b", "d.go"), []byte("d"), DefaultPerm))
+		assert.NoError(t, fs.WriteFile(filepath.Join(root, "e.go"), []byte("e"), DefaultPerm))
+
+		err := fs.WriteFile(filepath.Join(root, "missing", "f.go"), nil, DefaultPerm)
+		assert.True(t, os.IsNotExist(err), "writing into a missing folder should fail")
+
+		assert.NoError(t, fs.Rename(filepath.Join(root, "a"), filepath.Join(root, "z")))
+		assert.NoError(t, fs.RemoveAll(filepath.Join(root, "a-b", "d.go")))
+
+		b, err := fs.ReadFile(filepath.Join(root, "z", "b", "c.go"))
+		assert.NoError(t, err)
+		assert.Equal(t, "c", string(b))
+
+		_, err = fs.Stat(filepath.Join(root, "a"))
+		assert.True(t, os.IsNotExist(err), "renamed folder should be gone")
+
+		temp, err := fs.TempDir(root, ".staging-")
+		assert.NoError(t, err)
+		assert.Equal(t, root, filepath.Dir(temp))
+
+		assert.NoError(t, fs.RemoveAll(temp))
+
+		var walked []string
+
+		err = fs.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			walked = append(walked, rel)
+
+			return nil
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{
+			".",
+			"a-b",
+			"e.go",
+			"z",
+			filepath.Join("z", "b"),
+			filepath.Join("z", "b", "c.go"),
+		}, walked, "%T", fs)
+
+		assert.NoError(t, fs.RemoveAll(root))
+	}
+}
+
+func TestMemory_skipDir(t *testing.T) {
+	fs := NewMemory()
+
+	assert.NoError(t, fs.MkdirAll("/root/skip", DefaultPerm))
+	assert.NoError(t, fs.WriteFile("/root/skip/a.go", nil, DefaultPerm))
+	assert.NoError(t, fs.WriteFile("/root/b.go", nil, DefaultPerm))
+
+	var walked []string
+
+	err := fs.Walk("/root", func(path string, info os.FileInfo, err error) error {
+		walked = append(walked, path)
+
+		if info.IsDir() && info.Name() == "skip" {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/root", "/root/b.go", "/root/skip"}, walked)
+}
@@ -0,0 +1,268 @@
+package generate
+
+import (
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// accessLogInterfaces adds the entry of the access log, and the interface
+// through which the service implementation can write the access log itself.
+func accessLogInterfaces(f *File, md metadata.Metadata) {
+	if md.AccessLog == nil {
+		return
+	}
+
+	f.Comment("// AccessEntry is the entry of the access log for a request served by a route.")
+	f.Type().Id("AccessEntry").Struct(
+		Comment("// Time is when the request was received."),
+		Id("Time").Qual("time", "Time"),
+		Line(),
+		Comment("// Level is \"error\" for 5xx responses, \"warn\" for 4xx ones and \"info\""),
+		Comment("// otherwise."),
+		Id("Level").String(),
+		Line(),
+		Comment("// RequestID is the "+metadata.RequestIDHeader+" header of the request, or a random id if"),
+		Comment("// it had none. It is set on the response too."),
+		Id("RequestID").String(),
+		Line(),
+		Comment("// Handler is the name of the handler of the route serving the request."),
+		Id("Handler").String(),
+		Line(),
+		Comment("// Method, URI and RemoteAddr are the ones of the request."),
+		List(Id("Method"), Id("URI"), Id("RemoteAddr")).String(),
+		Line(),
+		Comment("// Status and Bytes are the status and the size of the body of the response."),
+		List(Id("Status"), Id("Bytes")).Int(),
+		Line(),
+		Comment("// Duration is how long the request took to be served."),
+		Id("Duration").Qual("time", "Duration"),
+	)
+
+	f.Comment("// AccessLogger is implemented by services which write the access log")
+	f.Comment("// themselves, e.g. to their own logger. Otherwise the entries are written to")
+	f.Commentf("// the standard error in the %s format, see NewAccessLogger.", md.AccessLog.LogFormat())
+	f.Type().Id("AccessLogger").Interface(
+		Id(metadata.HookAccessLog).Params(Id("entry").Id("AccessEntry")),
+	)
+}
+
+// bootstrapAccessLog adds the middleware logging the requests served by each
+// route, and the default access logger writing the entries in the format of
+// the descriptor.
+func bootstrapAccessLog(f *File, md metadata.Metadata) {
+	if md.AccessLog == nil {
+		return
+	}
+
+	f.Comment("// requestIDHeader is the header holding the id of a request.")
+	f.Const().Id("requestIDHeader").Op("=").Lit(metadata.RequestIDHeader)
+
+	f.Comment("// accessLog wraps the handler of a route, logging its requests to the access")
+	f.Comment("// logger of the service once answered. Requests whose handler panicked are")
+	f.Comment("// logged with a 500 Internal Server Error.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("accessLog").Params(
+		Id("handler").String(),
+		Id("next").Qual("net/http", "Handler"),
+	).Qual("net/http", "Handler").Block(
+		Return(Qual("net/http", "HandlerFunc").Call(httpHandlerFunc().Block(
+			Id("id").Op(":=").Id("r").Dot("Header").Dot("Get").Call(Id("requestIDHeader")),
+			If(Id("id").Op("==").Lit("")).Block(
+				Id("id").Op("=").Id("newRequestID").Call(),
+				Id("r").Dot("Header").Dot("Set").Call(Id("requestIDHeader"), Id("id")),
+			),
+			Empty(),
+			Id("w").Dot("Header").Call().Dot("Set").Call(Id("requestIDHeader"), Id("id")),
+			Empty(),
+			Id("start").Op(":=").Qual("time", "Now").Call(),
+			Id("rec").Op(":=").Op("&").Id("responseRecorder").Values(Dict{
+				Id("ResponseWriter"): Id("w"),
+			}),
+			Id("served").Op(":=").False(),
+			Empty(),
+			Defer().Func().Params().Block(
+				Id("status").Op(":=").Id("rec").Dot("Status").Call(),
+				If(Op("!").Id("served")).Block(
+					Id("status").Op("=").Qual("net/http", "StatusInternalServerError"),
+				),
+				Empty(),
+				Id("s").Dot("accessLogger").Dot(metadata.HookAccessLog).Call(Id("AccessEntry").Values(Dict{
+					Id("Time"):       Id("start"),
+					Id("Level"):      Id("accessLevel").Call(Id("status")),
+					Id("RequestID"):  Id("id"),
+					Id("Handler"):    Id("handler"),
+					Id("Method"):     Id("r").Dot("Method"),
+					Id("URI"):        Id("r").Dot("RequestURI"),
+					Id("RemoteAddr"): Id("r").Dot("RemoteAddr"),
+					Id("Status"):     Id("status"),
+					Id("Bytes"):      Id("rec").Dot("bytes"),
+					Id("Duration"):   Qual("time", "Since").Call(Id("start")),
+				})),
+			).Call(),
+			Empty(),
+			Id("next").Dot("ServeHTTP").Call(Id("rec"), Id("r")),
+			Id("served").Op("=").True(),
+		))),
+	)
+
+	f.Comment("// accessLevel returns the level of the entry of a request answered with status.")
+	f.Func().Id("accessLevel").Params(Id("status").Int()).String().Block(
+		Switch().Block(
+			Case(Id("status").Op(">=").Lit(500)).Block(
+				Return(Lit("error")),
+			),
+			Case(Id("status").Op(">=").Lit(400)).Block(
+				Return(Lit("warn")),
+			),
+			Default().Block(
+				Return(Lit("info")),
+			),
+		),
+	)
+
+	f.Comment("// newRequestID returns a random id for a request which has none.")
+	f.Func().Id("newRequestID").Params().String().Block(
+		Id("b").Op(":=").Make(Index().Byte(), Lit(8)),
+		Empty(),
+		List(Id("_"), Err()).Op(":=").Qual("crypto/rand", "Read").Call(Id("b")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Qual("strconv", "FormatInt").Call(Qual("time", "Now").Call().Dot("UnixNano").Call(), Lit(16))),
+		),
+		Empty(),
+		Return(Qual("encoding/hex", "EncodeToString").Call(Id("b"))),
+	)
+
+	f.Comment("// accessLoggerOf returns the service implementation if it implements")
+	f.Comment("// AccessLogger, or an access logger writing to the standard error otherwise.")
+	f.Func().Id("accessLoggerOf").Params(
+		Id("service").Interface(),
+	).Id("AccessLogger").Block(
+		If(List(Id("l"), Id("ok")).Op(":=").Id("service").Assert(Id("AccessLogger")), Id("ok")).Block(
+			Return(Id("l")),
+		),
+		Empty(),
+		Return(Id("NewAccessLogger").Call(Qual("os", "Stderr"))),
+	)
+
+	format := md.AccessLog.LogFormat()
+
+	f.Comment("// NewAccessLogger returns an AccessLogger writing each entry to w on its own")
+	f.Commentf("// line, in the %s format.", format)
+	f.Func().Id("NewAccessLogger").Params(Id("w").Qual("io", "Writer")).Id("AccessLogger").Block(
+		Return(Op("&").Id("writerLogger").Values(Dict{
+			Id("w"): Id("w"),
+		})),
+	)
+
+	f.Comment("// writerLogger is an AccessLogger writing the entries to a writer, one at a")
+	f.Comment("// time.")
+	f.Type().Id("writerLogger").Struct(
+		Id("mu").Qual("sync", "Mutex"),
+		Id("w").Qual("io", "Writer"),
+	)
+
+	f.Comment("// LogAccess writes the entry on its own line.")
+	f.Func().Params(
+		Id("l").Op("*").Id("writerLogger"),
+	).Id(metadata.HookAccessLog).Params(Id("entry").Id("AccessEntry")).Block(
+		Id("line").Op(":=").Id("formatEntry").Call(Id("entry")),
+		Empty(),
+		Id("l").Dot("mu").Dot("Lock").Call(),
+		Defer().Id("l").Dot("mu").Dot("Unlock").Call(),
+		Empty(),
+		Id("l").Dot("w").Dot("Write").Call(Id("line")),
+	)
+
+	entry := func(field string) *Statement {
+		return Id("entry").Dot(field)
+	}
+
+	fields := []struct {
+		name  string
+		value *Statement
+	}{
+		{"time", entry("Time").Dot("UTC").Call().Dot("Format").Call(Qual("time", "RFC3339Nano"))},
+		{"level", entry("Level")},
+		{"request_id", entry("RequestID")},
+		{"handler", entry("Handler")},
+		{"method", entry("Method")},
+		{"uri", entry("URI")},
+		{"remote_addr", entry("RemoteAddr")},
+		{"status", entry("Status")},
+		{"bytes", entry("Bytes")},
+		{"duration", entry("Duration").Dot("Seconds").Call()},
+	}
+
+	f.Comment("// accessField is a field of the entries of the access log.")
+	f.Type().Id("accessField").Struct(
+		Id("name").String(),
+		Id("value").Interface(),
+	)
+
+	f.Comment("// accessFields returns the fields of the entry in the order they are written.")
+	f.Comment("// The duration is written in seconds.")
+	f.Func().Id("accessFields").Params(Id("entry").Id("AccessEntry")).Index().Id("accessField").Block(
+		Return(Index().Id("accessField").ValuesFunc(func(g *Group) {
+			for _, field := range fields {
+				g.Line().Values(Lit(field.name), field.value)
+			}
+			g.Line()
+		})),
+	)
+
+	if format == metadata.FormatLogfmt {
+		f.Comment("// formatEntry returns the line of the entry in the logfmt format. Values holding")
+		f.Comment("// spaces, quotes or equal signs are quoted.")
+		f.Func().Id("formatEntry").Params(Id("entry").Id("AccessEntry")).Index().Byte().Block(
+			Var().Id("b").Qual("bytes", "Buffer"),
+			Empty(),
+			For(List(Id("i"), Id("field")).Op(":=").Range().Id("accessFields").Call(Id("entry"))).Block(
+				If(Id("i").Op(">").Lit(0)).Block(
+					Id("b").Dot("WriteByte").Call(LitRune(' ')),
+				),
+				Empty(),
+				Id("value").Op(":=").Qual("fmt", "Sprint").Call(Id("field").Dot("value")),
+				If(Id("value").Op("==").Lit("").Op("||").Qual("strings", "IndexFunc").Call(Id("value"), Id("needsQuote")).Op(">=").Lit(0)).Block(
+					Id("value").Op("=").Qual("strconv", "Quote").Call(Id("value")),
+				),
+				Empty(),
+				Id("b").Dot("WriteString").Call(Id("field").Dot("name").Op("+").Lit("=").Op("+").Id("value")),
+			),
+			Empty(),
+			Id("b").Dot("WriteByte").Call(LitRune('\n')),
+			Empty(),
+			Return(Id("b").Dot("Bytes").Call()),
+		)
+
+		f.Comment("// needsQuote reports whether a logfmt value holding c must be quoted.")
+		f.Func().Id("needsQuote").Params(Id("c").Rune()).Bool().Block(
+			Return(Id("c").Op("<=").LitRune(' ').Op("||").Id("c").Op("==").LitRune('=').Op("||").Id("c").Op("==").LitRune('"')),
+		)
+
+		return
+	}
+
+	f.Comment("// formatEntry returns the line of the entry as a JSON object.")
+	f.Func().Id("formatEntry").Params(Id("entry").Id("AccessEntry")).Index().Byte().Block(
+		Var().Id("b").Qual("bytes", "Buffer"),
+		Empty(),
+		Id("b").Dot("WriteByte").Call(LitRune('{')),
+		For(List(Id("i"), Id("field")).Op(":=").Range().Id("accessFields").Call(Id("entry"))).Block(
+			If(Id("i").Op(">").Lit(0)).Block(
+				Id("b").Dot("WriteByte").Call(LitRune(',')),
+			),
+			Empty(),
+			List(Id("name"), Id("_")).Op(":=").Qual("encoding/json", "Marshal").Call(Id("field").Dot("name")),
+			List(Id("value"), Id("_")).Op(":=").Qual("encoding/json", "Marshal").Call(Id("field").Dot("value")),
+			Empty(),
+			Id("b").Dot("Write").Call(Id("name")),
+			Id("b").Dot("WriteByte").Call(LitRune(':')),
+			Id("b").Dot("Write").Call(Id("value")),
+		),
+		Id("b").Dot("WriteString").Call(Lit("}\n")),
+		Empty(),
+		Return(Id("b").Dot("Bytes").Call()),
+	)
+}
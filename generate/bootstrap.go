@@ -0,0 +1,344 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"seed/consts"
+	"seed/metadata"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+const mux = "github.com/gorilla/mux"
+
+func BootstrapFile(md metadata.Metadata) ([]byte, error) {
+	gen := genPath(md)
+
+	f := NewFilePathName(gen, consts.GenFolder)
+
+	projectNameTitle := strings.Title(md.Name)
+
+	f.Comment("// Service is the struct that will be exposed to serve HTTP traffic.")
+	f.Type().Id("Service").StructFunc(func(g *Group) {
+		g.Id("router").Op("*").Qual(mux, "Router")
+		g.Id("serviceImpl").Qual(gen, projectNameTitle+"Service")
+		g.Id("config").Id("Config")
+
+		if md.Metrics != nil {
+			g.Id("metrics").Op("*").Id("metrics")
+		}
+
+		if md.AccessLog != nil {
+			g.Id("accessLogger").Id("AccessLogger")
+		}
+	})
+
+	f.Comment("// ServeHTTP is what ultimately allows this service to be " +
+		"used by the standard library's")
+	f.Comment("// listen and serve functions")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("ServeHTTP").Add(httpMethodParams()).Block(
+		Id("s").Dot("router").Dot("ServeHTTP").Call(
+			Id("w"),
+			Id("r"),
+		),
+	)
+
+	f.Comment("// Route is a struct that holds the path, the handler to " +
+		"be called when that path is hit, and")
+	f.Comment("// which list of methods it should serve.")
+
+	f.Type().Id("route").StructFunc(func(g *Group) {
+		if instrumented(md) {
+			g.Id("name").String()
+		}
+
+		g.Id("path").String()
+		g.Id("handler").Qual("net/http", "HandlerFunc")
+		g.Id("methods").Index().String()
+		g.Id("strictSlash").Bool()
+	})
+
+	f.Comment("// New returns a new service implementation, using the " +
+		"service as a dependency. It also sets up the routes")
+	f.Comment("// and the middlewares. The settings of the service are available through Config.")
+
+	f.Func().Id("New").Params(
+		Id("service").Qual(gen, projectNameTitle+"Service"),
+		Id("cfg").Id("Config"),
+	).Op("*").Qual(gen, "Service").Block(
+		Id("s").Op(":=").Op("&").Qual(gen, "Service").Values(
+			DictFunc(func(d Dict) {
+				d[Id("router")] = Qual(mux, "NewRouter").Call()
+				d[Id("serviceImpl")] = Id("service")
+				d[Id("config")] = Id("cfg")
+
+				if md.Metrics != nil {
+					d[Id("metrics")] = newMetrics(md)
+				}
+
+				if md.AccessLog != nil {
+					d[Id("accessLogger")] = Id("accessLoggerOf").Call(Id("service"))
+				}
+			}),
+		),
+		Empty(),
+		Id("s").Dot("routes").Call(),
+		Empty(),
+		Return(Id("s")),
+	)
+
+	f.Comment("// Config returns the settings the service was created with.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("Config").Params().Id("Config").Block(
+		Return(Id("s").Dot("config")),
+	)
+
+	bootstrapHooks(f)
+	bootstrapRoutes(f, md)
+	bootstrapTyped(f, md)
+	bootstrapMiddlewares(f, md)
+	bootstrapHealth(f, md)
+	bootstrapMetrics(f, md)
+	bootstrapAccessLog(f, md)
+
+	if instrumented(md) {
+		responseRecorder(f)
+	}
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// bootstrapHooks adds the methods running the lifecycle hooks of the service
+// implementation, which do nothing for the hooks it does not implement.
+func bootstrapHooks(f *File) {
+	hooks := []struct {
+		name, iface string
+		doc         []string
+	}{
+		{
+			name:  metadata.HookStart,
+			iface: "StartHook",
+			doc: []string{
+				"// OnStart runs the start hook of the service implementation, if it implements",
+				"// StartHook. It is meant to be called before the server starts accepting",
+				"// connections.",
+			},
+		},
+		{
+			name:  metadata.HookShutdown,
+			iface: "ShutdownHook",
+			doc: []string{
+				"// OnShutdown runs the shutdown hook of the service implementation, if it",
+				"// implements ShutdownHook. It is meant to be called once the server stopped",
+				"// serving requests, with a context holding the deadline of the shutdown.",
+			},
+		},
+	}
+
+	for _, hook := range hooks {
+		for _, line := range hook.doc {
+			f.Comment(line)
+		}
+
+		f.Func().Params(
+			Id("s").Op("*").Id("Service"),
+		).Id(hook.name).Params(
+			Id("ctx").Qual("context", "Context"),
+		).Error().Block(
+			If(
+				List(Id("h"), Id("ok")).Op(":=").Id("s").Dot("serviceImpl").Assert(Id(hook.iface)),
+				Id("ok"),
+			).Block(
+				Return(Id("h").Dot(hook.name).Call(Id("ctx"))),
+			),
+			Empty(),
+			Return(Nil()),
+		)
+	}
+}
+
+// bootstrapRoutes adds the routes function, which registers the routes of the
+// descriptor on the router, each of them wrapped in the middlewares that
+// apply to it.
+func bootstrapRoutes(f *File, md metadata.Metadata) {
+	f.Comment("// routes sets up the routes to be served by the service, wrapping each handler")
+	f.Comment("// in the middlewares that apply to its path")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("routes").Params().BlockFunc(func(g *Group) {
+		healthRoutes(g, md)
+		metricsRoutes(g, md)
+
+		g.Id("routes").Op(":=").Index().Id("route").ValuesFunc(func(g *Group) {
+			for _, r := range md.Routes {
+				g.Line().Values(DictFunc(func(d Dict) {
+					d[Id("path")] = Lit(r.Path)
+					d[Id("handler")] = routeHandler(md, r)
+					d[Id("methods")] = Index().String().ValuesFunc(httpMethods(r.HttpMethods))
+					d[Id("strictSlash")] = Lit(r.StrictSlash)
+
+					if instrumented(md) {
+						d[Id("name")] = Lit(r.HandlerName)
+					}
+				}))
+			}
+			g.Line()
+		})
+		g.Empty()
+		g.Id("mws").Op(":=").Id("s").Dot("middlewares").Call()
+		g.Empty()
+		g.For(
+			List(Id("_"), Id("route")).Op(":=").Range().Id("routes").Block(
+				Var().Id("handler").Qual("net/http", "Handler").Op("=").Id("route").Dot("handler"),
+				Empty(),
+				Comment("// Wrapping starts with the lowest priority middleware, so that the"),
+				Comment("// highest priority one ends up being the outermost."),
+				For(
+					Id("i").Op(":=").Len(Id("mws")).Op("-").Lit(1),
+					Id("i").Op(">=").Lit(0),
+					Id("i").Op("--"),
+				).Block(
+					If(Id("appliesTo").Call(Id("mws").Index(Id("i")).Dot("paths"), Id("route").Dot("path"))).Block(
+						Id("handler").Op("=").Id("mws").Index(Id("i")).Dot("handler").Call(Id("handler")),
+					),
+				),
+				Empty(),
+				Do(func(s *Statement) {
+					if !instrumented(md) {
+						return
+					}
+
+					s.Comment("// The requests are logged and measured outside of the middlewares, so")
+					s.Line().Comment("// that the ones rejected by them are accounted for too.")
+
+					if md.AccessLog != nil {
+						s.Line().Id("handler").Op("=").Id("s").Dot("accessLog").Call(Id("route").Dot("name"), Id("handler"))
+					}
+
+					if md.Metrics != nil {
+						s.Line().Id("handler").Op("=").Id("s").Dot("metrics").Dot("instrument").Call(Id("route").Dot("name"), Id("handler"))
+					}
+
+					s.Line()
+				}),
+				Id("s").Dot("router").
+					Dot("StrictSlash").Call(Id("route").Dot("strictSlash")).
+					Dot("Handle").
+					Call(
+						Id("route").Dot("path"), Id("handler")).
+					Dot("Methods").
+					Call(
+						Id("route").Dot("methods").Op("..."),
+					),
+			),
+		)
+	})
+}
+
+// instrumented reports whether the handlers of the routes are wrapped in the
+// metrics or the access log, which need the names of the routes.
+func instrumented(md metadata.Metadata) bool {
+	return md.Metrics != nil || md.AccessLog != nil
+}
+
+// routeHandler returns the handler of the route, which extracts the route's
+// parameters first if it declares some. Typed handlers are called through
+// their adapter.
+func routeHandler(md metadata.Metadata, r metadata.Route) *Statement {
+	handler := Id("s").Dot("serviceImpl").Dot(r.HandlerName).Call()
+	if r.IsTyped() {
+		handler = Id("s").Dot(adapterName(r)).Call()
+	}
+
+	if len(r.Params) == 0 {
+		return handler
+	}
+
+	return Qual(apiPath(md), "Bind"+r.HandlerName+"Params").Call(handler)
+}
+
+// bootstrapMiddlewares adds the middlewares function, which returns the
+// middlewares of the descriptor ordered by priority, and the helpers used to
+// decide which routes they apply to.
+func bootstrapMiddlewares(f *File, md metadata.Metadata) {
+	f.Comment("// middleware is a struct that holds a middleware, the paths of the routes it")
+	f.Comment("// should be applied on, and its priority.")
+	f.Type().Id("middleware").Struct(
+		Id("handler").Qual(mux, "MiddlewareFunc"),
+		Id("paths").Index().String(),
+		Id("priority").Int(),
+	)
+
+	f.Comment("// middlewares returns the middlewares of the service, ordered from highest to")
+	f.Comment("// lowest priority. Middlewares with the same priority keep their declaration order.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("middlewares").Params().Index().Id("middleware").Block(
+		Id("mws").Op(":=").Index().Id("middleware").ValuesFunc(func(g *Group) {
+			for _, mw := range md.Middlwares {
+				g.Line().Values(Dict{
+					Id("handler"):  Id("s").Dot("serviceImpl").Dot(mw.HandlerName),
+					Id("paths"):    Index().String().ValuesFunc(stringLits(mw.Paths)),
+					Id("priority"): Lit(mw.Priority),
+				})
+			}
+			g.Line()
+		}),
+		Empty(),
+		Qual("sort", "SliceStable").Call(
+			Id("mws"),
+			Func().Params(List(Id("i"), Id("j")).Int()).Bool().Block(
+				Return(Id("mws").Index(Id("i")).Dot("priority").Op(">").Id("mws").Index(Id("j")).Dot("priority")),
+			),
+		),
+		Empty(),
+		Return(Id("mws")),
+	)
+
+	f.Comment("// appliesTo reports whether any of the paths matches the path template of a")
+	f.Comment("// route. \"*\" matches all the routes, a path ending with \"*\" matches the routes")
+	f.Comment("// starting with what precedes it, and any other path is matched either exactly")
+	f.Comment("// or as a glob.")
+	f.Func().Id("appliesTo").Params(
+		Id("paths").Index().String(),
+		Id("template").String(),
+	).Bool().Block(
+		For(
+			List(Id("_"), Id("p")).Op(":=").Range().Id("paths").Block(
+				If(Id("p").Op("==").Lit("*").Op("||").Id("p").Op("==").Id("template")).Block(
+					Return(True()),
+				),
+				Empty(),
+				If(
+					Qual("strings", "HasSuffix").Call(Id("p"), Lit("*")).Op("&&").
+						Qual("strings", "HasPrefix").Call(
+						Id("template"),
+						Qual("strings", "TrimSuffix").Call(Id("p"), Lit("*")),
+					),
+				).Block(
+					Return(True()),
+				),
+				Empty(),
+				If(
+					List(Id("ok"), Id("_")).Op(":=").Qual("path", "Match").Call(Id("p"), Id("template")),
+					Id("ok"),
+				).Block(
+					Return(True()),
+				),
+			),
+		),
+		Empty(),
+		Return(False()),
+	)
+}
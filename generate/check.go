@@ -0,0 +1,239 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"seed/metadata"
+	"sort"
+	"strings"
+)
+
+// deprecatedMarker starts the block at the end of the service file into which
+// orphan methods are moved.
+const deprecatedMarker = "// seed:deprecated"
+
+const deprecatedBlock = deprecatedMarker + `
+//
+// The methods below are no longer referenced by the service descriptor. They
+// were moved here instead of being deleted so that no code is lost, and can
+// be removed once they are not needed anymore.
+`
+
+// Report lists the differences between the methods of Server and the service
+// descriptor.
+type Report struct {
+	// Orphans are the handler and middleware methods of Server that are not
+	// referenced by the descriptor.
+	Orphans []string
+
+	// Deprecated are the orphan methods that were already moved into the
+	// deprecated block.
+	Deprecated []string
+
+	// Missing are the handlers and middlewares of the descriptor that are not
+	// implemented by Server.
+	Missing []string
+}
+
+// OK reports whether Server implements exactly what the descriptor
+// references, ignoring the methods in the deprecated block.
+func (r Report) OK() bool {
+	return len(r.Orphans) == 0 && len(r.Missing) == 0
+}
+
+// CheckServiceFile compares the handler and middleware methods declared on
+// Server in the service file against the routes and middlewares of the
+// descriptor. Only exported methods with a handler or middleware signature
+// are considered, so helper methods on Server are never reported.
+func CheckServiceFile(src []byte, md metadata.Metadata) (Report, error) {
+	var report Report
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return report, fmt.Errorf("parsing service file: %v", err)
+	}
+
+	referenced := referencedMethods(md)
+	methods := ServerMethods(file)
+	deprecatedFrom := markerOffset(fset, file)
+
+	for _, fn := range handlerMethods(file) {
+		if referenced[fn.Name.Name] {
+			continue
+		}
+
+		if deprecatedFrom >= 0 && fset.Position(fn.Pos()).Offset > deprecatedFrom {
+			report.Deprecated = append(report.Deprecated, fn.Name.Name)
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, fn.Name.Name)
+	}
+
+	for name := range referenced {
+		if !methods[name] {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+
+	sort.Strings(report.Missing)
+
+	return report, nil
+}
+
+// DeprecateOrphans moves the orphan methods of the service file, along with
+// their doc comments, into the deprecated block at the end of the file. The
+// block is created if it does not exist yet.
+func DeprecateOrphans(src []byte, md metadata.Metadata) ([]byte, error) {
+	report, err := CheckServiceFile(src, md)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(report.Orphans) == 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing service file: %v", err)
+	}
+
+	orphans := make(map[string]bool)
+	for _, name := range report.Orphans {
+		orphans[name] = true
+	}
+
+	var (
+		kept  []byte
+		moved []string
+		last  int
+	)
+
+	for _, fn := range handlerMethods(file) {
+		if !orphans[fn.Name.Name] {
+			continue
+		}
+
+		start := fset.Position(fn.Pos()).Offset
+		if fn.Doc != nil {
+			start = fset.Position(fn.Doc.Pos()).Offset
+		}
+
+		end := fset.Position(fn.End()).Offset
+
+		kept = append(kept, src[last:start]...)
+		moved = append(moved, string(src[start:end]))
+		last = end
+	}
+
+	kept = append(kept, src[last:]...)
+
+	updated := strings.TrimRight(string(kept), "\n") + "\n\n"
+
+	if markerOffset(fset, file) < 0 {
+		updated += deprecatedBlock + "\n"
+	}
+
+	updated += strings.Join(moved, "\n\n") + "\n"
+
+	formatted, err := format.Source([]byte(updated))
+	if err != nil {
+		return nil, fmt.Errorf("formatting service file: %v", err)
+	}
+
+	return formatted, nil
+}
+
+// referencedMethods returns the names of the handlers and middlewares of the
+// descriptor.
+func referencedMethods(md metadata.Metadata) map[string]bool {
+	referenced := make(map[string]bool)
+
+	for _, r := range md.Routes {
+		referenced[r.HandlerName] = true
+	}
+
+	for _, mw := range md.Middlwares {
+		referenced[mw.HandlerName] = true
+	}
+
+	return referenced
+}
+
+// handlerMethods returns the exported methods of Server whose signature is
+// that of a route handler, a typed handler or a middleware, in declaration
+// order. Lifecycle hooks share the signature of typed handlers, and are left
+// out.
+func handlerMethods(file *ast.File) []*ast.FuncDecl {
+	var methods []*ast.FuncDecl
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !isServerMethod(fn) || !fn.Name.IsExported() || metadata.IsHook(fn.Name.Name) {
+			continue
+		}
+
+		if isHandlerSignature(fn.Type) || isTypedHandlerSignature(fn.Type) || isMiddlewareSignature(fn.Type) {
+			methods = append(methods, fn)
+		}
+	}
+
+	return methods
+}
+
+// isHandlerSignature reports whether the function has the signature of a
+// route handler: func() http.HandlerFunc
+func isHandlerSignature(fn *ast.FuncType) bool {
+	return fn.Params.NumFields() == 0 &&
+		fn.Results.NumFields() == 1 &&
+		isSelector(fn.Results.List[0].Type, "HandlerFunc")
+}
+
+// isTypedHandlerSignature reports whether the function has the signature of a
+// typed handler: func(context.Context, ...) (..., error)
+func isTypedHandlerSignature(fn *ast.FuncType) bool {
+	if fn.Params.NumFields() == 0 || fn.Results.NumFields() == 0 {
+		return false
+	}
+
+	last, ok := fn.Results.List[len(fn.Results.List)-1].Type.(*ast.Ident)
+
+	return isSelector(fn.Params.List[0].Type, "Context") && ok && last.Name == "error"
+}
+
+// isMiddlewareSignature reports whether the function has the signature of a
+// middleware: func(http.Handler) http.Handler
+func isMiddlewareSignature(fn *ast.FuncType) bool {
+	return fn.Params.NumFields() == 1 &&
+		fn.Results.NumFields() == 1 &&
+		isSelector(fn.Params.List[0].Type, "Handler") &&
+		isSelector(fn.Results.List[0].Type, "Handler")
+}
+
+func isSelector(expr ast.Expr, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+
+	return ok && sel.Sel.Name == name
+}
+
+// markerOffset returns the offset of the deprecated block's marker comment in
+// the file, or -1 if there is none.
+func markerOffset(fset *token.FileSet, file *ast.File) int {
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if c.Text == deprecatedMarker {
+				return fset.Position(c.Pos()).Offset
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,150 @@
+package generate
+
+import (
+	"seed/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const checkSrc = `package test
+
+import (
+	"context"
+	"net/http"
+)
+
+type Server struct{}
+
+// Index serves the root.
+func (s *Server) Index() http.HandlerFunc {
+	return nil
+}
+
+// Users is no longer routed.
+func (s *Server) Users() http.HandlerFunc {
+	return nil
+}
+
+// helper is not exported, so it is never reported.
+func (s *Server) helper() http.HandlerFunc {
+	return nil
+}
+
+// Respond is exported but is not a handler.
+func (s *Server) Respond(w http.ResponseWriter, status int) {
+	w.WriteHeader(status)
+}
+
+// OnShutdown is a lifecycle hook, not a typed handler.
+func (s *Server) OnShutdown(ctx context.Context) error {
+	return nil
+}
+
+func (s *Server) OldMw(next http.Handler) http.Handler {
+	return next
+}
+`
+
+func checkMetadata() metadata.Metadata {
+	return metadata.Metadata{
+		Routes: []metadata.Route{
+			{HandlerName: "Index"},
+			{HandlerName: "CreateUser"},
+		},
+		Middlwares: []metadata.Middleware{
+			{HandlerName: "LoggerMw"},
+		},
+	}
+}
+
+func TestCheckServiceFile(t *testing.T) {
+	report, err := CheckServiceFile([]byte(checkSrc), checkMetadata())
+	if err != nil {
+		t.Fatalf("CheckServiceFile() failed: %v", err)
+	}
+
+	assert.Equal(t, []string{"Users", "OldMw"}, report.Orphans)
+	assert.Equal(t, []string{"CreateUser", "LoggerMw"}, report.Missing)
+	assert.Empty(t, report.Deprecated)
+	assert.False(t, report.OK())
+}
+
+func TestDeprecateOrphans(t *testing.T) {
+	md := checkMetadata()
+
+	actual, err := DeprecateOrphans([]byte(checkSrc), md)
+	if err != nil {
+		t.Fatalf("DeprecateOrphans() failed: %v", err)
+	}
+
+	expected := `package test
+
+import (
+	"context"
+	"net/http"
+)
+
+type Server struct{}
+
+// Index serves the root.
+func (s *Server) Index() http.HandlerFunc {
+	return nil
+}
+
+// helper is not exported, so it is never reported.
+func (s *Server) helper() http.HandlerFunc {
+	return nil
+}
+
+// Respond is exported but is not a handler.
+func (s *Server) Respond(w http.ResponseWriter, status int) {
+	w.WriteHeader(status)
+}
+
+// OnShutdown is a lifecycle hook, not a typed handler.
+func (s *Server) OnShutdown(ctx context.Context) error {
+	return nil
+}
+
+` + deprecatedBlock + `
+// Users is no longer routed.
+func (s *Server) Users() http.HandlerFunc {
+	return nil
+}
+
+func (s *Server) OldMw(next http.Handler) http.Handler {
+	return next
+}
+`
+
+	assert.Equal(t, expected, string(actual))
+
+	report, err := CheckServiceFile(actual, md)
+	if err != nil {
+		t.Fatalf("CheckServiceFile() failed: %v", err)
+	}
+
+	assert.Empty(t, report.Orphans)
+	assert.Equal(t, []string{"Users", "OldMw"}, report.Deprecated)
+
+	again, err := DeprecateOrphans(actual, md)
+	if err != nil {
+		t.Fatalf("DeprecateOrphans() failed on updated file: %v", err)
+	}
+
+	assert.Equal(t, string(actual), string(again), "deprecating twice should not change anything")
+
+	updated, err := UpdateServiceFile(actual, md)
+	if err != nil {
+		t.Fatalf("UpdateServiceFile() failed: %v", err)
+	}
+
+	report, err = CheckServiceFile(updated, md)
+	if err != nil {
+		t.Fatalf("CheckServiceFile() failed: %v", err)
+	}
+
+	assert.True(t, report.OK(), "stubs should be added for the missing methods")
+	assert.Equal(t, []string{"Users", "OldMw"}, report.Deprecated, "stubs should be added before the deprecated block")
+}
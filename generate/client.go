@@ -0,0 +1,522 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"path"
+	"seed/consts"
+	"seed/metadata"
+	"strings"
+	"unicode"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// ClientFile generates the client package, which holds a Client with a
+// method calling each route of the service on each of its HTTP methods.
+func ClientFile(md metadata.Metadata) ([]byte, error) {
+	f := NewFilePathName(clientPath(md), consts.ClientFolder)
+
+	f.PackageComment(fmt.Sprintf("// Package client calls the endpoints of the %s service.", md.Name))
+
+	clientType(f, md)
+	clientError(f)
+
+	for _, r := range md.Routes {
+		for _, call := range r.ClientCalls() {
+			clientMethod(f, md, r, call)
+		}
+	}
+
+	clientHelpers(f, md)
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// clientPath returns the import path of the client package.
+func clientPath(md metadata.Metadata) string {
+	return path.Join(md.ModulePath(), consts.ClientFolder)
+}
+
+// clientType adds the Client type and its constructor.
+func clientType(f *File, md metadata.Metadata) {
+	f.Commentf("// Client calls the endpoints of the %s service. Its fields can be changed", md.Name)
+	f.Comment("// until the first call.")
+	f.Type().Id("Client").Struct(
+		Comment("// BaseURL is the URL the service is served at, e.g."),
+		Comment("// \"http://localhost:8080\". Paths of the routes are appended to it."),
+		Id("BaseURL").String(),
+		Line(),
+		Comment("// HTTPClient sends the requests."),
+		Id("HTTPClient").Op("*").Qual("net/http", "Client"),
+	)
+
+	f.Comment("// New returns a Client calling the service served at baseURL, using")
+	f.Comment("// http.DefaultClient.")
+	f.Func().Id("New").Params(Id("baseURL").String()).Op("*").Id("Client").Block(
+		Return(Op("&").Id("Client").Values(Dict{
+			Id("BaseURL"):    Qual("strings", "TrimSuffix").Call(Id("baseURL"), Lit("/")),
+			Id("HTTPClient"): Qual("net/http", "DefaultClient"),
+		})),
+	)
+}
+
+// clientError adds the Error type returned for the responses of the service
+// with a status code outside of the 2xx range.
+func clientError(f *File) {
+	f.Comment("// Error is returned for the responses of the service with a status code")
+	f.Comment("// outside of the 2xx range.")
+	f.Type().Id("Error").Struct(
+		Comment("// StatusCode is the status code of the response."),
+		Id("StatusCode").Int(),
+		Line(),
+		Comment("// Param, In and Message describe the error written by the service, if"),
+		Comment("// the body of the response holds one."),
+		Id("Param").String(),
+		Id("In").String(),
+		Id("Message").String(),
+		Line(),
+		Comment("// Body is the body of the response."),
+		Id("Body").Index().Byte(),
+	)
+
+	f.Func().Params(Id("e").Op("*").Id("Error")).Id("Error").Params().String().Block(
+		Id("status").Op(":=").Qual("fmt", "Sprintf").Call(
+			Lit("%d %s"), Id("e").Dot("StatusCode"), Qual("net/http", "StatusText").Call(Id("e").Dot("StatusCode")),
+		),
+		Empty(),
+		Switch().Block(
+			Case(Id("e").Dot("Message").Op("==").Lit("")).Block(
+				Return(Id("status")),
+			),
+			Case(Id("e").Dot("Param").Op("==").Lit("")).Block(
+				Return(Id("status").Op("+").Lit(": ").Op("+").Id("e").Dot("Message")),
+			),
+			Default().Block(
+				Return(Qual("fmt", "Sprintf").Call(
+					Lit("%s: %s %s %s"), Id("status"), Id("e").Dot("In"), Id("e").Dot("Param"), Id("e").Dot("Message"),
+				)),
+			),
+		),
+	)
+}
+
+// clientSignature returns the parameters and results of the client method
+// calling the route. Path variables the route does not declare as parameters
+// are passed as strings. Typed routes return the decoded response body, and
+// other routes return the response itself.
+func clientSignature(md metadata.Metadata, r metadata.Route) (params, results []Code) {
+	params = append(params, Id("ctx").Qual("context", "Context"))
+
+	for _, v := range undeclaredVars(r) {
+		params = append(params, Id(varArg(v)).String())
+	}
+
+	if len(r.Params) > 0 {
+		params = append(params, Id("params").Qual(apiPath(md), r.HandlerName+"Params"))
+	}
+
+	if r.Request != nil {
+		params = append(params, Id("req").Add(bodyType(md, r, r.Request, "Request", false)))
+	}
+
+	switch {
+	case r.Response != nil:
+		results = append(results, bodyType(md, r, r.Response, "Response", false))
+	case !r.IsTyped():
+		results = append(results, Op("*").Qual("net/http", "Response"))
+	}
+
+	return params, append(results, Error())
+}
+
+// clientMethod adds the method of Client making the call of the route.
+func clientMethod(f *File, md metadata.Metadata, r metadata.Route, call metadata.ClientCall) {
+	params, results := clientSignature(md, r)
+
+	query := paramsIn(r, metadata.InQuery)
+	header := paramsIn(r, metadata.InHeader)
+
+	// fail returns the results of the method on error.
+	fail := func() []Code {
+		switch {
+		case r.Response != nil:
+			return []Code{Id("resp"), Id("err")}
+		case r.IsTyped():
+			return []Code{Id("err")}
+		default:
+			return []Code{Nil(), Id("err")}
+		}
+	}
+
+	f.Commentf("// %s calls the %s %s route of the service.", call.Name, call.HttpMethod, r.Path)
+	for _, p := range r.Params {
+		if p.Default != "" {
+			f.Commentf("// The parameters having a default are always sent, see api.New%sParams.", r.HandlerName)
+			break
+		}
+	}
+	if r.Response == nil && !r.IsTyped() {
+		f.Comment("// The caller is responsible for closing the body of the response.")
+	}
+
+	f.Func().Params(Id("c").Op("*").Id("Client")).Id(call.Name).Params(params...).Params(results...).BlockFunc(func(g *Group) {
+		if r.Response != nil {
+			g.Var().Id("resp").Add(bodyType(md, r, r.Response, "Response", false))
+			g.Line()
+		}
+
+		queryArg := Code(Nil())
+
+		if len(query) > 0 {
+			g.Id("query").Op(":=").Qual("net/url", "Values").Values()
+			for _, p := range query {
+				setParam(g, Id("query"), p)
+			}
+			g.Line()
+
+			queryArg = Id("query")
+		}
+
+		body := Code(Nil())
+		if r.Request != nil {
+			body = Id("req")
+		}
+
+		g.List(Id("r"), Id("err")).Op(":=").Id("c").Dot("newRequest").Call(
+			Id("ctx"),
+			httpMethod(call.HttpMethod),
+			clientPathExpr(r),
+			queryArg,
+			body,
+		)
+		g.If(Id("err").Op("!=").Nil()).Block(Return(fail()...))
+		g.Line()
+
+		if len(header) > 0 {
+			for _, p := range header {
+				setParam(g, Id("r").Dot("Header"), p)
+			}
+			g.Line()
+		}
+
+		if !r.IsTyped() {
+			g.Return(Id("c").Dot("send").Call(Id("r")))
+			return
+		}
+
+		g.List(Id("res"), Id("err")).Op(":=").Id("c").Dot("send").Call(Id("r"))
+		g.If(Id("err").Op("!=").Nil()).Block(Return(fail()...))
+		g.Line()
+
+		if r.Response == nil {
+			g.Return(Id("decode").Call(Id("res"), Nil()))
+			return
+		}
+
+		g.Id("err").Op("=").Id("decode").Call(Id("res"), Op("&").Id("resp"))
+		g.Line()
+		g.Return(Id("resp"), Id("err"))
+	})
+}
+
+// setParam sets the parameter on values, either url.Values or an
+// http.Header. Parameters having a default are always set, so that any of
+// their values can be sent, e.g. false when the default is true. Other
+// optional parameters are only set if they are not their type's zero value,
+// which the service would otherwise see anyway.
+func setParam(g *Group, values *Statement, p metadata.Param) {
+	value := func() *Statement { return Id("params").Dot(p.FieldName()) }
+	set := values.Dot("Set").Call(Lit(p.Name), formatParam(p, value()))
+
+	if p.IsRequired() || p.Default != "" {
+		g.Add(set)
+		return
+	}
+
+	var isSet *Statement
+
+	switch p.ParamType() {
+	case metadata.TypeInt:
+		isSet = value().Op("!=").Lit(0)
+	case metadata.TypeBool:
+		isSet = value()
+	case metadata.TypeTime:
+		isSet = Op("!").Add(value()).Dot("IsZero").Call()
+	default:
+		isSet = value().Op("!=").Lit("")
+	}
+
+	g.If(isSet).Block(set)
+}
+
+// formatParam returns the expression formatting the value of the parameter.
+func formatParam(p metadata.Param, value *Statement) *Statement {
+	switch p.ParamType() {
+	case metadata.TypeInt:
+		return Qual("strconv", "Itoa").Call(value)
+	case metadata.TypeBool:
+		return Qual("strconv", "FormatBool").Call(value)
+	case metadata.TypeTime:
+		return value.Dot("Format").Call(Qual("time", "RFC3339"))
+	default:
+		return value
+	}
+}
+
+// clientPathExpr returns the expression building the path of a request to
+// the route, with its path variables escaped.
+func clientPathExpr(r metadata.Route) *Statement {
+	var parts []Code
+
+	for _, seg := range pathSegments(r) {
+		switch {
+		case !seg.isVar:
+			parts = append(parts, Lit(seg.lit))
+		case seg.param != nil:
+			parts = append(parts, Qual("net/url", "PathEscape").Call(
+				formatParam(*seg.param, Id("params").Dot(seg.param.FieldName())),
+			))
+		default:
+			parts = append(parts, Qual("net/url", "PathEscape").Call(Id(varArg(seg.v))))
+		}
+	}
+
+	expr := Add(parts[0])
+	for _, part := range parts[1:] {
+		expr = expr.Op("+").Add(part)
+	}
+
+	return expr
+}
+
+// pathSegment is a part of the path of a route: either a literal, or a path
+// variable along with the parameter declaring it, if any.
+type pathSegment struct {
+	lit   string
+	isVar bool
+	v     metadata.PathVar
+	param *metadata.Param
+}
+
+// pathSegments splits the path of the route into literals and path
+// variables. A path without variables makes a single literal.
+func pathSegments(r metadata.Route) []pathSegment {
+	vars, _ := metadata.PathVars(r.Path)
+
+	var segments []pathSegment
+
+	rest := r.Path
+	for _, v := range vars {
+		placeholder := "{" + v.Name + "}"
+		if v.Pattern != "" {
+			placeholder = "{" + v.Name + ":" + v.Pattern + "}"
+		}
+
+		i := strings.Index(rest, placeholder)
+		if i > 0 {
+			segments = append(segments, pathSegment{lit: rest[:i]})
+		}
+
+		seg := pathSegment{isVar: true, v: v}
+		for _, p := range paramsIn(r, metadata.InPath) {
+			if p.Name == v.Name {
+				p := p
+				seg.param = &p
+			}
+		}
+
+		segments = append(segments, seg)
+		rest = rest[i+len(placeholder):]
+	}
+
+	if rest != "" || len(segments) == 0 {
+		segments = append(segments, pathSegment{lit: rest})
+	}
+
+	return segments
+}
+
+// paramsIn returns the parameters of the route found in the given part of
+// the request, in their declaration order.
+func paramsIn(r metadata.Route, in string) []metadata.Param {
+	var params []metadata.Param
+
+	for _, p := range r.Params {
+		if p.In == in {
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+// undeclaredVars returns the path variables of the route it does not declare
+// as parameters.
+func undeclaredVars(r metadata.Route) []metadata.PathVar {
+	var undeclared []metadata.PathVar
+
+	for _, seg := range pathSegments(r) {
+		if seg.isVar && seg.param == nil {
+			undeclared = append(undeclared, seg.v)
+		}
+	}
+
+	return undeclared
+}
+
+// clientLocals are the identifiers used by the client methods, which path
+// variable arguments should not shadow.
+var clientLocals = map[string]bool{
+	"c":      true,
+	"ctx":    true,
+	"params": true,
+	"req":    true,
+	"resp":   true,
+	"query":  true,
+	"r":      true,
+	"res":    true,
+	"err":    true,
+}
+
+// varArg returns the name of the argument holding the path variable, e.g.
+// "userID" for "user_id".
+func varArg(v metadata.PathVar) string {
+	arg := lowerCamel(v.Name)
+
+	switch {
+	case arg == "" || !unicode.IsLetter([]rune(arg)[0]):
+		arg = "var" + arg
+	case clientLocals[arg] || token.Lookup(arg).IsKeyword():
+		arg += "Var"
+	}
+
+	return arg
+}
+
+// lowerCamel returns the Go name of the descriptor name with its leading
+// initialism or letter lowered, e.g. "xRequestID" for "X-Request-Id".
+func lowerCamel(name string) string {
+	runes := []rune(metadata.GoName(name))
+
+	for i := range runes {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) || !unicode.IsUpper(runes[i]) {
+			break
+		}
+
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	return string(runes)
+}
+
+// clientHelpers adds the functions building and sending requests, and the one
+// decoding responses if any route is typed.
+func clientHelpers(f *File, md metadata.Metadata) {
+	f.Comment("// newRequest returns a request to the path of the service, holding the query")
+	f.Comment("// if it is not nil, and the body encoded as JSON if it is not nil.")
+	f.Func().Params(Id("c").Op("*").Id("Client")).Id("newRequest").Params(
+		Id("ctx").Qual("context", "Context"),
+		List(Id("method"), Id("path")).String(),
+		Id("query").Qual("net/url", "Values"),
+		Id("body").Interface(),
+	).Params(Op("*").Qual("net/http", "Request"), Error()).Block(
+		Id("u").Op(":=").Id("c").Dot("BaseURL").Op("+").Id("path"),
+		If(Len(Id("query")).Op(">").Lit(0)).Block(
+			Id("u").Op("+=").Lit("?").Op("+").Id("query").Dot("Encode").Call(),
+		),
+		Empty(),
+		Var().Id("reader").Qual("io", "Reader"),
+		If(Id("body").Op("!=").Nil()).Block(
+			List(Id("b"), Id("err")).Op(":=").Qual("encoding/json", "Marshal").Call(Id("body")),
+			If(Id("err").Op("!=").Nil()).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit("encoding request body: %v"), Id("err"))),
+			),
+			Empty(),
+			Id("reader").Op("=").Qual("bytes", "NewReader").Call(Id("b")),
+		),
+		Empty(),
+		List(Id("r"), Id("err")).Op(":=").Qual("net/http", "NewRequest").Call(Id("method"), Id("u"), Id("reader")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Nil(), Id("err")),
+		),
+		Empty(),
+		If(Id("body").Op("!=").Nil()).Block(
+			Id("r").Dot("Header").Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		),
+		Empty(),
+		Return(Id("r").Dot("WithContext").Call(Id("ctx")), Nil()),
+	)
+
+	f.Comment("// send sends the request, and returns an *Error if the status code of the")
+	f.Comment("// response is outside of the 2xx range.")
+	f.Func().Params(Id("c").Op("*").Id("Client")).Id("send").Params(
+		Id("r").Op("*").Qual("net/http", "Request"),
+	).Params(Op("*").Qual("net/http", "Response"), Error()).Block(
+		List(Id("res"), Id("err")).Op(":=").Id("c").Dot("HTTPClient").Dot("Do").Call(Id("r")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Nil(), Id("err")),
+		),
+		Empty(),
+		If(Id("res").Dot("StatusCode").Op(">=").Lit(200).Op("&&").Id("res").Dot("StatusCode").Op("<").Lit(300)).Block(
+			Return(Id("res"), Nil()),
+		),
+		Empty(),
+		Defer().Id("res").Dot("Body").Dot("Close").Call(),
+		Empty(),
+		Id("e").Op(":=").Op("&").Id("Error").Values(Dict{Id("StatusCode"): Id("res").Dot("StatusCode")}),
+		Empty(),
+		List(Id("e").Dot("Body"), Id("err")).Op("=").Qual("io/ioutil", "ReadAll").Call(Id("res").Dot("Body")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Nil(), Qual("fmt", "Errorf").Call(Lit("%v, reading body: %v"), Id("e"), Id("err"))),
+		),
+		Empty(),
+		Var().Id("body").Struct(
+			Id("Error").Struct(
+				Id("Param").String().Tag(map[string]string{"json": "param"}),
+				Id("In").String().Tag(map[string]string{"json": "in"}),
+				Id("Message").String().Tag(map[string]string{"json": "message"}),
+			).Tag(map[string]string{"json": "error"}),
+		),
+		Empty(),
+		If(Qual("encoding/json", "Unmarshal").Call(Id("e").Dot("Body"), Op("&").Id("body")).Op("==").Nil()).Block(
+			List(Id("e").Dot("Param"), Id("e").Dot("In"), Id("e").Dot("Message")).Op("=").List(
+				Id("body").Dot("Error").Dot("Param"), Id("body").Dot("Error").Dot("In"), Id("body").Dot("Error").Dot("Message"),
+			),
+		),
+		Empty(),
+		Return(Nil(), Id("e")),
+	)
+
+	if !hasTypedRoutes(md) {
+		return
+	}
+
+	f.Comment("// decode decodes the JSON body of the response into v if it is not nil, and")
+	f.Comment("// closes it.")
+	f.Func().Id("decode").Params(
+		Id("res").Op("*").Qual("net/http", "Response"),
+		Id("v").Interface(),
+	).Error().Block(
+		Defer().Id("res").Dot("Body").Dot("Close").Call(),
+		Empty(),
+		If(Id("v").Op("==").Nil()).Block(
+			Return(Nil()),
+		),
+		Empty(),
+		Err().Op(":=").Qual("encoding/json", "NewDecoder").Call(Id("res").Dot("Body")).Dot("Decode").Call(Id("v")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Qual("fmt", "Errorf").Call(Lit("decoding response body: %v"), Id("err"))),
+		),
+		Empty(),
+		Return(Nil()),
+	)
+}
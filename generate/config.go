@@ -0,0 +1,298 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"seed/consts"
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+const yaml = "github.com/go-yaml/yaml"
+
+// ConfigFile generates the configuration of the service: the Config struct
+// holding its settings, and LoadConfig, which loads them from command line
+// flags, environment variables and an optional YAML file.
+func ConfigFile(md metadata.Metadata) ([]byte, error) {
+	f := NewFilePathName(genPath(md), consts.GenFolder)
+
+	settings := md.Settings()
+
+	f.Comment("// Config holds the settings of the service, see LoadConfig.")
+	f.Type().Id("Config").StructFunc(func(g *Group) {
+		for i, s := range settings {
+			if i > 0 {
+				g.Line()
+			}
+
+			if s.Description != "" {
+				g.Comment("// " + s.Description)
+			}
+
+			g.Id(s.FieldName()).Add(settingType(s))
+		}
+	})
+
+	configSettings(f, md, settings)
+	configLoad(f, md)
+	configHelpers(f)
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// settingType returns the Go type of the setting.
+func settingType(s metadata.Setting) *Statement {
+	switch s.SettingType() {
+	case metadata.TypeInt:
+		return Int()
+	case metadata.TypeFloat:
+		return Float64()
+	case metadata.TypeBool:
+		return Bool()
+	case metadata.TypeDuration:
+		return Qual("time", "Duration")
+	default:
+		return String()
+	}
+}
+
+// configSettings adds the description of each setting: its names, its
+// default and how its value is parsed into Config.
+func configSettings(f *File, md metadata.Metadata, settings []metadata.Setting) {
+	f.Comment("// setting describes how a field of Config is loaded.")
+	f.Type().Id("setting").Struct(
+		List(Id("name"), Id("flag"), Id("env"), Id("def"), Id("usage")).String(),
+		List(Id("required"), Id("isBool")).Bool(),
+		Id("parse").Func().Params(Id("cfg").Op("*").Id("Config"), Id("value").String()).Error(),
+	)
+
+	f.Comment("// settings are the settings of Config, in declaration order.")
+	f.Var().Id("settings").Op("=").Index().Id("setting").ValuesFunc(func(g *Group) {
+		for _, s := range settings {
+			g.Line().ValuesFunc(func(g *Group) {
+				field := func(name string, value Code) {
+					g.Line().Id(name).Op(":").Add(value)
+				}
+
+				field("name", Lit(s.Name))
+				field("flag", Lit(s.FlagName()))
+				field("env", Lit(s.EnvVar(md.Name)))
+
+				if s.Default != "" {
+					field("def", Lit(s.Default))
+				}
+
+				if s.Description != "" {
+					field("usage", Lit(s.Description))
+				}
+
+				if s.Required {
+					field("required", True())
+				}
+
+				if s.SettingType() == metadata.TypeBool {
+					field("isBool", True())
+				}
+
+				field("parse", settingParser(s))
+				g.Line()
+			})
+		}
+		g.Line()
+	})
+}
+
+// settingParser returns the function parsing the value of the setting into
+// its field of Config.
+func settingParser(s metadata.Setting) *Statement {
+	field := Id("cfg").Dot(s.FieldName())
+
+	parse := func(call *Statement) *Statement {
+		return Func().Params(Id("cfg").Op("*").Id("Config"), Id("value").String()).Params(Id("err").Error()).Block(
+			List(field, Id("err")).Op("=").Add(call),
+			Return(Id("err")),
+		)
+	}
+
+	switch s.SettingType() {
+	case metadata.TypeInt:
+		return parse(Qual("strconv", "Atoi").Call(Id("value")))
+	case metadata.TypeFloat:
+		return parse(Qual("strconv", "ParseFloat").Call(Id("value"), Lit(64)))
+	case metadata.TypeBool:
+		return parse(Qual("strconv", "ParseBool").Call(Id("value")))
+	case metadata.TypeDuration:
+		return parse(Qual("time", "ParseDuration").Call(Id("value")))
+	default:
+		return Func().Params(Id("cfg").Op("*").Id("Config"), Id("value").String()).Error().Block(
+			field.Op("=").Id("value"),
+			Return(Nil()),
+		)
+	}
+}
+
+// configLoad adds LoadConfig, which loads the settings from their sources by
+// order of precedence.
+func configLoad(f *File, md metadata.Metadata) {
+	configEnv := metadata.EnvName(md.Name + "_" + metadata.ConfigSetting)
+
+	f.Comment("// LoadConfig loads the settings of the service from, by order of precedence, the")
+	f.Comment("// command line flags in args, the environment variables, the YAML file given by")
+	f.Commentf("// the -%s flag or the %s environment variable, and their defaults.", metadata.ConfigSetting, configEnv)
+	f.Comment("// It fails listing the required settings which are set nowhere.")
+	f.Func().Id("LoadConfig").Params(Id("args").Index().String()).Params(Id("Config"), Error()).Block(
+		Var().Id("cfg").Id("Config"),
+		Line(),
+		Id("fs").Op(":=").Qual("flag", "NewFlagSet").Call(Lit(md.Name), Qual("flag", "ContinueOnError")),
+		Id("file").Op(":=").Id("fs").Dot("String").Call(
+			Lit(metadata.ConfigSetting),
+			Qual("os", "Getenv").Call(Lit(configEnv)),
+			Lit("YAML file holding settings, which environment variables and flags override."),
+		),
+		Line(),
+		Id("flags").Op(":=").Make(Index().Op("*").Id("flagValue"), Len(Id("settings"))),
+		For(List(Id("i"), Id("s")).Op(":=").Range().Id("settings")).Block(
+			Id("flags").Index(Id("i")).Op("=").Op("&").Id("flagValue").Values(Dict{
+				Id("value"):  Id("s").Dot("def"),
+				Id("isBool"): Id("s").Dot("isBool"),
+			}),
+			Id("fs").Dot("Var").Call(Id("flags").Index(Id("i")), Id("s").Dot("flag"), Id("s").Dot("usage")),
+		),
+		Line(),
+		Err().Op(":=").Id("fs").Dot("Parse").Call(Id("args")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Id("cfg"), Err()),
+		),
+		Line(),
+		Id("fromFile").Op(":=").Make(Map(String()).String()),
+		If(Op("*").Id("file").Op("!=").Lit("")).Block(
+			List(Id("fromFile"), Err()).Op("=").Id("readConfigFile").Call(Op("*").Id("file")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Id("cfg"), Err()),
+			),
+		),
+		Line(),
+		Var().Id("missing").Index().String(),
+		Line(),
+		For(List(Id("i"), Id("s")).Op(":=").Range().Id("settings")).Block(
+			List(Id("value"), Id("from"), Id("set")).Op(":=").List(
+				Id("s").Dot("def"), Lit("default"), Id("s").Dot("def").Op("!=").Lit(""),
+			),
+			Line(),
+			If(List(Id("v"), Id("ok")).Op(":=").Id("fromFile").Index(Id("s").Dot("name")), Id("ok")).Block(
+				List(Id("value"), Id("from"), Id("set")).Op("=").List(Id("v"), Op("*").Id("file"), True()),
+			),
+			Line(),
+			If(Id("v").Op(":=").Qual("os", "Getenv").Call(Id("s").Dot("env")), Id("v").Op("!=").Lit("")).Block(
+				List(Id("value"), Id("from"), Id("set")).Op("=").List(Id("v"), Lit("$").Op("+").Id("s").Dot("env"), True()),
+			),
+			Line(),
+			If(Id("flags").Index(Id("i")).Dot("set")).Block(
+				List(Id("value"), Id("from"), Id("set")).Op("=").List(
+					Id("flags").Index(Id("i")).Dot("value"), Lit("-").Op("+").Id("s").Dot("flag"), True(),
+				),
+			),
+			Line(),
+			If(Op("!").Id("set")).Block(
+				If(Id("s").Dot("required")).Block(
+					Id("missing").Op("=").Append(Id("missing"), Qual("fmt", "Sprintf").Call(
+						Lit("%s (-%s or $%s)"), Id("s").Dot("name"), Id("s").Dot("flag"), Id("s").Dot("env"),
+					)),
+				),
+				Line(),
+				Continue(),
+			),
+			Line(),
+			Err().Op(":=").Id("s").Dot("parse").Call(Op("&").Id("cfg"), Id("value")),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Id("cfg"), Qual("fmt", "Errorf").Call(
+					Lit("invalid %s from %s: %v"), Id("s").Dot("name"), Id("from"), Err(),
+				)),
+			),
+		),
+		Line(),
+		If(Len(Id("missing")).Op(">").Lit(0)).Block(
+			Return(Id("cfg"), Qual("fmt", "Errorf").Call(
+				Lit("missing required settings: %s"), Qual("strings", "Join").Call(Id("missing"), Lit(", ")),
+			)),
+		),
+		Line(),
+		Return(Id("cfg"), Nil()),
+	)
+}
+
+// configHelpers adds the flag.Value recording whether a flag was set, and the
+// function reading the YAML file.
+func configHelpers(f *File) {
+	f.Comment("// flagValue is a flag.Value recording whether the flag was set, so that flags")
+	f.Comment("// only override the other sources when given.")
+	f.Type().Id("flagValue").Struct(
+		Id("value").String(),
+		List(Id("set"), Id("isBool")).Bool(),
+	)
+
+	f.Func().Params(Id("v").Op("*").Id("flagValue")).Id("String").Params().String().Block(
+		Return(Id("v").Dot("value")),
+	)
+	f.Line()
+
+	f.Func().Params(Id("v").Op("*").Id("flagValue")).Id("Set").Params(Id("value").String()).Error().Block(
+		List(Id("v").Dot("value"), Id("v").Dot("set")).Op("=").List(Id("value"), True()),
+		Return(Nil()),
+	)
+	f.Line()
+
+	f.Func().Params(Id("v").Op("*").Id("flagValue")).Id("IsBoolFlag").Params().Bool().Block(
+		Return(Id("v").Dot("isBool")),
+	)
+
+	f.Comment("// readConfigFile reads the YAML file at path, which maps the names of settings")
+	f.Comment("// to their values.")
+	f.Func().Id("readConfigFile").Params(Id("path").String()).Params(Map(String()).String(), Error()).Block(
+		List(Id("b"), Err()).Op(":=").Qual("io/ioutil", "ReadFile").Call(Id("path")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Nil(), Qual("fmt", "Errorf").Call(Lit("reading config file: %v"), Err())),
+		),
+		Line(),
+		Var().Id("raw").Map(String()).Interface(),
+		Line(),
+		Err().Op("=").Qual(yaml, "Unmarshal").Call(Id("b"), Op("&").Id("raw")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Nil(), Qual("fmt", "Errorf").Call(Lit("parsing config file %s: %v"), Id("path"), Err())),
+		),
+		Line(),
+		Id("known").Op(":=").Make(Map(String()).Bool()),
+		For(List(Id("_"), Id("s")).Op(":=").Range().Id("settings")).Block(
+			Id("known").Index(Id("s").Dot("name")).Op("=").True(),
+		),
+		Line(),
+		Id("values").Op(":=").Make(Map(String()).String()),
+		Line(),
+		For(List(Id("name"), Id("v")).Op(":=").Range().Id("raw")).Block(
+			If(Op("!").Id("known").Index(Id("name"))).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit("unknown setting %q in %s"), Id("name"), Id("path"))),
+			),
+			Line(),
+			Switch(Id("v").Assert(Type())).Block(
+				Case(Nil()).Block(
+					Continue(),
+				),
+				Case(Map(Interface()).Interface(), Index().Interface()).Block(
+					Return(Nil(), Qual("fmt", "Errorf").Call(Lit("setting %q in %s is not a single value"), Id("name"), Id("path"))),
+				),
+			),
+			Line(),
+			Id("values").Index(Id("name")).Op("=").Qual("fmt", "Sprint").Call(Id("v")),
+		),
+		Line(),
+		Return(Id("values"), Nil()),
+	)
+}
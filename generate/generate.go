@@ -3,208 +3,117 @@ package generate
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"net/http"
+	"path"
 	"path/filepath"
 	"seed/consts"
 	"seed/files"
 	"seed/metadata"
 	"strings"
 
-	"github.com/go-yaml/yaml"
-
 	. "github.com/dave/jennifer/jen"
 )
 
-func ServiceFile(projectName string) ([]byte, error) {
-	f := NewFilePath(projectName)
+// MainFile generates the executable of the service. It loads the settings of
+// the service, serves it with the timeouts they set, and shuts it down
+// gracefully on SIGINT and SIGTERM, running its lifecycle hooks.
+func MainFile(md metadata.Metadata) ([]byte, error) {
+	module := md.ModulePath()
+	gen := genPath(md)
 
-	f.Type().Id("Server").Struct()
+	f := NewFile("main")
+	f.ImportAlias(module, md.PackageName())
 
-	f.Func().Params(
-		Id("s").Op("*").Id("Server"),
-	).Id("LoggerMw").Params(
-		Id("next").Qual("net/http", "Handler"),
-	).Qual("net/http", "Handler").Block(
-		Comment("// Anything you add here will be executed once, during startup."),
-		Comment("// The returned http.Handler will be able to access these variables"),
-		Comment("// thanks to closure."),
+	f.Func().Id("main").Params().Block(
+		List(Id("cfg"), Err()).Op(":=").Qual(gen, "LoadConfig").Call(Qual("os", "Args").Index(Lit(1), Empty())),
+		If(Err().Op("==").Qual("flag", "ErrHelp")).Block(
+			Return(),
+		),
+		If(Err().Op("!=").Nil()).Block(
+			Qual("log", "Fatal").Call(Err()),
+		),
 		Line(),
-		Id("prefix").Op(":=").Lit(fmt.Sprintf("[%s] - ", projectName)),
-		Return(
-			Qual("net/http", "HandlerFunc").Call(
-				Add(httpHandlerFunc()).Block(
-					Qual("log", "Println").Call(
-						Id("prefix"),
-						Id("r").Dot("RemoteAddr"),
-						Id("r").Dot("Method"),
-						Id("r").Dot("RequestURI"),
-					),
-					Line(),
-					Id("next").Dot("ServeHTTP").Call(
-						Id("w"), Id("r"),
-					),
-				),
+		Id("service").Op(":=").
+			Qual(gen, "New").
+			Call(
+				Op("&").Qual(module, "Server").Values(),
+				Id("cfg"),
 			),
+		Line(),
+		Id("server").Op(":=").Op("&").Qual("net/http", "Server").Values(Dict{
+			Id("Addr"):         Id("cfg").Dot("Addr"),
+			Id("Handler"):      Id("service"),
+			Id("ReadTimeout"):  Id("cfg").Dot("ReadTimeout"),
+			Id("WriteTimeout"): Id("cfg").Dot("WriteTimeout"),
+			Id("IdleTimeout"):  Id("cfg").Dot("IdleTimeout"),
+		}),
+		Line(),
+		Err().Op("=").Id("run").Call(Id("server"), Id("service"), Id("cfg").Dot("ShutdownTimeout")),
+		If(Err().Op("!=").Nil()).Block(
+			Qual("log", "Fatal").Call(Err()),
 		),
 	)
 
-	f.Line()
-
-	f.Func().Params(
-		Id("s").Op("*").Id("Server"),
-	).Id("Index").Params().Qual("net/http", "HandlerFunc").Block(
-		Comment("// Anything you add here will be executed once, during startup."),
-		Comment("// The returned http.HandlerFunc will be able to access these variables"),
-		Comment("// thanks to closure."),
+	f.Comment("// run serves the service until SIGINT or SIGTERM is received, then stops")
+	f.Comment("// accepting connections and waits for in-flight requests to complete, for at")
+	f.Comment("// most drain. The lifecycle hooks of the service run before serving and after")
+	f.Comment("// draining, or once the server failed if it did.")
+	f.Func().Id("run").Params(
+		Id("server").Op("*").Qual("net/http", "Server"),
+		Id("service").Op("*").Qual(gen, "Service"),
+		Id("drain").Qual("time", "Duration"),
+	).Error().Block(
+		Err().Op(":=").Id("service").Dot("OnStart").Call(Qual("context", "Background").Call()),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Qual("fmt", "Errorf").Call(Lit("starting service: %v"), Err())),
+		),
+		Line(),
+		Id("errs").Op(":=").Make(Chan().Error(), Lit(1)),
+		Go().Func().Params().Block(
+			Qual("log", "Printf").Call(Lit("listening on %s"), Id("server").Dot("Addr")),
+			Id("errs").Op("<-").Id("server").Dot("ListenAndServe").Call(),
+		).Call(),
+		Line(),
+		Id("stop").Op(":=").Make(Chan().Qual("os", "Signal"), Lit(1)),
+		Qual("os/signal", "Notify").Call(Id("stop"), Qual("os", "Interrupt"), Qual("syscall", "SIGTERM")),
 		Line(),
-		Id("defaultMsg").Op(":=").Index().Byte().Call(Lit("I'm alive!")),
-		Return().Add(
-			httpHandlerFunc().Block(
-				Id("w").Dot("WriteHeader").Call(Qual("net/http", "StatusOK")),
+		Select().Block(
+			Case(Err().Op(":=").Op("<-").Id("errs")).Block(
+				List(Id("ctx"), Id("cancel")).Op(":=").Qual("context", "WithTimeout").Call(
+					Qual("context", "Background").Call(), Id("drain"),
+				),
+				Defer().Id("cancel").Call(),
 				Line(),
-				List(
-					Id("_"), Id("err"),
-				).Op(":=").Id("w").Dot("Write").Call(Id("defaultMsg")),
-				If(Id("err").Op("!=").Nil()).Block(
-					Panic(Id("err")),
+				If(
+					Id("shutdownErr").Op(":=").Id("service").Dot("OnShutdown").Call(Id("ctx")),
+					Id("shutdownErr").Op("!=").Nil(),
+				).Block(
+					Qual("log", "Printf").Call(Lit("shutting down service: %v"), Id("shutdownErr")),
 				),
+				Line(),
+				Return(Qual("fmt", "Errorf").Call(Lit("serving: %v"), Err())),
 			),
-		),
-	)
-
-	var buf bytes.Buffer
-
-	err := f.Render(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("rendering file: %v", err)
-	}
-
-	return buf.Bytes(), nil
-}
-
-func BootstrapFile(projectName string) ([]byte, error) {
-	f := NewFilePath("gen")
-
-	projectNameTitle := strings.Title(projectName)
-
-	const mux = "github.com/gorilla/mux"
-
-	f.Comment("// Service is the struct that will be exposed to serve HTTP traffic.")
-	f.Type().Id("Service").Struct(
-		Id("router").Op("*").Qual(mux, "Router"),
-		Id("serviceImpl").Qual("gen", projectNameTitle+"Service"),
-	)
-
-	f.Comment("// ServeHTTP is what ultimately allows this service to be " +
-		"used by the standard library's")
-	f.Comment("// listen and serve functions")
-	f.Func().Params(
-		Id("s").Op("*").Id("Service"),
-	).Id("ServeHTTP").Add(httpMethodParams()).Block(
-		Id("s").Dot("router").Dot("ServeHTTP").Call(
-			Id("w"),
-			Id("r"),
-		),
-	)
-
-	f.Comment("// Route is a struct that holds the path, the handler to " +
-		"be called when that path is hit, and")
-	f.Comment("// which list of methods it should serve.")
-
-	f.Type().Id("route").Struct(
-		Id("path").String(),
-		Id("handler").Qual("net/http", "HandlerFunc"),
-		Id("methods").Index().String(),
-	)
-
-	f.Comment("// New returns a new service implementation, using the " +
-		"service as a dependency. It also sets up the routes")
-	f.Comment("// and the middlewares.")
-
-	f.Func().Id("New").Params(
-		Id("service").Qual("gen", projectNameTitle+"Service"),
-	).Op("*").Qual("gen", "Service").Block(
-		Id("s").Op(":=").Op("&").Qual("gen", "Service").Values(
-			Dict{
-				Id("router"):      Qual(mux, "NewRouter").Call(),
-				Id("serviceImpl"): Id("service"),
-			},
-		),
-		Empty(),
-		Id("s").Dot("routes").Call(),
-		Id("s").Dot("middlewares").Call(),
-		Empty(),
-		Return(Id("s")),
-	)
-
-	f.Comment("// routes sets up the routes to be served by the service")
-	f.Func().Params(
-		Id("s").Op("*").Id("Service"),
-	).Id("routes").Params().Block(
-		Id("routes").Op(":=").Index().Id("route").Values(
-			Block(
-				Dict{
-					Id("handler"): Id("s").Dot("serviceImpl").Dot("Index").Call(),
-					Id("methods"): Index().String().Values(Qual("net/http", "MethodGet")),
-					Id("path"):    Lit("/"),
-				},
-			),
-		),
-		Empty(),
-		For(
-			List(Id("_"), Id("route")).Op(":=").Range().Id("routes").Block(
-				Id("s").Dot("router").
-					Dot("StrictSlash").Call(Lit(true)).
-					Dot("HandleFunc").
-					Call(
-						Id("route").Dot("path"), Id("route").Dot("handler")).
-					Dot("Methods").
-					Call(
-						Id("route").Dot("methods").Op("..."),
-					),
+			Case(Id("sig").Op(":=").Op("<-").Id("stop")).Block(
+				Qual("log", "Printf").Call(Lit("received %v, shutting down"), Id("sig")),
 			),
 		),
-	)
-
-	f.Comment("// middlewares sets up the middlewares to be set up by the service")
-	f.Func().Params(
-		Id("s").Op("*").Id("Service"),
-	).Id("middlewares").Params().Block(
-		Id("mws").Op(":=").Index().Qual(mux, "MiddlewareFunc").Values(
-			Id("s").Dot("serviceImpl").Dot("LoggerMw"),
+		Line(),
+		List(Id("ctx"), Id("cancel")).Op(":=").Qual("context", "WithTimeout").Call(
+			Qual("context", "Background").Call(), Id("drain"),
 		),
-		Empty(),
-		For(
-			List(Id("_"), Id("mw")).Op(":=").Range().Id("mws").Block(
-				Id("s").Dot("router").Dot("Use").Call(Id("mw")),
-			),
+		Defer().Id("cancel").Call(),
+		Line(),
+		Err().Op("=").Id("server").Dot("Shutdown").Call(Id("ctx")),
+		If(Err().Op("!=").Nil()).Block(
+			Qual("log", "Printf").Call(Lit("draining connections: %v"), Err()),
 		),
-	)
-
-	var buf bytes.Buffer
-
-	err := f.Render(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("rendering file: %v", err)
-	}
-
-	return buf.Bytes(), nil
-}
-
-func MainFile(projectName string) ([]byte, error) {
-	f := NewFile("main")
-
-	f.Func().Id("main").Params().Block(
-		Id("service").Op(":=").
-			Qual(fmt.Sprintf("%s/gen", projectName), "New").
-			Call(
-				Op("&").Qual(projectName, "Server").Values(),
-			),
-		Qual("log", "Fatal").Call(
-			Qual("net/http", "ListenAndServe").Call(
-				Lit(":8080"), Id("service"),
-			),
+		Line(),
+		Err().Op("=").Id("service").Dot("OnShutdown").Call(Id("ctx")),
+		If(Err().Op("!=").Nil()).Block(
+			Return(Qual("fmt", "Errorf").Call(Lit("shutting down service: %v"), Err())),
 		),
+		Line(),
+		Return(Nil()),
 	)
 
 	var buf bytes.Buffer
@@ -217,10 +126,10 @@ func MainFile(projectName string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func InterfaceFile(projectName string) ([]byte, error) {
-	f := NewFile("gen")
+func InterfaceFile(md metadata.Metadata) ([]byte, error) {
+	f := NewFilePathName(genPath(md), consts.GenFolder)
 
-	title := strings.Title(projectName)
+	title := strings.Title(md.Name)
 	service := fmt.Sprintf("%sService", title)
 	handler := fmt.Sprintf("%sHandler", title)
 	middleware := fmt.Sprintf("%sMiddleware", title)
@@ -239,19 +148,44 @@ func InterfaceFile(projectName string) ([]byte, error) {
 		"endpoint added by seed will be added here as a", handler)
 	f.Comment("// new method on the interface.")
 
-	f.Type().Id(handler).Interface(
-		Id("Index").Params().Qual("net/http", "HandlerFunc"),
-	)
+	f.Type().Id(handler).InterfaceFunc(func(g *Group) {
+		for _, r := range md.Routes {
+			if r.IsTyped() {
+				params, results := typedSignature(md, r, false)
+				g.Id(r.HandlerName).Params(params...).Params(results...)
+				continue
+			}
+
+			g.Id(r.HandlerName).Params().Qual("net/http", "HandlerFunc")
+		}
+	})
 
 	f.Commentf("// %s is the interface for all the middlewares that "+
 		"will be added to all of the paths.", middleware)
 
-	f.Type().Id(middleware).Interface(
-		Id("LoggerMw").Params(
-			Qual("net/http", "Handler"),
-		).Qual("net/http", "Handler"),
+	f.Type().Id(middleware).InterfaceFunc(func(g *Group) {
+		for _, mw := range md.Middlwares {
+			g.Id(mw.HandlerName).Params(
+				Qual("net/http", "Handler"),
+			).Qual("net/http", "Handler")
+		}
+	})
+
+	f.Comment("// StartHook is implemented by services which need to run something before the")
+	f.Comment("// server starts accepting connections, see Service.OnStart.")
+	f.Type().Id("StartHook").Interface(
+		Id(metadata.HookStart).Params(Id("ctx").Qual("context", "Context")).Error(),
+	)
+
+	f.Comment("// ShutdownHook is implemented by services which need to release resources once")
+	f.Comment("// the server stopped serving requests, see Service.OnShutdown.")
+	f.Type().Id("ShutdownHook").Interface(
+		Id(metadata.HookShutdown).Params(Id("ctx").Qual("context", "Context")).Error(),
 	)
 
+	healthInterfaces(f, md)
+	accessLogInterfaces(f, md)
+
 	var buf bytes.Buffer
 
 	err := f.Render(&buf)
@@ -262,8 +196,10 @@ func InterfaceFile(projectName string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func ProjectStructure(projectName string) error {
-	err := createDirs(files.Pwd, projectName)
+// ProjectStructure creates the folders of a project in dir, including dir
+// itself.
+func ProjectStructure(fs files.FS, dir string) error {
+	err := createDirs(fs, dir)
 	if err != nil {
 		return fmt.Errorf("creating folders: %v", err)
 	}
@@ -271,22 +207,22 @@ func ProjectStructure(projectName string) error {
 	return nil
 }
 
-func createDirs(pwd, projectName string) error {
-	paths := [][]string{
-		{pwd, projectName},
-		{pwd, projectName, consts.CmdFolder},
-		{pwd, projectName, consts.GenFolder},
+func createDirs(fs files.FS, dir string) error {
+	paths := []string{
+		dir,
+		filepath.Join(dir, consts.CmdFolder),
+		filepath.Join(dir, consts.GenFolder),
+		filepath.Join(dir, consts.GenFolder, consts.ApiFolder),
+		filepath.Join(dir, consts.ClientFolder),
 	}
 
 	for _, path := range paths {
-		fullPath := filepath.Join(path...)
-
-		err := os.MkdirAll(fullPath, files.DefaultPerm)
+		err := fs.MkdirAll(path, files.DefaultPerm)
 		if err != nil {
 			return fmt.Errorf("creating %v failed: %v", path, err)
 		}
 
-		err = os.Chmod(fullPath, files.DefaultPerm)
+		err = fs.Chmod(path, files.DefaultPerm)
 		if err != nil {
 			return fmt.Errorf("changing %v permissions failed: %v", path, err)
 		}
@@ -295,39 +231,64 @@ func createDirs(pwd, projectName string) error {
 	return nil
 }
 
-func ServiceDescriptor(projectName string) error {
-	desc := metadata.Base(metadata.Info{
+// DefaultDescriptor returns the service descriptor of a new project, see
+// metadata.Base.
+func DefaultDescriptor(projectName string) metadata.Metadata {
+	return metadata.Base(metadata.Info{
 		Name:    projectName,
 		Summary: "just a test for now",
 	})
+}
 
-	path := filepath.Join(files.Pwd, projectName, projectName+".yml")
-
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed creating project metadata: %v", err)
+// ServiceDescriptor writes the service descriptor of the project to
+// <dir>/<name>.yml, where name is the descriptor's name.
+func ServiceDescriptor(fs files.FS, dir string, desc metadata.Metadata) error {
+	b, err := desc.Marshal()
+	if err == nil {
+		err = fs.WriteFile(filepath.Join(dir, desc.Name+".yml"), b, files.DefaultPerm)
 	}
-	defer f.Close()
 
-	err = yaml.NewEncoder(f).Encode(&desc)
 	if err != nil {
-		return fmt.Errorf("failed creating metadata: %v", err)
+		return fmt.Errorf("failed creating project metadata: %v", err)
 	}
 
 	return nil
 }
 
-func GoModule(projectName string) ([]byte, error) {
+func GoModule(md metadata.Metadata) ([]byte, error) {
 	goModContents := fmt.Sprintf(`module %s
 
 go 1.12
 
-require github.com/gorilla/mux v1.7.1
-`, projectName)
+require (
+	github.com/go-yaml/yaml v2.1.0+incompatible
+	github.com/gorilla/mux v1.7.1
+)
+`, md.ModulePath())
 
 	return []byte(goModContents), nil
 }
 
+// GoSum returns the go.sum of the project, holding the checksums of the
+// modules required by GoModule, so the project builds without having to
+// resolve them first.
+func GoSum(md metadata.Metadata) ([]byte, error) {
+	return []byte(goSum), nil
+}
+
+// goSum holds the checksums of the versions of go-yaml and gorilla/mux
+// required by GoModule.
+const goSum = `github.com/go-yaml/yaml v2.1.0+incompatible h1:RYi2hDdss1u4YE7GwixGzWwVo47T8UQwnTLB6vQiq+o=
+github.com/go-yaml/yaml v2.1.0+incompatible/go.mod h1:w2MrLa16VYP0jy6N7M5kHaCkaLENm+P+Tv+MfurjSw0=
+github.com/gorilla/mux v1.7.1 h1:Dw4jY2nghMMRsh1ol8dv1axHkDwMQK2DHerMNJsIpJU=
+github.com/gorilla/mux v1.7.1/go.mod h1:1lud6UwP+6orDFRuTfBEV8e9/aOM/c4fVVCaMa2zaAs=
+`
+
+// genPath returns the import path of the service's gen package.
+func genPath(md metadata.Metadata) string {
+	return path.Join(md.ModulePath(), consts.GenFolder)
+}
+
 func httpHandlerFunc() *Statement {
 	return Func().Add(httpMethodParams())
 }
@@ -338,3 +299,45 @@ func httpMethodParams() *Statement {
 		Id("r").Op("*").Qual("net/http", "Request"),
 	)
 }
+
+// httpMethods returns the http package's constant for each of the standard
+// methods, and a string literal for anything else.
+func httpMethods(methods []string) func(*Group) {
+	return func(g *Group) {
+		for _, method := range methods {
+			g.Add(httpMethod(method))
+		}
+	}
+}
+
+// httpMethod returns the net/http constant of the HTTP method, or a string
+// literal if there is none.
+func httpMethod(method string) *Statement {
+	constant, ok := httpMethodConsts[strings.ToUpper(method)]
+	if !ok {
+		return Lit(method)
+	}
+
+	return Qual("net/http", constant)
+}
+
+var httpMethodConsts = map[string]string{
+	http.MethodGet:     "MethodGet",
+	http.MethodHead:    "MethodHead",
+	http.MethodPost:    "MethodPost",
+	http.MethodPut:     "MethodPut",
+	http.MethodPatch:   "MethodPatch",
+	http.MethodDelete:  "MethodDelete",
+	http.MethodConnect: "MethodConnect",
+	http.MethodOptions: "MethodOptions",
+	http.MethodTrace:   "MethodTrace",
+}
+
+// stringLits returns a string literal for each of the values.
+func stringLits(values []string) func(*Group) {
+	return func(g *Group) {
+		for _, value := range values {
+			g.Lit(value)
+		}
+	}
+}
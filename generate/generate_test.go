@@ -0,0 +1,516 @@
+package generate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"seed/consts"
+	"seed/metadata"
+	"seed/openapi"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testMetadata() metadata.Metadata {
+	md := metadata.Base(metadata.Info{Name: "test"})
+
+	md.Routes = append(md.Routes, metadata.Route{
+		Path:        "/users",
+		HttpMethods: []string{http.MethodPost, "PURGE"},
+		HandlerName: "CreateUser",
+	})
+
+	md.Middlwares = append(md.Middlwares, metadata.Middleware{
+		Paths:       []string{"/users"},
+		HandlerName: "AuthMw",
+		Priority:    10,
+	})
+
+	return md
+}
+
+func TestBootstrapFile_fromMetadata(t *testing.T) {
+	b, err := BootstrapFile(testMetadata())
+	if err != nil {
+		t.Fatalf("BootstrapFile() failed: %v", err)
+	}
+
+	actual := string(b)
+
+	assert.Contains(t, actual, "s.serviceImpl.Index()")
+	assert.Contains(t, actual, "s.serviceImpl.CreateUser()")
+	assert.Contains(t, actual, `[]string{http.MethodPost, "PURGE"}`)
+	assert.Contains(t, actual, `"/users"`)
+	assert.Contains(t, actual, "strictSlash: false")
+	assert.Contains(t, actual, "s.accessLog(route.name, handler)")
+	assert.Contains(t, actual, "s.serviceImpl.AuthMw,")
+	assert.Contains(t, actual, `paths:    []string{"/users"}`)
+	assert.Contains(t, actual, "priority: 10")
+}
+
+func TestInterfaceFile_fromMetadata(t *testing.T) {
+	b, err := InterfaceFile(testMetadata())
+	if err != nil {
+		t.Fatalf("InterfaceFile() failed: %v", err)
+	}
+
+	actual := string(b)
+
+	assert.Contains(t, actual, "Index() http.HandlerFunc")
+	assert.Contains(t, actual, "CreateUser() http.HandlerFunc")
+	assert.Contains(t, actual, "LogAccess(entry AccessEntry)")
+	assert.Contains(t, actual, "AuthMw(http.Handler) http.Handler")
+}
+
+func TestModulePath(t *testing.T) {
+	md := metadata.Base(metadata.Info{Name: "fleet"})
+	md.Module = "github.com/org/fleet-api/v2"
+
+	tests := []struct {
+		name     string
+		generate func(metadata.Metadata) ([]byte, error)
+		expected []string
+	}{
+		{
+			name:     "go.mod",
+			generate: GoModule,
+			expected: []string{"module github.com/org/fleet-api/v2\n"},
+		},
+		{
+			name:     "main",
+			generate: MainFile,
+			expected: []string{
+				`fleetapi "github.com/org/fleet-api/v2"`,
+				`gen "github.com/org/fleet-api/v2/gen"`,
+				"gen.New(&fleetapi.Server{}, cfg)",
+			},
+		},
+		{
+			name:     "service",
+			generate: ServiceFile,
+			expected: []string{"package fleetapi\n"},
+		},
+		{
+			name:     "interface",
+			generate: InterfaceFile,
+			expected: []string{"type FleetService interface"},
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := tt.generate(md)
+		if err != nil {
+			t.Fatalf("%s: generating failed: %v", tt.name, err)
+		}
+
+		for _, expected := range tt.expected {
+			assert.Contains(t, string(b), expected, tt.name)
+		}
+	}
+}
+
+func TestBootstrapFile_middlewareOrder(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{Path: "/users", HttpMethods: []string{http.MethodGet}, HandlerName: "Users"},
+			{Path: "/users/{id:[0-9]+}", HttpMethods: []string{http.MethodGet}, HandlerName: "User"},
+			{Path: "/admin/settings", HttpMethods: []string{http.MethodGet}, HandlerName: "Admin"},
+		},
+		Middlwares: []metadata.Middleware{
+			{HandlerName: "LogMw", Paths: []string{"*"}},
+			{HandlerName: "AuthMw", Paths: []string{"/users*"}, Priority: 10},
+			{HandlerName: "TraceMw", Paths: []string{"/users/{id:[0-9]+}", "/admin/*"}, Priority: 5},
+			{HandlerName: "AuditMw", Paths: []string{"/users/*"}, Priority: 10},
+		},
+	}
+
+	testGenerated(t, md, "middleware_order_test.go")
+}
+
+func TestBootstrapFile_hooks(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+		},
+	}
+
+	testGenerated(t, md, "hooks_test.go")
+}
+
+func TestBootstrapFile_health(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{Path: "/{page}", HttpMethods: []string{http.MethodGet}, HandlerName: "Page"},
+		},
+		Middlwares: []metadata.Middleware{
+			{Paths: []string{"*"}, HandlerName: "DenyMw"},
+		},
+		Health: &metadata.Health{Readiness: "/ready", Timeout: "50ms"},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "health_test.go")
+}
+
+func TestBootstrapFile_metrics(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{Path: "/users", HttpMethods: []string{http.MethodGet}, HandlerName: "Users"},
+			{Path: "/denied", HttpMethods: []string{http.MethodGet}, HandlerName: "Denied"},
+			{Path: "/slow", HttpMethods: []string{http.MethodGet}, HandlerName: "Slow"},
+			{Path: "/panic", HttpMethods: []string{http.MethodGet}, HandlerName: "Panic"},
+			{Path: "/stream", HttpMethods: []string{http.MethodGet}, HandlerName: "Stream"},
+			{Path: "/hijack", HttpMethods: []string{http.MethodGet}, HandlerName: "Hijack"},
+		},
+		Middlwares: []metadata.Middleware{
+			{Paths: []string{"/denied"}, HandlerName: "DenyMw"},
+		},
+		Metrics: &metadata.Metrics{Buckets: []float64{0.05, 1}},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "metrics_test.go")
+}
+
+func TestBootstrapFile_accessLog(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{Path: "/broken", HttpMethods: []string{http.MethodGet}, HandlerName: "Broken"},
+			{Path: "/denied", HttpMethods: []string{http.MethodGet}, HandlerName: "Denied"},
+			{Path: "/panic", HttpMethods: []string{http.MethodGet}, HandlerName: "Panic"},
+		},
+		Middlwares: []metadata.Middleware{
+			{Paths: []string{"/denied"}, HandlerName: "DenyMw"},
+		},
+		AccessLog: &metadata.AccessLog{},
+		Metrics:   &metadata.Metrics{},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "accesslog_test.go")
+}
+
+func TestBootstrapFile_accessLogLogfmt(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+		},
+		AccessLog: &metadata.AccessLog{Format: metadata.FormatLogfmt},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "accesslog_logfmt_test.go")
+}
+
+func TestConfigFile_loading(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+		},
+		Config: []metadata.Setting{
+			{Name: "db_url", Required: true, Description: "URL of the database."},
+			{Name: "workers", Type: metadata.TypeInt, Default: "4"},
+			{Name: "debug", Type: metadata.TypeBool},
+			{Name: "ratio", Type: metadata.TypeFloat, Default: "0.5"},
+			{Name: "cache_ttl", Type: metadata.TypeDuration, Env: "CACHE_TTL"},
+		},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "config_test.go")
+}
+
+func TestParamsFile_binding(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{
+				Path:        "/users/{id:[0-9]+}",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "User",
+				Params: []metadata.Param{
+					{Name: "id", In: metadata.InPath, Type: metadata.TypeInt},
+					{Name: "verbose", In: metadata.InQuery, Type: metadata.TypeBool},
+					{Name: "since", In: metadata.InQuery, Type: metadata.TypeTime, Default: "2019-01-01T00:00:00Z"},
+				},
+			},
+			{
+				Path:        "/search",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "Search",
+				Params: []metadata.Param{
+					{Name: "q", In: metadata.InQuery, Required: true},
+					{Name: "limit", In: metadata.InQuery, Type: metadata.TypeInt, Default: "10"},
+					{Name: "X-Request-Id", In: metadata.InHeader, Type: metadata.TypeUUID},
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "params_test.go")
+}
+
+func TestTypesFile_binding(t *testing.T) {
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{
+				Path:        "/teams/{team}/users",
+				HttpMethods: []string{http.MethodPost},
+				HandlerName: "CreateUser",
+				Params: []metadata.Param{
+					{Name: "team", In: metadata.InPath},
+				},
+				Request: &metadata.Body{Fields: []metadata.Field{
+					{Name: "email", Required: true},
+					{Name: "age", Type: metadata.TypeInt},
+					{Name: "manager_id", Type: metadata.TypeUUID},
+					{Name: "tags", Type: "[]string"},
+				}},
+				Response: &metadata.Body{Fields: []metadata.Field{
+					{Name: "id", Type: metadata.TypeUUID},
+					{Name: "team"},
+					{Name: "email"},
+				}},
+			},
+			{
+				Path:        "/users/{id}",
+				HttpMethods: []string{http.MethodDelete},
+				HandlerName: "DeleteUser",
+				Params: []metadata.Param{
+					{Name: "id", In: metadata.InPath, Type: metadata.TypeUUID},
+				},
+				Request: &metadata.Body{Fields: []metadata.Field{
+					{Name: "reason"},
+				}},
+			},
+			{
+				Path:        "/status",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "Status",
+				Response: &metadata.Body{Fields: []metadata.Field{
+					{Name: "healthy", Type: metadata.TypeBool},
+				}},
+			},
+		},
+	}
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "typed_test.go")
+}
+
+// clientMetadata returns a descriptor whose routes cover the ways a client
+// passes parameters and bodies.
+func clientMetadata() metadata.Metadata {
+	return metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{
+				Path:        "/teams/{team}/users",
+				HttpMethods: []string{http.MethodPost},
+				HandlerName: "CreateUser",
+				Params: []metadata.Param{
+					{Name: "team", In: metadata.InPath},
+					{Name: "notify", In: metadata.InQuery, Type: metadata.TypeBool, Default: "true"},
+					{Name: "X-Request-Id", In: metadata.InHeader, Type: metadata.TypeUUID, Required: true},
+				},
+				Request: &metadata.Body{Fields: []metadata.Field{
+					{Name: "email", Required: true},
+					{Name: "tags", Type: "[]string"},
+				}},
+				Response: &metadata.Body{Fields: []metadata.Field{
+					{Name: "team"},
+					{Name: "email"},
+					{Name: "notified", Type: metadata.TypeBool},
+				}},
+			},
+			{
+				Path:        "/users/{user_id:[0-9]+}/{type}",
+				HttpMethods: []string{http.MethodDelete, http.MethodPost},
+				HandlerName: "DeleteUser",
+				Request:     &metadata.Body{Fields: []metadata.Field{{Name: "reason"}}},
+			},
+			{
+				Path:        "/search",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "Search",
+				Params: []metadata.Param{
+					{Name: "q", In: metadata.InQuery, Required: true},
+					{Name: "limit", In: metadata.InQuery, Type: metadata.TypeInt, Default: "10"},
+					{Name: "since", In: metadata.InQuery, Type: metadata.TypeTime},
+				},
+			},
+		},
+	}
+}
+
+func TestClientFile_calls(t *testing.T) {
+	md := clientMetadata()
+
+	assert.NoError(t, md.Validate())
+
+	testGenerated(t, md, "client_test.go")
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	md := clientMetadata()
+	md.Routes = append(md.Routes,
+		metadata.Route{
+			Path:        "/users",
+			HttpMethods: []string{http.MethodGet},
+			HandlerName: "ListUsers",
+			Params:      []metadata.Param{{Name: "page", In: metadata.InQuery, Type: metadata.TypeInt}},
+			Response:    &metadata.Body{Type: "[]User"},
+		},
+		metadata.Route{
+			Path:        "/users/{id}",
+			HttpMethods: []string{http.MethodPut},
+			HandlerName: "Delete",
+			Request:     &metadata.Body{Type: "User"},
+			Response:    &metadata.Body{Type: "User"},
+		},
+	)
+
+	assert.NoError(t, md.Validate())
+
+	actual, err := TypeScriptClient(md)
+	if err != nil {
+		t.Fatalf("TypeScriptClient() failed = %v", err)
+	}
+
+	expected, err := ioutil.ReadFile(filepath.Join("..", "testdata", "client_ts.expected"))
+	if err != nil {
+		t.Fatalf("reading expected file: %v", err)
+	}
+
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestGenerated_openAPIImport(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("..", "testdata", "import.yml"))
+	if err != nil {
+		t.Fatalf("reading document: %v", err)
+	}
+
+	d, err := openapi.Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	md, _ := openapi.ToMetadata(d)
+	md.Name = "test"
+
+	assert.NoError(t, md.Validate())
+
+	testGeneratedWith(t, md, map[string]func(metadata.Metadata) ([]byte, error){
+		consts.TypesFile: d.TypesFile,
+	})
+}
+
+// testGenerated writes the gen folder generated from md into a temporary
+// module, along with the named test files from testdata/gen, then vets the
+// module and runs the tests of the gen package. The module cache is used
+// offline.
+func testGenerated(t *testing.T, md metadata.Metadata, testFiles ...string) {
+	testGeneratedWith(t, md, nil, testFiles...)
+}
+
+// testGeneratedWith runs testGenerated with additional files of the module,
+// generated from md, by path relative to the module's folder.
+func testGeneratedWith(t *testing.T, md metadata.Metadata, files map[string]func(metadata.Metadata) ([]byte, error), testFiles ...string) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	dir, err := ioutil.TempDir("", "seed-generated")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	genDir := filepath.Join(dir, consts.GenFolder)
+
+	for _, folder := range []string{filepath.Join(genDir, consts.ApiFolder), filepath.Join(dir, consts.ClientFolder)} {
+		err = os.MkdirAll(folder, 0755)
+		if err != nil {
+			t.Fatalf("creating %s folder: %v", folder, err)
+		}
+	}
+
+	write := func(path string, contents []byte) {
+		err := ioutil.WriteFile(path, contents, 0644)
+		if err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+
+	generators := map[string]func(metadata.Metadata) ([]byte, error){
+		filepath.Join(dir, "go.mod"):                               GoModule,
+		filepath.Join(dir, "go.sum"):                               GoSum,
+		filepath.Join(genDir, consts.InterfaceFile):                InterfaceFile,
+		filepath.Join(genDir, consts.BootstrapFile):                BootstrapFile,
+		filepath.Join(genDir, consts.ConfigFile):                   ConfigFile,
+		filepath.Join(genDir, consts.ApiFolder, consts.ParamsFile): ParamsFile,
+		filepath.Join(genDir, consts.ApiFolder, consts.TypesFile):  TypesFile,
+		filepath.Join(dir, consts.ClientFolder, consts.ClientFile): ClientFile,
+	}
+
+	for path, generator := range files {
+		generators[filepath.Join(dir, path)] = generator
+	}
+
+	for path, generator := range generators {
+		contents, err := generator(md)
+		if err != nil {
+			t.Fatalf("generating %s: %v", path, err)
+		}
+
+		write(path, contents)
+	}
+
+	for _, name := range testFiles {
+		contents, err := ioutil.ReadFile(filepath.Join("..", "testdata", consts.GenFolder, name))
+		if err != nil {
+			t.Fatalf("reading test file: %v", err)
+		}
+
+		write(filepath.Join(genDir, name), contents)
+	}
+
+	for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off")
+
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go %s on generated code failed: %v\n%s", args[0], err, out)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package generate
+
+import (
+	"seed/metadata"
+	"time"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// healthInterfaces adds the readiness check type, and the interface through
+// which the service implementation declares its checks.
+func healthInterfaces(f *File, md metadata.Metadata) {
+	if md.Health == nil {
+		return
+	}
+
+	f.Comment("// Check is a readiness check of the service, see ReadinessChecker.")
+	f.Type().Id("Check").Struct(
+		Comment("// Name identifies the check in the readiness report."),
+		Id("Name").String(),
+		Line(),
+		Comment("// Timeout is how long Func may run before the check fails. Defaults to "+
+			md.Health.CheckTimeout().String()+"."),
+		Id("Timeout").Qual("time", "Duration"),
+		Line(),
+		Comment("// Func runs the check, which fails if it returns an error. Its context is"),
+		Comment("// done once the timeout expires."),
+		Id("Func").Func().Params(Id("ctx").Qual("context", "Context")).Error(),
+	)
+
+	f.Comment("// ReadinessChecker is implemented by services which depend on other systems to")
+	f.Comment("// serve requests, e.g. a database. Its checks are run by the readiness endpoint,")
+	f.Comment("// see Service.Ready.")
+	f.Type().Id("ReadinessChecker").Interface(
+		Id(metadata.HookReadiness).Params().Index().Id("Check"),
+	)
+}
+
+// healthRoutes registers the health endpoints on the router, outside of the
+// middlewares and before the routes of the descriptor.
+func healthRoutes(g *Group, md metadata.Metadata) {
+	if md.Health == nil {
+		return
+	}
+
+	g.Comment("// The health endpoints are not wrapped in middlewares, so that probes are")
+	g.Comment("// neither logged nor rejected by them. They are registered first, so that")
+	g.Comment("// routes with path variables do not capture them.")
+
+	endpoints := []struct {
+		path, handler string
+	}{
+		{md.Health.LivenessPath(), "liveness"},
+		{md.Health.ReadinessPath(), "readiness"},
+	}
+
+	for _, e := range endpoints {
+		g.Id("s").Dot("router").Dot("HandleFunc").Call(
+			Lit(e.path), Id("s").Dot(e.handler),
+		).Dot("Methods").Call(Qual("net/http", "MethodGet"))
+	}
+	g.Empty()
+}
+
+// bootstrapHealth adds the handlers of the health endpoints, and the
+// functions running the readiness checks of the service implementation.
+func bootstrapHealth(f *File, md metadata.Metadata) {
+	if md.Health == nil {
+		return
+	}
+
+	f.Comment("// defaultCheckTimeout is how long a readiness check may run when it does not set")
+	f.Comment("// its own timeout.")
+	f.Const().Id("defaultCheckTimeout").Op("=").Add(durationExpr(md.Health.CheckTimeout()))
+
+	f.Comment("// Statuses of the health reports and of their checks.")
+	f.Const().Defs(
+		Id("StatusOK").Op("=").Lit("ok"),
+		Id("StatusFailed").Op("=").Lit("failed"),
+	)
+
+	f.Comment("// HealthReport is the JSON body of the answers of the health endpoints.")
+	f.Type().Id("HealthReport").Struct(
+		Id("Status").String().Tag(map[string]string{"json": "status"}),
+		Id("Checks").Index().Id("CheckResult").Tag(map[string]string{"json": "checks,omitempty"}),
+	)
+
+	f.Comment("// CheckResult is the outcome of a readiness check.")
+	f.Type().Id("CheckResult").Struct(
+		Id("Name").String().Tag(map[string]string{"json": "name"}),
+		Id("Status").String().Tag(map[string]string{"json": "status"}),
+		Id("Error").String().Tag(map[string]string{"json": "error,omitempty"}),
+		Id("Duration").String().Tag(map[string]string{"json": "duration"}),
+	)
+
+	f.Comment("// liveness answers with a 200 OK as long as the service serves requests.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("liveness").Add(httpMethodParams()).Block(
+		Id("writeReport").Call(Id("w"), Id("HealthReport").Values(Dict{
+			Id("Status"): Id("StatusOK"),
+		})),
+	)
+
+	f.Comment("// readiness answers with the report of the readiness checks, and a 503 Service")
+	f.Comment("// Unavailable if any of them failed.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("readiness").Add(httpMethodParams()).Block(
+		Id("writeReport").Call(Id("w"), Id("s").Dot("Ready").Call(Id("r").Dot("Context").Call())),
+	)
+
+	f.Comment("// Ready runs the readiness checks of the service implementation concurrently, if")
+	f.Comment("// it implements ReadinessChecker, and reports their outcome in declaration order.")
+	f.Comment("// The service is ready if every check succeeded.")
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id("Ready").Params(Id("ctx").Qual("context", "Context")).Id("HealthReport").Block(
+		Id("report").Op(":=").Id("HealthReport").Values(Dict{
+			Id("Status"): Id("StatusOK"),
+		}),
+		Empty(),
+		List(Id("checker"), Id("ok")).Op(":=").Id("s").Dot("serviceImpl").Assert(Id("ReadinessChecker")),
+		If(Op("!").Id("ok")).Block(
+			Return(Id("report")),
+		),
+		Empty(),
+		Id("checks").Op(":=").Id("checker").Dot(metadata.HookReadiness).Call(),
+		Id("report").Dot("Checks").Op("=").Make(Index().Id("CheckResult"), Len(Id("checks"))),
+		Empty(),
+		Var().Id("wg").Qual("sync", "WaitGroup"),
+		For(List(Id("i"), Id("check")).Op(":=").Range().Id("checks")).Block(
+			Id("wg").Dot("Add").Call(Lit(1)),
+			Go().Func().Params(Id("i").Int(), Id("check").Id("Check")).Block(
+				Defer().Id("wg").Dot("Done").Call(),
+				Id("report").Dot("Checks").Index(Id("i")).Op("=").Id("runCheck").Call(Id("ctx"), Id("check")),
+			).Call(Id("i"), Id("check")),
+		),
+		Id("wg").Dot("Wait").Call(),
+		Empty(),
+		For(List(Id("_"), Id("result")).Op(":=").Range().Id("report").Dot("Checks")).Block(
+			If(Id("result").Dot("Status").Op("!=").Id("StatusOK")).Block(
+				Id("report").Dot("Status").Op("=").Id("StatusFailed"),
+			),
+		),
+		Empty(),
+		Return(Id("report")),
+	)
+
+	f.Comment("// runCheck runs the check, which fails if it does not return before its timeout.")
+	f.Comment("// The check keeps running in the background in that case, until it returns.")
+	f.Func().Id("runCheck").Params(
+		Id("ctx").Qual("context", "Context"),
+		Id("check").Id("Check"),
+	).Id("CheckResult").Block(
+		Id("timeout").Op(":=").Id("check").Dot("Timeout"),
+		If(Id("timeout").Op("<=").Lit(0)).Block(
+			Id("timeout").Op("=").Id("defaultCheckTimeout"),
+		),
+		Empty(),
+		List(Id("ctx"), Id("cancel")).Op(":=").Qual("context", "WithTimeout").Call(Id("ctx"), Id("timeout")),
+		Defer().Id("cancel").Call(),
+		Empty(),
+		Id("start").Op(":=").Qual("time", "Now").Call(),
+		Id("done").Op(":=").Make(Chan().Error(), Lit(1)),
+		Empty(),
+		Go().Func().Params().Block(
+			Id("done").Op("<-").Id("check").Dot("Func").Call(Id("ctx")),
+		).Call(),
+		Empty(),
+		Var().Id("err").Error(),
+		Select().Block(
+			Case(Id("err").Op("=").Op("<-").Id("done")),
+			Case(Op("<-").Id("ctx").Dot("Done").Call()).Block(
+				Id("err").Op("=").Id("ctx").Dot("Err").Call(),
+			),
+		),
+		Empty(),
+		Id("result").Op(":=").Id("CheckResult").Values(Dict{
+			Id("Name"):     Id("check").Dot("Name"),
+			Id("Status"):   Id("StatusOK"),
+			Id("Duration"): Qual("time", "Since").Call(Id("start")).Dot("String").Call(),
+		}),
+		Empty(),
+		If(Id("err").Op("!=").Nil()).Block(
+			List(Id("result").Dot("Status"), Id("result").Dot("Error")).Op("=").List(
+				Id("StatusFailed"), Id("err").Dot("Error").Call(),
+			),
+		),
+		Empty(),
+		Return(Id("result")),
+	)
+
+	f.Comment("// writeReport answers with the report encoded as JSON, and a 503 Service")
+	f.Comment("// Unavailable unless its status is StatusOK.")
+	f.Func().Id("writeReport").Params(
+		Id("w").Qual("net/http", "ResponseWriter"),
+		Id("report").Id("HealthReport"),
+	).Block(
+		Id("status").Op(":=").Qual("net/http", "StatusOK"),
+		If(Id("report").Dot("Status").Op("!=").Id("StatusOK")).Block(
+			Id("status").Op("=").Qual("net/http", "StatusServiceUnavailable"),
+		),
+		Empty(),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		Id("w").Dot("WriteHeader").Call(Id("status")),
+		Qual("encoding/json", "NewEncoder").Call(Id("w")).Dot("Encode").Call(Id("report")),
+	)
+}
+
+// durationExpr returns the expression of the duration in the largest unit
+// dividing it, e.g. 1500 * time.Millisecond.
+func durationExpr(d time.Duration) *Statement {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+
+	for _, u := range units {
+		if d%u.unit != 0 {
+			continue
+		}
+
+		if d == u.unit {
+			return Qual("time", u.name)
+		}
+
+		return Lit(int(d/u.unit)).Op("*").Qual("time", u.name)
+	}
+
+	return Qual("time", "Duration").Call(Lit(int(d)))
+}
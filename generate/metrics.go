@@ -0,0 +1,314 @@
+package generate
+
+import (
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// metricsRoutes registers the metrics endpoint on the router, outside of the
+// middlewares and before the routes of the descriptor.
+func metricsRoutes(g *Group, md metadata.Metadata) {
+	if md.Metrics == nil {
+		return
+	}
+
+	g.Comment("// The metrics endpoint is not wrapped in middlewares, so that scrapes are")
+	g.Comment("// neither logged nor rejected by them. It is registered first, so that routes")
+	g.Comment("// with path variables do not capture it.")
+	g.Id("s").Dot("router").Dot("Handle").Call(
+		Lit(md.Metrics.MetricsPath()), Id("s").Dot("metrics"),
+	).Dot("Methods").Call(Qual("net/http", "MethodGet"))
+	g.Empty()
+}
+
+// newMetrics returns the expression creating the metrics of the routes of the
+// descriptor.
+func newMetrics(md metadata.Metadata) *Statement {
+	return Id("newMetrics").CallFunc(func(g *Group) {
+		for _, r := range md.Routes {
+			g.Lit(r.HandlerName)
+		}
+	})
+}
+
+// bootstrapMetrics adds the metrics recording the requests served by each
+// route, and exposing them in the Prometheus text format.
+func bootstrapMetrics(f *File, md metadata.Metadata) {
+	if md.Metrics == nil {
+		return
+	}
+
+	f.Comment("// metricsBuckets are the upper bounds of the buckets of the request duration")
+	f.Comment("// histogram, in seconds.")
+	f.Var().Id("metricsBuckets").Op("=").Index().Float64().ValuesFunc(func(g *Group) {
+		for _, b := range md.Metrics.DurationBuckets() {
+			g.Lit(b)
+		}
+	})
+
+	f.Comment("// metrics records the requests served by each route, labelled by the name of")
+	f.Comment("// its handler and by the status of the response. It serves them in the")
+	f.Comment("// Prometheus text format. Requests matching no route are not recorded.")
+	f.Type().Id("metrics").Struct(
+		Id("mu").Qual("sync", "Mutex"),
+		Id("inFlight").Map(String()).Int(),
+		Id("requests").Map(Id("requestKey")).Op("*").Id("requestStats"),
+	)
+
+	f.Comment("// requestKey identifies the requests served by a handler with a status.")
+	f.Type().Id("requestKey").Struct(
+		Id("handler").String(),
+		Id("status").Int(),
+	)
+
+	f.Comment("// requestStats holds the number of requests, the sum of their durations in")
+	f.Comment("// seconds, and the number of them lasting at most each of metricsBuckets.")
+	f.Type().Id("requestStats").Struct(
+		Id("count").Int(),
+		Id("sum").Float64(),
+		Id("buckets").Index().Int(),
+	)
+
+	f.Comment("// newMetrics returns the metrics of the handlers, none of which is serving a")
+	f.Comment("// request yet.")
+	f.Func().Id("newMetrics").Params(Id("handlers").Op("...").String()).Op("*").Id("metrics").Block(
+		Id("m").Op(":=").Op("&").Id("metrics").Values(Dict{
+			Id("inFlight"): Make(Map(String()).Int()),
+			Id("requests"): Make(Map(Id("requestKey")).Op("*").Id("requestStats")),
+		}),
+		Empty(),
+		For(List(Id("_"), Id("handler")).Op(":=").Range().Id("handlers")).Block(
+			Id("m").Dot("inFlight").Index(Id("handler")).Op("=").Lit(0),
+		),
+		Empty(),
+		Return(Id("m")),
+	)
+
+	f.Comment("// instrument wraps the handler of a route, recording its requests. Requests")
+	f.Comment("// whose handler panicked are recorded with a 500 Internal Server Error.")
+	f.Func().Params(
+		Id("m").Op("*").Id("metrics"),
+	).Id("instrument").Params(
+		Id("handler").String(),
+		Id("next").Qual("net/http", "Handler"),
+	).Qual("net/http", "Handler").Block(
+		Return(Qual("net/http", "HandlerFunc").Call(httpHandlerFunc().Block(
+			Id("m").Dot("mu").Dot("Lock").Call(),
+			Id("m").Dot("inFlight").Index(Id("handler")).Op("++"),
+			Id("m").Dot("mu").Dot("Unlock").Call(),
+			Empty(),
+			Id("start").Op(":=").Qual("time", "Now").Call(),
+			Id("rec").Op(":=").Op("&").Id("responseRecorder").Values(Dict{
+				Id("ResponseWriter"): Id("w"),
+			}),
+			Id("served").Op(":=").False(),
+			Empty(),
+			Defer().Func().Params().Block(
+				Id("status").Op(":=").Id("rec").Dot("Status").Call(),
+				If(Op("!").Id("served")).Block(
+					Id("status").Op("=").Qual("net/http", "StatusInternalServerError"),
+				),
+				Empty(),
+				Id("m").Dot("observe").Call(Id("handler"), Id("status"), Qual("time", "Since").Call(Id("start"))),
+			).Call(),
+			Empty(),
+			Id("next").Dot("ServeHTTP").Call(Id("rec"), Id("r")),
+			Id("served").Op("=").True(),
+		))),
+	)
+
+	f.Comment("// observe records a request served by the handler.")
+	f.Func().Params(
+		Id("m").Op("*").Id("metrics"),
+	).Id("observe").Params(
+		Id("handler").String(),
+		Id("status").Int(),
+		Id("d").Qual("time", "Duration"),
+	).Block(
+		Id("m").Dot("mu").Dot("Lock").Call(),
+		Defer().Id("m").Dot("mu").Dot("Unlock").Call(),
+		Empty(),
+		Id("m").Dot("inFlight").Index(Id("handler")).Op("--"),
+		Empty(),
+		Id("key").Op(":=").Id("requestKey").Values(Dict{
+			Id("handler"): Id("handler"),
+			Id("status"):  Id("status"),
+		}),
+		Empty(),
+		List(Id("stats"), Id("ok")).Op(":=").Id("m").Dot("requests").Index(Id("key")),
+		If(Op("!").Id("ok")).Block(
+			Id("stats").Op("=").Op("&").Id("requestStats").Values(Dict{
+				Id("buckets"): Make(Index().Int(), Len(Id("metricsBuckets"))),
+			}),
+			Id("m").Dot("requests").Index(Id("key")).Op("=").Id("stats"),
+		),
+		Empty(),
+		Id("seconds").Op(":=").Id("d").Dot("Seconds").Call(),
+		Id("stats").Dot("count").Op("++"),
+		Id("stats").Dot("sum").Op("+=").Id("seconds"),
+		Empty(),
+		For(List(Id("i"), Id("le")).Op(":=").Range().Id("metricsBuckets")).Block(
+			If(Id("seconds").Op("<=").Id("le")).Block(
+				Id("stats").Dot("buckets").Index(Id("i")).Op("++"),
+			),
+		),
+	)
+
+	fprintf := func(format string, args ...Code) *Statement {
+		return Qual("fmt", "Fprintf").Call(append([]Code{Op("&").Id("b"), Lit(format)}, args...)...)
+	}
+
+	header := func(name, help, kind string) *Statement {
+		return Id("b").Dot("WriteString").Call(Lit("# HELP " + name + " " + help + "\n# TYPE " + name + " " + kind + "\n"))
+	}
+
+	f.Comment("// ServeHTTP writes the metrics in the Prometheus text exposition format, sorted")
+	f.Comment("// by handler and status.")
+	f.Func().Params(
+		Id("m").Op("*").Id("metrics"),
+	).Id("ServeHTTP").Add(httpMethodParams()).Block(
+		Id("m").Dot("mu").Dot("Lock").Call(),
+		Defer().Id("m").Dot("mu").Dot("Unlock").Call(),
+		Empty(),
+		Id("keys").Op(":=").Make(Index().Id("requestKey"), Lit(0), Len(Id("m").Dot("requests"))),
+		For(Id("key").Op(":=").Range().Id("m").Dot("requests")).Block(
+			Id("keys").Op("=").Append(Id("keys"), Id("key")),
+		),
+		Empty(),
+		Qual("sort", "Slice").Call(Id("keys"), Func().Params(List(Id("i"), Id("j")).Int()).Bool().Block(
+			If(Id("keys").Index(Id("i")).Dot("handler").Op("!=").Id("keys").Index(Id("j")).Dot("handler")).Block(
+				Return(Id("keys").Index(Id("i")).Dot("handler").Op("<").Id("keys").Index(Id("j")).Dot("handler")),
+			),
+			Empty(),
+			Return(Id("keys").Index(Id("i")).Dot("status").Op("<").Id("keys").Index(Id("j")).Dot("status")),
+		)),
+		Empty(),
+		Id("handlers").Op(":=").Make(Index().String(), Lit(0), Len(Id("m").Dot("inFlight"))),
+		For(Id("handler").Op(":=").Range().Id("m").Dot("inFlight")).Block(
+			Id("handlers").Op("=").Append(Id("handlers"), Id("handler")),
+		),
+		Empty(),
+		Qual("sort", "Strings").Call(Id("handlers")),
+		Empty(),
+		Id("labels").Op(":=").Func().Params(Id("key").Id("requestKey")).String().Block(
+			Return(Qual("fmt", "Sprintf").Call(Lit(`handler="%s",status="%d"`), Id("key").Dot("handler"), Id("key").Dot("status"))),
+		),
+		Empty(),
+		Var().Id("b").Qual("bytes", "Buffer"),
+		Empty(),
+		header("http_requests_total", "Number of HTTP requests served.", "counter"),
+		For(List(Id("_"), Id("key")).Op(":=").Range().Id("keys")).Block(
+			fprintf("http_requests_total{%s} %d\n", Id("labels").Call(Id("key")), Id("m").Dot("requests").Index(Id("key")).Dot("count")),
+		),
+		Empty(),
+		header("http_request_duration_seconds", "Duration of the HTTP requests served.", "histogram"),
+		For(List(Id("_"), Id("key")).Op(":=").Range().Id("keys")).Block(
+			Id("stats").Op(":=").Id("m").Dot("requests").Index(Id("key")),
+			For(List(Id("i"), Id("le")).Op(":=").Range().Id("metricsBuckets")).Block(
+				fprintf("http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+					Id("labels").Call(Id("key")),
+					Qual("strconv", "FormatFloat").Call(Id("le"), LitRune('g'), Lit(-1), Lit(64)),
+					Id("stats").Dot("buckets").Index(Id("i")),
+				),
+			),
+			fprintf("http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", Id("labels").Call(Id("key")), Id("stats").Dot("count")),
+			fprintf("http_request_duration_seconds_sum{%s} %s\n",
+				Id("labels").Call(Id("key")),
+				Qual("strconv", "FormatFloat").Call(Id("stats").Dot("sum"), LitRune('g'), Lit(-1), Lit(64)),
+			),
+			fprintf("http_request_duration_seconds_count{%s} %d\n", Id("labels").Call(Id("key")), Id("stats").Dot("count")),
+		),
+		Empty(),
+		header("http_requests_in_flight", "Number of HTTP requests being served.", "gauge"),
+		For(List(Id("_"), Id("handler")).Op(":=").Range().Id("handlers")).Block(
+			fprintf("http_requests_in_flight{handler=\"%s\"} %d\n", Id("handler"), Id("m").Dot("inFlight").Index(Id("handler"))),
+		),
+		Empty(),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("text/plain; version=0.0.4; charset=utf-8")),
+		Id("w").Dot("Write").Call(Id("b").Dot("Bytes").Call()),
+	)
+}
+
+// responseRecorder adds the response writer recording the status and the
+// size of the response.
+func responseRecorder(f *File) {
+	f.Comment("// responseRecorder is an http.ResponseWriter recording the status and the size")
+	f.Comment("// of the response written through it. It forwards http.Flusher and")
+	f.Comment("// http.Hijacker to the underlying http.ResponseWriter.")
+	f.Type().Id("responseRecorder").Struct(
+		Qual("net/http", "ResponseWriter"),
+		Line(),
+		Id("status").Int(),
+		Id("bytes").Int(),
+	)
+
+	f.Comment("// WriteHeader records the status of the response, and writes it.")
+	f.Func().Params(
+		Id("rec").Op("*").Id("responseRecorder"),
+	).Id("WriteHeader").Params(Id("status").Int()).Block(
+		If(Id("rec").Dot("status").Op("==").Lit(0)).Block(
+			Id("rec").Dot("status").Op("=").Id("status"),
+		),
+		Empty(),
+		Id("rec").Dot("ResponseWriter").Dot("WriteHeader").Call(Id("status")),
+	)
+
+	f.Comment("// Write writes b to the response, and records its size.")
+	f.Func().Params(
+		Id("rec").Op("*").Id("responseRecorder"),
+	).Id("Write").Params(Id("b").Index().Byte()).Params(Int(), Error()).Block(
+		If(Id("rec").Dot("status").Op("==").Lit(0)).Block(
+			Id("rec").Dot("status").Op("=").Qual("net/http", "StatusOK"),
+		),
+		Empty(),
+		List(Id("n"), Err()).Op(":=").Id("rec").Dot("ResponseWriter").Dot("Write").Call(Id("b")),
+		Id("rec").Dot("bytes").Op("+=").Id("n"),
+		Empty(),
+		Return(Id("n"), Err()),
+	)
+
+	f.Comment("// Flush sends the response written so far to the client, if the underlying")
+	f.Comment("// http.ResponseWriter is an http.Flusher.")
+	f.Func().Params(
+		Id("rec").Op("*").Id("responseRecorder"),
+	).Id("Flush").Params().Block(
+		List(Id("flusher"), Id("ok")).Op(":=").Id("rec").Dot("ResponseWriter").Assert(Qual("net/http", "Flusher")),
+		If(Op("!").Id("ok")).Block(
+			Return(),
+		),
+		Empty(),
+		If(Id("rec").Dot("status").Op("==").Lit(0)).Block(
+			Id("rec").Dot("status").Op("=").Qual("net/http", "StatusOK"),
+		),
+		Empty(),
+		Id("flusher").Dot("Flush").Call(),
+	)
+
+	f.Comment("// Hijack lets the handler take over the connection, if the underlying")
+	f.Comment("// http.ResponseWriter is an http.Hijacker.")
+	f.Func().Params(
+		Id("rec").Op("*").Id("responseRecorder"),
+	).Id("Hijack").Params().Params(
+		Qual("net", "Conn"), Op("*").Qual("bufio", "ReadWriter"), Error(),
+	).Block(
+		List(Id("hijacker"), Id("ok")).Op(":=").Id("rec").Dot("ResponseWriter").Assert(Qual("net/http", "Hijacker")),
+		If(Op("!").Id("ok")).Block(
+			Return(Nil(), Nil(), Qual("errors", "New").Call(Lit("response writer cannot be hijacked"))),
+		),
+		Empty(),
+		Return(Id("hijacker").Dot("Hijack").Call()),
+	)
+
+	f.Comment("// Status returns the status of the response, which is a 200 OK if the handler")
+	f.Comment("// wrote nothing.")
+	f.Func().Params(
+		Id("rec").Op("*").Id("responseRecorder"),
+	).Id("Status").Params().Int().Block(
+		If(Id("rec").Dot("status").Op("==").Lit(0)).Block(
+			Return(Qual("net/http", "StatusOK")),
+		),
+		Empty(),
+		Return(Id("rec").Dot("status")),
+	)
+}
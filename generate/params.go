@@ -0,0 +1,338 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"seed/consts"
+	"seed/metadata"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// paramParsers holds the name of the generated function converting a raw
+// value to each of the parameter types.
+var paramParsers = map[string]string{
+	metadata.TypeString: "parseString",
+	metadata.TypeInt:    "parseInt",
+	metadata.TypeUUID:   "parseUUID",
+	metadata.TypeBool:   "parseBool",
+	metadata.TypeTime:   "parseTime",
+}
+
+// ParamsFile generates the api package, which holds the parameters of each
+// route that declares some, along with the code extracting them from the
+// requests.
+func ParamsFile(md metadata.Metadata) ([]byte, error) {
+	f := NewFilePathName(apiPath(md), consts.ApiFolder)
+
+	paramsError(f)
+	paramsHelpers(f)
+
+	for _, r := range md.Routes {
+		if len(r.Params) > 0 {
+			routeParams(f, r)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// apiPath returns the import path of the service's api package.
+func apiPath(md metadata.Metadata) string {
+	return path.Join(genPath(md), consts.ApiFolder)
+}
+
+// paramsError adds the Error type, describing a bad parameter, and the
+// function writing errors to the response.
+func paramsError(f *File) {
+	f.Comment("// Error describes a parameter or a field of a request which is missing or")
+	f.Comment("// malformed, or any other reason a request failed.")
+	f.Type().Id("Error").Struct(
+		Comment("// Param is the name of the parameter or field, if any."),
+		Id("Param").String().Tag(map[string]string{"json": "param,omitempty"}),
+		Empty(),
+		Comment("// In is where the parameter is found: \"path\", \"query\", \"header\" or"),
+		Comment("// \"body\"."),
+		Id("In").String().Tag(map[string]string{"json": "in,omitempty"}),
+		Empty(),
+		Comment("// Message explains what is wrong."),
+		Id("Message").String().Tag(map[string]string{"json": "message"}),
+	)
+
+	f.Func().Params(Id("e").Op("*").Id("Error")).Id("Error").Params().String().Block(
+		If(Id("e").Dot("Param").Op("==").Lit("")).Block(
+			Return(Id("e").Dot("Message")),
+		),
+		Empty(),
+		Return(Qual("fmt", "Sprintf").Call(Lit("%s parameter %q: %s"), Id("e").Dot("In"), Id("e").Dot("Param"), Id("e").Dot("Message"))),
+	)
+
+	f.Comment("// WriteError answers the request with the status code, and the error encoded")
+	f.Comment("// as JSON in the body.")
+	f.Func().Id("WriteError").Params(
+		Id("w").Qual("net/http", "ResponseWriter"),
+		Id("status").Int(),
+		Id("err").Error(),
+	).Block(
+		List(Id("e"), Id("ok")).Op(":=").Id("err").Assert(Op("*").Id("Error")),
+		If(Op("!").Id("ok")).Block(
+			Id("e").Op("=").Op("&").Id("Error").Values(Dict{Id("Message"): Id("err").Dot("Error").Call()}),
+		),
+		Empty(),
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		Id("w").Dot("WriteHeader").Call(Id("status")),
+		Qual("encoding/json", "NewEncoder").Call(Id("w")).Dot("Encode").Call(
+			Struct(
+				Id("Error").Op("*").Id("Error").Tag(map[string]string{"json": "error"}),
+			).Values(Id("e")),
+		),
+	)
+}
+
+// paramsHelpers adds the functions looking parameters up in the requests,
+// and converting them to their types.
+func paramsHelpers(f *File) {
+	f.Comment("// lookup returns the raw value of a parameter, and whether the request holds it.")
+	f.Func().Id("lookup").Params(
+		Id("r").Op("*").Qual("net/http", "Request"),
+		List(Id("in"), Id("name")).String(),
+	).Params(String(), Bool()).Block(
+		Switch(Id("in")).Block(
+			Case(Lit(metadata.InPath)).Block(
+				List(Id("value"), Id("ok")).Op(":=").Qual(mux, "Vars").Call(Id("r")).Index(Id("name")),
+				Return(Id("value"), Id("ok")),
+			),
+			Case(Lit(metadata.InQuery)).Block(
+				Id("values").Op(":=").Id("r").Dot("URL").Dot("Query").Call().Index(Id("name")),
+				If(Len(Id("values")).Op("==").Lit(0)).Block(
+					Return(Lit(""), False()),
+				),
+				Return(Id("values").Index(Lit(0)), True()),
+			),
+			Default().Block(
+				Id("values").Op(":=").Id("r").Dot("Header").Index(
+					Qual("net/http", "CanonicalHeaderKey").Call(Id("name")),
+				),
+				If(Len(Id("values")).Op("==").Lit(0)).Block(
+					Return(Lit(""), False()),
+				),
+				Return(Id("values").Index(Lit(0)), True()),
+			),
+		),
+	)
+
+	f.Func().Id("missing").Params(List(Id("in"), Id("name")).String()).Error().Block(
+		Return(Op("&").Id("Error").Values(Dict{
+			Id("Param"):   Id("name"),
+			Id("In"):      Id("in"),
+			Id("Message"): Lit("is required"),
+		})),
+	)
+
+	f.Func().Id("invalid").Params(List(Id("in"), Id("name")).String(), Id("err").Error()).Error().Block(
+		Return(Op("&").Id("Error").Values(Dict{
+			Id("Param"):   Id("name"),
+			Id("In"):      Id("in"),
+			Id("Message"): Id("err").Dot("Error").Call(),
+		})),
+	)
+
+	rawParam := Params(Id("raw").String())
+
+	f.Func().Id("parseString").Add(rawParam.Clone()).Params(String(), Error()).Block(
+		Return(Id("raw"), Nil()),
+	)
+
+	f.Func().Id("parseInt").Add(rawParam.Clone()).Params(Int(), Error()).Block(
+		List(Id("v"), Id("err")).Op(":=").Qual("strconv", "Atoi").Call(Id("raw")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Lit(0), Qual("fmt", "Errorf").Call(Lit("%q is not an integer"), Id("raw"))),
+		),
+		Return(Id("v"), Nil()),
+	)
+
+	f.Var().Id("uuidPattern").Op("=").Qual("regexp", "MustCompile").Call(Lit(metadata.UUIDPattern))
+
+	f.Func().Id("parseUUID").Add(rawParam.Clone()).Params(String(), Error()).Block(
+		If(Op("!").Id("uuidPattern").Dot("MatchString").Call(Id("raw"))).Block(
+			Return(Lit(""), Qual("fmt", "Errorf").Call(Lit("%q is not a UUID"), Id("raw"))),
+		),
+		Return(Id("raw"), Nil()),
+	)
+
+	f.Func().Id("parseBool").Add(rawParam.Clone()).Params(Bool(), Error()).Block(
+		List(Id("v"), Id("err")).Op(":=").Qual("strconv", "ParseBool").Call(Id("raw")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(False(), Qual("fmt", "Errorf").Call(Lit("%q is not a boolean"), Id("raw"))),
+		),
+		Return(Id("v"), Nil()),
+	)
+
+	f.Func().Id("parseTime").Add(rawParam.Clone()).Params(Qual("time", "Time"), Error()).Block(
+		List(Id("v"), Id("err")).Op(":=").Qual("time", "Parse").Call(Qual("time", "RFC3339"), Id("raw")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Qual("time", "Time").Values(), Qual("fmt", "Errorf").Call(Lit("%q is not an RFC 3339 time"), Id("raw"))),
+		),
+		Return(Id("v"), Nil()),
+	)
+}
+
+// routeParams adds the struct holding the parameters of the route, and the
+// functions extracting them from the requests.
+func routeParams(f *File, r metadata.Route) {
+	typeName := r.HandlerName + "Params"
+	key := strings.ToLower(r.HandlerName[:1]) + r.HandlerName[1:] + "ParamsKey"
+
+	f.Commentf("// %s holds the parameters of the requests served by %s.", typeName, r.HandlerName)
+	f.Type().Id(typeName).StructFunc(func(g *Group) {
+		for _, p := range r.Params {
+			g.Id(p.FieldName()).Add(paramType(p))
+		}
+	})
+
+	newParams(f, r, typeName)
+
+	f.Commentf("// Parse%s extracts the parameters of the requests served by %s.", typeName, r.HandlerName)
+	f.Func().Id("Parse"+typeName).Params(
+		Id("r").Op("*").Qual("net/http", "Request"),
+	).Params(Id(typeName), Error()).BlockFunc(func(g *Group) {
+		g.Var().Defs(
+			Id("p").Id(typeName),
+			Id("raw").String(),
+			Id("ok").Bool(),
+			Id("err").Error(),
+		)
+
+		for _, p := range r.Params {
+			g.Line()
+			parseParam(g, p)
+		}
+
+		g.Line()
+		g.Return(Id("p"), Nil())
+	})
+
+	f.Type().Id(key).Struct()
+
+	f.Commentf("// Get%s returns the parameters of a request served by %s, which were", typeName, r.HandlerName)
+	f.Comment("// extracted before calling the handler.")
+	f.Func().Id("Get"+typeName).Params(
+		Id("r").Op("*").Qual("net/http", "Request"),
+	).Id(typeName).Block(
+		List(Id("p"), Id("_")).Op(":=").Id("r").Dot("Context").Call().Dot("Value").Call(Id(key).Values()).Assert(Id(typeName)),
+		Return(Id("p")),
+	)
+
+	f.Commentf("// Bind%s extracts the parameters of the requests before calling next,", typeName)
+	f.Commentf("// which gets them through Get%s. Requests with missing or malformed", typeName)
+	f.Comment("// parameters are answered with a 400 Bad Request.")
+	f.Func().Id("Bind"+typeName).Params(
+		Id("next").Qual("net/http", "HandlerFunc"),
+	).Qual("net/http", "HandlerFunc").Block(
+		Return(httpHandlerFunc().Block(
+			List(Id("p"), Id("err")).Op(":=").Id("Parse"+typeName).Call(Id("r")),
+			If(Id("err").Op("!=").Nil()).Block(
+				Id("WriteError").Call(Id("w"), Qual("net/http", "StatusBadRequest"), Id("err")),
+				Return(),
+			),
+			Empty(),
+			Id("next").Call(
+				Id("w"),
+				Id("r").Dot("WithContext").Call(
+					Qual("context", "WithValue").Call(Id("r").Dot("Context").Call(), Id(key).Values(), Id("p")),
+				),
+			),
+		)),
+	)
+}
+
+// newParams adds the function returning the parameters of the route holding
+// their defaults, if any of them has one.
+func newParams(f *File, r metadata.Route, typeName string) {
+	var defaults []metadata.Param
+
+	for _, p := range r.Params {
+		if p.Default != "" {
+			defaults = append(defaults, p)
+		}
+	}
+
+	if len(defaults) == 0 {
+		return
+	}
+
+	f.Commentf("// New%s returns the parameters of the requests served by %s, holding", typeName, r.HandlerName)
+	f.Comment("// the defaults of the optional ones. Clients always send the parameters having")
+	f.Comment("// a default, so they should start from these.")
+	f.Func().Id("New" + typeName).Params().Id(typeName).BlockFunc(func(g *Group) {
+		g.Var().Id("p").Id(typeName)
+		g.Line()
+
+		for _, p := range defaults {
+			g.List(Id("p").Dot(p.FieldName()), Id("_")).Op("=").Id(paramParsers[p.ParamType()]).Call(Lit(p.Default))
+		}
+
+		g.Line()
+		g.Return(Id("p"))
+	})
+}
+
+// parseParam adds the code looking the parameter up in the request, and
+// converting it to its type.
+func parseParam(g *Group, p metadata.Param) {
+	in, name := Lit(p.In), Lit(p.Name)
+
+	g.List(Id("raw"), Id("ok")).Op("=").Id("lookup").Call(Id("r"), in.Clone(), name.Clone())
+
+	convert := []Code{
+		List(Id("p").Dot(p.FieldName()), Id("err")).Op("=").Id(paramParsers[p.ParamType()]).Call(Id("raw")),
+		If(Id("err").Op("!=").Nil()).Block(
+			Return(Id("p"), Id("invalid").Call(in.Clone(), name.Clone(), Id("err"))),
+		),
+	}
+
+	switch {
+	case p.IsRequired():
+		g.If(Op("!").Id("ok")).Block(
+			Return(Id("p"), Id("missing").Call(in.Clone(), name.Clone())),
+		)
+		addAll(g, convert)
+	case p.Default != "":
+		g.If(Op("!").Id("ok")).Block(
+			Id("raw").Op("=").Lit(p.Default),
+		)
+		addAll(g, convert)
+	default:
+		g.If(Id("ok")).Block(convert...)
+	}
+}
+
+// addAll adds each of the statements to the group on its own line.
+func addAll(g *Group, statements []Code) {
+	for _, s := range statements {
+		g.Add(s)
+	}
+}
+
+// paramType returns the Go type of the parameter.
+func paramType(p metadata.Param) *Statement {
+	switch p.ParamType() {
+	case metadata.TypeInt:
+		return Int()
+	case metadata.TypeBool:
+		return Bool()
+	case metadata.TypeTime:
+		return Qual("time", "Time")
+	default:
+		return String()
+	}
+}
@@ -0,0 +1,139 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// samples holds the sample implementations of the handlers that are part of
+// the default service descriptor, keyed by their names. Every other handler
+// and middleware is generated as a stub.
+var samples = map[string]func(projectName string) *Statement{
+	"Index": indexSample,
+}
+
+func ServiceFile(md metadata.Metadata) ([]byte, error) {
+	projectName := md.Name
+
+	f := NewFilePathName(md.ModulePath(), md.PackageName())
+
+	f.Type().Id("Server").Struct()
+
+	for _, mw := range md.Middlwares {
+		f.Add(serviceMethod(projectName, mw.HandlerName, MiddlewareStub))
+		f.Line()
+	}
+
+	for _, r := range md.Routes {
+		if r.IsTyped() {
+			f.Add(TypedHandlerStub(md, r))
+			f.Line()
+			continue
+		}
+
+		f.Add(serviceMethod(projectName, r.HandlerName, HandlerStub))
+		f.Line()
+	}
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// serviceMethod returns the sample implementation of the method if there is
+// one, or the stub otherwise.
+func serviceMethod(projectName, name string, stub func(string) *Statement) *Statement {
+	if sample, ok := samples[name]; ok {
+		return sample(projectName)
+	}
+
+	return stub(name)
+}
+
+// HandlerStub returns a method on Server called name, which can be used as a
+// route handler. It responds with 501 Not Implemented.
+func HandlerStub(name string) *Statement {
+	return Func().Params(
+		Id("s").Op("*").Id("Server"),
+	).Id(name).Params().Qual("net/http", "HandlerFunc").Block(
+		Comment("// Anything you add here will be executed once, during startup."),
+		Comment("// The returned http.HandlerFunc will be able to access these variables"),
+		Comment("// thanks to closure."),
+		Line(),
+		Id("notImplemented").Op(":=").Index().Byte().Call(Lit(name+" not yet implemented")),
+		Return().Add(
+			httpHandlerFunc().Block(
+				Comment("// TODO: Business logic to be executed at every request should go here"),
+				Line(),
+				Id("w").Dot("WriteHeader").Call(Qual("net/http", "StatusNotImplemented")),
+				Line(),
+				List(
+					Id("_"), Id("err"),
+				).Op(":=").Id("w").Dot("Write").Call(Id("notImplemented")),
+				If(Id("err").Op("!=").Nil()).Block(
+					Panic(Id("err")),
+				),
+			),
+		),
+	)
+}
+
+// MiddlewareStub returns a method on Server called name, which can be used as
+// a middleware. It calls the next handler without doing anything else.
+func MiddlewareStub(name string) *Statement {
+	return Func().Params(
+		Id("s").Op("*").Id("Server"),
+	).Id(name).Params(
+		Id("next").Qual("net/http", "Handler"),
+	).Qual("net/http", "Handler").Block(
+		Comment("// Anything you add here will be executed once, during startup."),
+		Comment("// The returned http.Handler will be able to access these variables"),
+		Comment("// thanks to closure."),
+		Line(),
+		Return(
+			Qual("net/http", "HandlerFunc").Call(
+				Add(httpHandlerFunc()).Block(
+					Comment("// TODO: Business logic before the handler is called should go here"),
+					Line(),
+					Id("next").Dot("ServeHTTP").Call(
+						Id("w"), Id("r"),
+					),
+					Line(),
+					Comment("// TODO: Business logic after the handler has been called should go here"),
+				),
+			),
+		),
+	)
+}
+
+func indexSample(string) *Statement {
+	return Func().Params(
+		Id("s").Op("*").Id("Server"),
+	).Id("Index").Params().Qual("net/http", "HandlerFunc").Block(
+		Comment("// Anything you add here will be executed once, during startup."),
+		Comment("// The returned http.HandlerFunc will be able to access these variables"),
+		Comment("// thanks to closure."),
+		Line(),
+		Id("defaultMsg").Op(":=").Index().Byte().Call(Lit("I'm alive!")),
+		Return().Add(
+			httpHandlerFunc().Block(
+				Id("w").Dot("WriteHeader").Call(Qual("net/http", "StatusOK")),
+				Line(),
+				List(
+					Id("_"), Id("err"),
+				).Op(":=").Id("w").Dot("Write").Call(Id("defaultMsg")),
+				If(Id("err").Op("!=").Nil()).Block(
+					Panic(Id("err")),
+				),
+			),
+		),
+	)
+}
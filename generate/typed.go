@@ -0,0 +1,291 @@
+package generate
+
+import (
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// typedSignature returns the parameters and results of the typed handler of
+// the route: the request's context, its parameters and body if the route
+// declares them, then the response body if the route declares one, and an
+// error. Types of the service package are qualified unless inService is set.
+func typedSignature(md metadata.Metadata, r metadata.Route, inService bool) (params, results []Code) {
+	params = append(params, Id("ctx").Qual("context", "Context"))
+
+	if len(r.Params) > 0 {
+		params = append(params, Id("params").Qual(apiPath(md), r.HandlerName+"Params"))
+	}
+
+	if r.Request != nil {
+		params = append(params, Id("req").Add(bodyType(md, r, r.Request, "Request", inService)))
+	}
+
+	if r.Response != nil {
+		results = append(results, bodyType(md, r, r.Response, "Response", inService))
+	}
+
+	return params, append(results, Error())
+}
+
+// bodyType returns the Go type of the body: the service package type it
+// names, or the struct generated for its fields in the api package.
+func bodyType(md metadata.Metadata, r metadata.Route, b *metadata.Body, suffix string, inService bool) *Statement {
+	if b.Type == "" {
+		return Qual(apiPath(md), r.HandlerName+suffix)
+	}
+
+	elem, list := b.ElemType()
+
+	t := Qual(md.ModulePath(), elem)
+	if inService {
+		t = Id(elem)
+	}
+
+	if list {
+		return Index().Add(t)
+	}
+
+	return t
+}
+
+// TypedHandlerStub returns a method on Server implementing the typed handler
+// of the route. It fails with a 501 Not Implemented.
+func TypedHandlerStub(md metadata.Metadata, r metadata.Route) *Statement {
+	params, results := typedSignature(md, r, true)
+
+	notImplemented := Op("&").Qual(apiPath(md), "HTTPError").Values(Dict{
+		Id("Status"):  Qual("net/http", "StatusNotImplemented"),
+		Id("Message"): Lit(r.HandlerName + " not yet implemented"),
+	})
+
+	return Func().Params(
+		Id("s").Op("*").Id("Server"),
+	).Id(r.HandlerName).Params(params...).Params(results...).BlockFunc(func(g *Group) {
+		g.Comment("// TODO: Business logic to be executed at every request should go here")
+		g.Line()
+
+		if r.Response == nil {
+			g.Return(notImplemented)
+			return
+		}
+
+		g.Var().Id("resp").Add(bodyType(md, r, r.Response, "Response", true))
+		g.Line()
+		g.Return(Id("resp"), notImplemented)
+	})
+}
+
+// hasTypedRoutes reports whether any route of the descriptor is served by a
+// typed handler.
+func hasTypedRoutes(md metadata.Metadata) bool {
+	for _, r := range md.Routes {
+		if r.IsTyped() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// adapterName returns the name of the Service method adapting the typed
+// handler of the route to an http.HandlerFunc.
+func adapterName(r metadata.Route) string {
+	return "handle" + r.HandlerName
+}
+
+// bootstrapTyped adds an adapter for the typed handler of each route that
+// has one, along with the helpers decoding requests and encoding responses.
+func bootstrapTyped(f *File, md metadata.Metadata) {
+	if !hasTypedRoutes(md) {
+		return
+	}
+
+	for _, r := range md.Routes {
+		if r.IsTyped() {
+			typedAdapter(f, md, r)
+		}
+	}
+
+	typedHelpers(f, md)
+}
+
+// typedAdapter adds the Service method adapting the typed handler of the
+// route to an http.HandlerFunc.
+func typedAdapter(f *File, md metadata.Metadata, r metadata.Route) {
+	api := apiPath(md)
+
+	f.Commentf("// %s adapts the typed %s handler to an http.HandlerFunc.", adapterName(r), r.HandlerName)
+	f.Func().Params(
+		Id("s").Op("*").Id("Service"),
+	).Id(adapterName(r)).Params().Qual("net/http", "HandlerFunc").Block(
+		Return(httpHandlerFunc().BlockFunc(func(g *Group) {
+			args := []Code{Id("r").Dot("Context").Call()}
+
+			if len(r.Params) > 0 {
+				args = append(args, Qual(api, "Get"+r.HandlerName+"Params").Call(Id("r")))
+			}
+
+			if r.Request != nil {
+				g.Var().Id("req").Add(bodyType(md, r, r.Request, "Request", false))
+				g.Line()
+				g.If(Op("!").Id("decodeJSON").CallFunc(func(g *Group) {
+					g.Id("w")
+					g.Id("r")
+					g.Op("&").Id("req")
+
+					for _, field := range r.Request.Fields {
+						if field.Required {
+							g.Lit(field.Name)
+						}
+					}
+				})).Block(
+					Return(),
+				)
+				g.Line()
+
+				args = append(args, Id("req"))
+			}
+
+			call := Id("s").Dot("serviceImpl").Dot(r.HandlerName).Call(args...)
+
+			if r.Response != nil {
+				g.List(Id("resp"), Id("err")).Op(":=").Add(call)
+			} else {
+				g.Id("err").Op(":=").Add(call)
+			}
+
+			g.If(Id("err").Op("!=").Nil()).Block(
+				Id("writeHandlerError").Call(Id("w"), Id("err")),
+				Return(),
+			)
+			g.Line()
+
+			if r.Response != nil {
+				g.Id("writeJSON").Call(Id("w"), Id("resp"))
+			} else {
+				g.Id("w").Dot("WriteHeader").Call(Qual("net/http", "StatusNoContent"))
+			}
+		})),
+	)
+}
+
+// typedHelpers adds the functions decoding requests and encoding responses
+// for the typed handlers.
+func typedHelpers(f *File, md metadata.Metadata) {
+	api := apiPath(md)
+
+	badRequest := func(format string, args ...Code) *Statement {
+		return Qual(api, "WriteError").Call(
+			Id("w"),
+			Qual("net/http", "StatusBadRequest"),
+			Qual("fmt", "Errorf").Call(append([]Code{Lit(format)}, args...)...),
+		)
+	}
+
+	f.Comment("// decodeJSON decodes the JSON body of the request into v, checking that it")
+	f.Comment("// holds the required fields, then validates it if v has a Validate method.")
+	f.Comment("// Requests without a JSON body are answered with a 415 Unsupported Media Type,")
+	f.Comment("// and invalid ones with a 400 Bad Request, in which case false is returned.")
+	f.Func().Id("decodeJSON").Params(
+		Id("w").Qual("net/http", "ResponseWriter"),
+		Id("r").Op("*").Qual("net/http", "Request"),
+		Id("v").Interface(),
+		Id("required").Op("...").String(),
+	).Bool().Block(
+		List(Id("mediaType"), Id("_"), Id("_")).Op(":=").Qual("mime", "ParseMediaType").Call(
+			Id("r").Dot("Header").Dot("Get").Call(Lit("Content-Type")),
+		),
+		If(Id("mediaType").Op("!=").Lit("application/json")).Block(
+			Qual(api, "WriteError").Call(
+				Id("w"),
+				Qual("net/http", "StatusUnsupportedMediaType"),
+				Qual("fmt", "Errorf").Call(Lit("expected an application/json body, got %q"), Id("mediaType")),
+			),
+			Return(False()),
+		),
+		Empty(),
+		List(Id("body"), Id("err")).Op(":=").Qual("io/ioutil", "ReadAll").Call(Id("r").Dot("Body")),
+		If(Id("err").Op("!=").Nil()).Block(
+			badRequest("reading body: %v", Id("err")),
+			Return(False()),
+		),
+		Empty(),
+		If(Len(Id("required")).Op(">").Lit(0)).Block(
+			Var().Id("fields").Map(String()).Qual("encoding/json", "RawMessage"),
+			Empty(),
+			Err().Op("=").Qual("encoding/json", "Unmarshal").Call(Id("body"), Op("&").Id("fields")),
+			If(Err().Op("!=").Nil()).Block(
+				badRequest("invalid JSON body: %v", Id("err")),
+				Return(False()),
+			),
+			Empty(),
+			For(List(Id("_"), Id("name")).Op(":=").Range().Id("required")).Block(
+				List(Id("value"), Id("ok")).Op(":=").Id("fields").Index(Id("name")),
+				If(Op("!").Id("ok").Op("||").String().Call(Id("value")).Op("==").Lit("null")).Block(
+					Qual(api, "WriteError").Call(
+						Id("w"),
+						Qual("net/http", "StatusBadRequest"),
+						Op("&").Qual(api, "Error").Values(Dict{
+							Id("Param"):   Id("name"),
+							Id("In"):      Lit("body"),
+							Id("Message"): Lit("is required"),
+						}),
+					),
+					Return(False()),
+				),
+			),
+		),
+		Empty(),
+		Err().Op("=").Qual("encoding/json", "Unmarshal").Call(Id("body"), Id("v")),
+		If(Err().Op("!=").Nil()).Block(
+			badRequest("invalid JSON body: %v", Id("err")),
+			Return(False()),
+		),
+		Empty(),
+		If(
+			List(Id("validator"), Id("ok")).Op(":=").Id("v").Assert(Interface(Id("Validate").Params().Error())),
+			Id("ok"),
+		).Block(
+			Err().Op("=").Id("validator").Dot("Validate").Call(),
+			If(Err().Op("!=").Nil()).Block(
+				Qual(api, "WriteError").Call(Id("w"), Qual("net/http", "StatusBadRequest"), Id("err")),
+				Return(False()),
+			),
+		),
+		Empty(),
+		Return(True()),
+	)
+
+	f.Comment("// writeJSON answers the request with a 200 OK, and v encoded as JSON in the")
+	f.Comment("// body.")
+	f.Func().Id("writeJSON").Params(
+		Id("w").Qual("net/http", "ResponseWriter"),
+		Id("v").Interface(),
+	).Block(
+		Id("w").Dot("Header").Call().Dot("Set").Call(Lit("Content-Type"), Lit("application/json")),
+		Id("w").Dot("WriteHeader").Call(Qual("net/http", "StatusOK")),
+		Qual("encoding/json", "NewEncoder").Call(Id("w")).Dot("Encode").Call(Id("v")),
+	)
+
+	f.Comment("// writeHandlerError answers the request with the status code of an")
+	f.Comment("// *api.HTTPError, or with a 500 Internal Server Error for any other error.")
+	f.Func().Id("writeHandlerError").Params(
+		Id("w").Qual("net/http", "ResponseWriter"),
+		Id("err").Error(),
+	).Block(
+		If(
+			List(Id("e"), Id("ok")).Op(":=").Id("err").Assert(Op("*").Qual(api, "HTTPError")),
+			Id("ok"),
+		).Block(
+			Qual(api, "WriteError").Call(Id("w"), Id("e").Dot("Status"), Id("e")),
+			Return(),
+		),
+		Empty(),
+		Qual(api, "WriteError").Call(
+			Id("w"),
+			Qual("net/http", "StatusInternalServerError"),
+			Qual("errors", "New").Call(Qual("net/http", "StatusText").Call(Qual("net/http", "StatusInternalServerError"))),
+		),
+	)
+}
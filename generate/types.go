@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"seed/consts"
+	"seed/metadata"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// TypesFile generates the types of the api package: the error typed
+// handlers return to pick the status code of the response, and the structs
+// of the bodies declared as fields in the descriptor.
+func TypesFile(md metadata.Metadata) ([]byte, error) {
+	f := NewFilePathName(apiPath(md), consts.ApiFolder)
+
+	f.Comment("// HTTPError is an error returned by a typed handler to answer the request with")
+	f.Comment("// a specific status code. Any other error is answered with a 500 Internal")
+	f.Comment("// Server Error, without exposing the error's message.")
+	f.Type().Id("HTTPError").Struct(
+		Id("Status").Int(),
+		Id("Message").String(),
+	)
+
+	f.Func().Params(Id("e").Op("*").Id("HTTPError")).Id("Error").Params().String().Block(
+		Return(Id("e").Dot("Message")),
+	)
+
+	for _, r := range md.Routes {
+		if r.Request != nil && len(r.Request.Fields) > 0 {
+			bodyStruct(f, r.HandlerName+"Request", "the requests", r.HandlerName, r.Request.Fields)
+			bodyValidate(f, r.HandlerName+"Request", r.Request.Fields)
+		}
+
+		if r.Response != nil && len(r.Response.Fields) > 0 {
+			bodyStruct(f, r.HandlerName+"Response", "the responses", r.HandlerName, r.Response.Fields)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// bodyStruct adds the struct of a body declared as fields.
+func bodyStruct(f *File, name, of, handlerName string, fields []metadata.Field) {
+	f.Commentf("// %s is the body of %s served by %s.", name, of, handlerName)
+	f.Type().Id(name).StructFunc(func(g *Group) {
+		for _, field := range fields {
+			g.Id(field.FieldName()).Add(fieldType(field)).Tag(map[string]string{"json": field.Name})
+		}
+	})
+}
+
+// bodyValidate adds the Validate method of a request body, which checks the
+// format of its UUID fields. Required fields are checked while decoding.
+func bodyValidate(f *File, name string, fields []metadata.Field) {
+	f.Comment("// Validate checks the format of the fields of the body.")
+	f.Func().Params(Id("b").Id(name)).Id("Validate").Params().Error().BlockFunc(func(g *Group) {
+		for _, field := range fields {
+			elem, list := field.ElemType()
+			if elem != metadata.TypeUUID {
+				continue
+			}
+
+			check := func(value *Statement) *Statement {
+				return If(Op("!").Id("uuidPattern").Dot("MatchString").Call(value.Clone())).Block(
+					Return(Op("&").Id("Error").Values(Dict{
+						Id("Param"):   Lit(field.Name),
+						Id("In"):      Lit("body"),
+						Id("Message"): Qual("fmt", "Sprintf").Call(Lit("%q is not a UUID"), value.Clone()),
+					})),
+				)
+			}
+
+			value := Id("b").Dot(field.FieldName())
+
+			if list {
+				g.For(List(Id("_"), Id("v")).Op(":=").Range().Add(value)).Block(check(Id("v")))
+			} else {
+				g.If(value.Clone().Op("!=").Lit("")).Block(check(value))
+			}
+
+			g.Line()
+		}
+
+		g.Return(Nil())
+	})
+}
+
+// fieldType returns the Go type of the field.
+func fieldType(field metadata.Field) *Statement {
+	elem, list := field.ElemType()
+
+	var t *Statement
+
+	switch elem {
+	case metadata.TypeInt:
+		t = Int()
+	case metadata.TypeFloat:
+		t = Float64()
+	case metadata.TypeBool:
+		t = Bool()
+	case metadata.TypeTime:
+		t = Qual("time", "Time")
+	default:
+		t = String()
+	}
+
+	if list {
+		return Index().Add(t)
+	}
+
+	return t
+}
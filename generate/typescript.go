@@ -0,0 +1,444 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"seed/metadata"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TypeScriptClient generates a TypeScript module calling the routes of the
+// service with fetch. It exports a function per route, named after its
+// handler, and an interface for the parameters and the bodies the route
+// declares, named like their Go counterparts in the api package.
+func TypeScriptClient(md metadata.Metadata) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Client of the %s service, generated by seed from its descriptor.\n", md.Name)
+	buf.WriteString(tsClient)
+
+	declared := make(map[string]bool)
+
+	for _, r := range md.Routes {
+		if len(r.Params) > 0 {
+			tsParams(&buf, r)
+		}
+
+		tsBody(&buf, r, r.Request, "Request", "the requests", declared)
+		tsBody(&buf, r, r.Response, "Response", "the responses", declared)
+		for _, call := range r.ClientCalls() {
+			tsFunction(&buf, r, call)
+		}
+	}
+
+	buf.WriteString(tsHelpers)
+
+	return buf.Bytes(), nil
+}
+
+// tsClient declares the client passed to every function, and the error they
+// throw.
+const tsClient = `
+/** Client holds what the functions of this module need to call the service. */
+export interface Client {
+  /** URL the service is served at, e.g. "http://localhost:8080". */
+  baseURL: string;
+
+  /** Sends the requests, defaults to the global fetch. */
+  fetch?: typeof fetch;
+}
+
+/**
+ * ServiceError is thrown for the responses of the service with a status code
+ * outside of the 2xx range.
+ */
+export class ServiceError extends Error {
+  /** Name of the parameter or field at fault, if any. */
+  param?: string;
+
+  /** Where the parameter is found: "path", "query", "header" or "body". */
+  in?: string;
+
+  constructor(readonly status: number, statusText: string, readonly body: string) {
+    super(` + "`${status} ${statusText}`" + `);
+    Object.setPrototypeOf(this, ServiceError.prototype);
+    this.name = "ServiceError";
+
+    const e = parseError(body);
+    if (e && e.message) {
+      this.param = e.param || undefined;
+      this.in = e.in || undefined;
+      this.message += this.param ? ` + "`: ${this.in} ${this.param} ${e.message}`" + ` : ` + "`: ${e.message}`" + `;
+    }
+  }
+}
+`
+
+// tsHelpers holds the functions sending the requests.
+const tsHelpers = `
+/** Call holds what a request to the service is made of, besides its path. */
+interface Call {
+  query?: URLSearchParams;
+  headers?: Record<string, string>;
+  body?: unknown;
+}
+
+/**
+ * send sends a request to the path of the service, with its body encoded as
+ * JSON, and throws a ServiceError if the status code of the response is
+ * outside of the 2xx range.
+ */
+async function send(client: Client, method: string, path: string, call: Call = {}): Promise<Response> {
+  let url = client.baseURL.replace(/\/+$/, "") + path;
+
+  const search = call.query ? call.query.toString() : "";
+  if (search !== "") {
+    url += "?" + search;
+  }
+
+  const headers: Record<string, string> = { ...call.headers };
+
+  let body: string | undefined;
+  if (call.body !== undefined) {
+    headers["Content-Type"] = "application/json";
+    body = JSON.stringify(call.body);
+  }
+
+  const res = await (client.fetch || fetch)(url, { method, headers, body });
+  if (!res.ok) {
+    throw new ServiceError(res.status, res.statusText, await res.text());
+  }
+
+  return res;
+}
+
+/** parseError returns the error described by the body of a response, if any. */
+function parseError(body: string): { param?: string; in?: string; message?: string } | undefined {
+  try {
+    return JSON.parse(body).error;
+  } catch (err) {
+    return undefined;
+  }
+}
+`
+
+// tsParams adds the interface of the parameters of the route. Its properties
+// are named like the arguments of the Go client, e.g. "xRequestID", and
+// optional parameters are left out of the request when undefined.
+func tsParams(buf *bytes.Buffer, r metadata.Route) {
+	fmt.Fprintf(buf, "\n/** %sParams holds the parameters of the requests served by %s. */\n", r.HandlerName, r.HandlerName)
+	fmt.Fprintf(buf, "export interface %sParams {\n", r.HandlerName)
+
+	for _, p := range r.Params {
+		optional := ""
+		if !p.IsRequired() {
+			optional = "?"
+		}
+
+		fmt.Fprintf(buf, "  %s%s: %s;\n", lowerCamel(p.Name), optional, tsType(p.ParamType()))
+	}
+
+	buf.WriteString("}\n")
+}
+
+// tsBody adds the interface of a body declared as fields, or of the service
+// package type it names. The fields of such a type are not described by the
+// descriptor, so it is declared once as an object of unknown values.
+func tsBody(buf *bytes.Buffer, r metadata.Route, b *metadata.Body, suffix, of string, declared map[string]bool) {
+	if b == nil {
+		return
+	}
+
+	if b.Type != "" {
+		elem, _ := b.ElemType()
+		if declared[elem] {
+			return
+		}
+
+		declared[elem] = true
+
+		fmt.Fprintf(buf, "\n/** %s is declared by the service, which does not describe its fields. */\n", elem)
+		fmt.Fprintf(buf, "export type %s = Record<string, unknown>;\n", elem)
+
+		return
+	}
+
+	fmt.Fprintf(buf, "\n/** %s%s is the body of %s served by %s. */\n", r.HandlerName, suffix, of, r.HandlerName)
+	fmt.Fprintf(buf, "export interface %s%s {\n", r.HandlerName, suffix)
+
+	for _, f := range b.Fields {
+		// The service always writes every field of a response.
+		optional := ""
+		if suffix == "Request" && !f.Required {
+			optional = "?"
+		}
+
+		elem, list := f.ElemType()
+
+		t := tsType(elem)
+		if list {
+			t += "[]"
+		}
+
+		fmt.Fprintf(buf, "  %s%s: %s;\n", tsProperty(f.Name), optional, t)
+	}
+
+	buf.WriteString("}\n")
+}
+
+// tsFunction adds the function making the call of the route. Typed routes
+// resolve to the decoded response body, if any, and other routes to the
+// response itself.
+func tsFunction(buf *bytes.Buffer, r metadata.Route, call metadata.ClientCall) {
+	method := strings.ToUpper(call.HttpMethod)
+	name := tsFuncName(call.Name)
+
+	args := []string{"client: Client"}
+
+	for _, v := range undeclaredVars(r) {
+		args = append(args, tsVarArg(v)+": string")
+	}
+
+	if len(r.Params) > 0 {
+		arg := "params: " + r.HandlerName + "Params"
+		if !hasRequiredParams(r) {
+			arg += " = {}"
+		}
+
+		args = append(args, arg)
+	}
+
+	if r.Request != nil {
+		args = append(args, "req: "+tsBodyType(r, r.Request, "Request"))
+	}
+
+	result := "Response"
+	switch {
+	case r.Response != nil:
+		result = tsBodyType(r, r.Response, "Response")
+	case r.IsTyped():
+		result = "void"
+	}
+
+	fmt.Fprintf(buf, "\n/** %s calls the %s %s route of the service. */\n", name, method, r.Path)
+	fmt.Fprintf(buf, "export async function %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), result)
+
+	var options []string
+
+	if query := paramsIn(r, metadata.InQuery); len(query) > 0 {
+		buf.WriteString("  const query = new URLSearchParams();\n")
+		for _, p := range query {
+			tsSetParam(buf, p, func(value string) string {
+				return fmt.Sprintf("query.set(%q, %s)", p.Name, value)
+			})
+		}
+		buf.WriteString("\n")
+
+		options = append(options, "query")
+	}
+
+	if header := paramsIn(r, metadata.InHeader); len(header) > 0 {
+		buf.WriteString("  const headers: Record<string, string> = {};\n")
+		for _, p := range header {
+			tsSetParam(buf, p, func(value string) string {
+				return fmt.Sprintf("headers[%q] = %s", p.Name, value)
+			})
+		}
+		buf.WriteString("\n")
+
+		options = append(options, "headers")
+	}
+
+	if r.Request != nil {
+		options = append(options, "body: req")
+	}
+
+	sendArgs := []string{"client", strconv.Quote(method), tsPathExpr(r)}
+	if len(options) > 0 {
+		sendArgs = append(sendArgs, "{ "+strings.Join(options, ", ")+" }")
+	}
+
+	sendCall := "send(" + strings.Join(sendArgs, ", ") + ")"
+
+	switch {
+	case r.Response != nil:
+		fmt.Fprintf(buf, "  const res = await %s;\n", sendCall)
+		fmt.Fprintf(buf, "  return (await res.json()) as %s;\n", result)
+	case r.IsTyped():
+		fmt.Fprintf(buf, "  await %s;\n", sendCall)
+	default:
+		fmt.Fprintf(buf, "  return %s;\n", sendCall)
+	}
+
+	buf.WriteString("}\n")
+}
+
+// tsSetParam adds the statement returned by set for the value of the
+// parameter, which only applies to optional parameters that are defined.
+func tsSetParam(buf *bytes.Buffer, p metadata.Param, set func(value string) string) {
+	value := "params." + lowerCamel(p.Name)
+	assign := set(tsString(p.ParamType(), value))
+
+	if p.IsRequired() {
+		fmt.Fprintf(buf, "  %s;\n", assign)
+		return
+	}
+
+	fmt.Fprintf(buf, "  if (%s !== undefined) {\n", value)
+	fmt.Fprintf(buf, "    %s;\n", assign)
+	buf.WriteString("  }\n")
+}
+
+// tsPathExpr returns the expression building the path of a request to the
+// route, with its path variables escaped.
+func tsPathExpr(r metadata.Route) string {
+	segments := pathSegments(r)
+	if len(segments) == 1 && !segments[0].isVar {
+		return strconv.Quote(segments[0].lit)
+	}
+
+	var b strings.Builder
+
+	b.WriteString("`")
+
+	for _, seg := range segments {
+		switch {
+		case !seg.isVar:
+			b.WriteString(templateEscaper.Replace(seg.lit))
+		case seg.param != nil:
+			value := tsString(seg.param.ParamType(), "params."+lowerCamel(seg.param.Name))
+			fmt.Fprintf(&b, "${encodeURIComponent(%s)}", value)
+		default:
+			fmt.Fprintf(&b, "${encodeURIComponent(%s)}", tsVarArg(seg.v))
+		}
+	}
+
+	b.WriteString("`")
+
+	return b.String()
+}
+
+// templateEscaper escapes the literal parts of a template string.
+var templateEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+
+// tsType returns the TypeScript type of the descriptor type. Times are
+// passed as RFC 3339 strings.
+func tsType(t string) string {
+	switch t {
+	case metadata.TypeInt, metadata.TypeFloat:
+		return "number"
+	case metadata.TypeBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// tsString returns the expression turning the value of the descriptor type
+// into a string.
+func tsString(t, value string) string {
+	if tsType(t) == "string" {
+		return value
+	}
+
+	return "String(" + value + ")"
+}
+
+// tsBodyType returns the TypeScript type of the body.
+func tsBodyType(r metadata.Route, b *metadata.Body, suffix string) string {
+	if b.Type == "" {
+		return r.HandlerName + suffix
+	}
+
+	elem, list := b.ElemType()
+	if list {
+		return elem + "[]"
+	}
+
+	return elem
+}
+
+// hasRequiredParams reports whether the route declares a required parameter.
+func hasRequiredParams(r metadata.Route) bool {
+	for _, p := range r.Params {
+		if p.IsRequired() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tsFuncName returns the name of the function making a call of a route, e.g.
+// "createUser" for "CreateUser".
+func tsFuncName(callName string) string {
+	name := lowerCamel(callName)
+	if tsReserved[name] || tsLocals[name] {
+		name += "Route"
+	}
+
+	return name
+}
+
+// tsVarArg returns the name of the argument holding the path variable, e.g.
+// "userID" for "user_id".
+func tsVarArg(v metadata.PathVar) string {
+	arg := lowerCamel(v.Name)
+
+	switch {
+	case arg == "" || !unicode.IsLetter([]rune(arg)[0]):
+		arg = "var" + arg
+	case tsReserved[arg] || tsLocals[arg]:
+		arg += "Var"
+	}
+
+	return arg
+}
+
+// tsIdentifier matches the names which can be used as properties without
+// quoting them.
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsProperty returns the name of the property, quoted if it is not an
+// identifier.
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+
+	return strconv.Quote(name)
+}
+
+// tsLocals are the names declared by the module or used by its functions,
+// which the functions calling the routes and their arguments should not
+// shadow.
+var tsLocals = map[string]bool{
+	"client":             true,
+	"params":             true,
+	"req":                true,
+	"res":                true,
+	"query":              true,
+	"headers":            true,
+	"send":               true,
+	"parseError":         true,
+	"fetch":              true,
+	"encodeURIComponent": true,
+	"String":             true,
+}
+
+// tsReserved holds the reserved words of TypeScript.
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "implements": true, "interface": true,
+	"let": true, "package": true, "private": true, "protected": true,
+	"public": true, "static": true, "yield": true, "await": true,
+}
@@ -0,0 +1,206 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"seed/metadata"
+	"strconv"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// UpdateServiceFile parses the contents of a service file, and appends a stub
+// for every handler and middleware of the descriptor that is not yet
+// declared as a method on Server. Everything already in the file, including
+// comments, is preserved as is. The imports used by the stubs are added if
+// needed, and the stubs are placed before the deprecated block if the file
+// has one.
+func UpdateServiceFile(src []byte, md metadata.Metadata) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing service file: %v", err)
+	}
+
+	if !declaresServer(file) {
+		return nil, fmt.Errorf("service file does not declare the Server type")
+	}
+
+	methods := ServerMethods(file)
+
+	var (
+		stubs   []*Statement
+		imports = []string{"net/http"}
+	)
+
+	for _, mw := range md.Middlwares {
+		if !methods[mw.HandlerName] {
+			stubs = append(stubs, MiddlewareStub(mw.HandlerName))
+		}
+	}
+
+	for _, r := range md.Routes {
+		if methods[r.HandlerName] {
+			continue
+		}
+
+		if r.IsTyped() {
+			stubs = append(stubs, TypedHandlerStub(md, r))
+			imports = append(imports, "context", apiPath(md))
+			continue
+		}
+
+		stubs = append(stubs, HandlerStub(r.HandlerName))
+	}
+
+	if len(stubs) == 0 {
+		return src, nil
+	}
+
+	updated := addImports(fset, file, src, imports...)
+
+	// Stubs go before the deprecated block, so that it stays at the end.
+	var deprecated []byte
+	if i := bytes.Index(updated, []byte("\n"+deprecatedMarker+"\n")); i >= 0 {
+		deprecated = append([]byte{}, updated[i+1:]...)
+		updated = updated[:i+1]
+	}
+
+	updated = bytes.TrimRight(updated, "\n")
+
+	for _, stub := range stubs {
+		updated = append(updated, "\n\n"...)
+		updated = append(updated, fmt.Sprintf("%#v", stub)...)
+	}
+
+	updated = append(updated, '\n')
+
+	if deprecated != nil {
+		updated = append(updated, '\n')
+		updated = append(updated, deprecated...)
+	}
+
+	formatted, err := format.Source(updated)
+	if err != nil {
+		return nil, fmt.Errorf("formatting service file: %v", err)
+	}
+
+	return formatted, nil
+}
+
+// ServerMethods returns the names of the methods declared on Server, either
+// with a value or a pointer receiver.
+func ServerMethods(file *ast.File) map[string]bool {
+	methods := make(map[string]bool)
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !isServerMethod(fn) {
+			continue
+		}
+
+		methods[fn.Name.Name] = true
+	}
+
+	return methods
+}
+
+func isServerMethod(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return false
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+
+	ident, ok := recv.(*ast.Ident)
+
+	return ok && ident.Name == "Server"
+}
+
+func declaresServer(file *ast.File) bool {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			if spec.(*ast.TypeSpec).Name.Name == "Server" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// addImports returns src with the import paths added, skipping those that
+// are already imported. They are added to the first parenthesized import
+// declaration if there is one, or as a separate declaration after the package
+// clause otherwise.
+func addImports(fset *token.FileSet, file *ast.File, src []byte, paths ...string) []byte {
+	imported := make(map[string]bool)
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		imported[p] = true
+	}
+
+	var missing []string
+
+	for _, path := range paths {
+		if !imported[path] {
+			missing = append(missing, path)
+			imported[path] = true
+		}
+	}
+
+	if len(missing) == 0 {
+		return src
+	}
+
+	var (
+		offset int
+		text   string
+	)
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() {
+			continue
+		}
+
+		offset = fset.Position(gen.Lparen).Offset + 1
+		for _, path := range missing {
+			text += fmt.Sprintf("\n\t%q", path)
+		}
+
+		break
+	}
+
+	if text == "" {
+		offset = fset.Position(file.Name.End()).Offset
+		text = fmt.Sprintf("\n\nimport %q", missing[0])
+		if len(missing) > 1 {
+			text = "\n\nimport ("
+			for _, path := range missing {
+				text += fmt.Sprintf("\n\t%q", path)
+			}
+			text += "\n)"
+		}
+	}
+
+	updated := make([]byte, 0, len(src)+len(text))
+	updated = append(updated, src[:offset]...)
+	updated = append(updated, text...)
+	updated = append(updated, src[offset:]...)
+
+	return updated
+}
@@ -0,0 +1,196 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"seed/metadata"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerStub(t *testing.T) {
+	expected := parseStubExpected(t, "handler.expected", "CreateUser")
+
+	assert.Equal(t, expected, fmt.Sprintf("%#v", HandlerStub("CreateUser"))+"\n")
+}
+
+func TestMiddlewareStub(t *testing.T) {
+	expected := parseStubExpected(t, "middleware.expected", "AuthMw")
+
+	assert.Equal(t, expected, fmt.Sprintf("%#v", MiddlewareStub("AuthMw"))+"\n")
+}
+
+func TestUpdateServiceFile(t *testing.T) {
+	src := `package test
+
+import (
+	"log"
+)
+
+// Server holds the dependencies of the service.
+type Server struct {
+	db string // connection string
+}
+
+// LoggerMw is hand written.
+func (s *Server) LoggerMw(next http.Handler) http.Handler {
+	log.Println("custom")   // odd spacing is kept as gofmt keeps it
+	return next
+}
+
+func (s Server) Index() http.HandlerFunc {
+	return nil
+}
+`
+
+	md := testMetadata()
+
+	actual, err := UpdateServiceFile([]byte(src), md)
+	if err != nil {
+		t.Fatalf("UpdateServiceFile() failed: %v", err)
+	}
+
+	expected := `package test
+
+import (
+	"log"
+	"net/http"
+)
+
+// Server holds the dependencies of the service.
+type Server struct {
+	db string // connection string
+}
+
+// LoggerMw is hand written.
+func (s *Server) LoggerMw(next http.Handler) http.Handler {
+	log.Println("custom") // odd spacing is kept as gofmt keeps it
+	return next
+}
+
+func (s Server) Index() http.HandlerFunc {
+	return nil
+}
+
+` + parseStubExpected(t, "middleware.expected", "AuthMw") +
+		"\n" + parseStubExpected(t, "handler.expected", "CreateUser")
+
+	assert.Equal(t, expected, string(actual))
+
+	again, err := UpdateServiceFile(actual, md)
+	if err != nil {
+		t.Fatalf("UpdateServiceFile() failed on updated file: %v", err)
+	}
+
+	assert.Equal(t, string(actual), string(again), "updating twice should not change anything")
+}
+
+func TestUpdateServiceFile_noImports(t *testing.T) {
+	src := "package test\n\ntype Server struct{}\n"
+
+	actual, err := UpdateServiceFile([]byte(src), metadata.Metadata{
+		Routes: []metadata.Route{{HandlerName: "Index"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateServiceFile() failed: %v", err)
+	}
+
+	expected := "package test\n\nimport \"net/http\"\n\ntype Server struct{}\n\n" +
+		parseStubExpected(t, "handler.expected", "Index")
+
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestUpdateServiceFile_typed(t *testing.T) {
+	src := "package test\n\ntype Server struct{}\n"
+
+	md := metadata.Metadata{
+		Info: metadata.Info{Name: "test"},
+		Routes: []metadata.Route{
+			{
+				HandlerName: "CreateUser",
+				Params:      []metadata.Param{{Name: "team", In: metadata.InPath}},
+				Request:     &metadata.Body{Fields: []metadata.Field{{Name: "email"}}},
+				Response:    &metadata.Body{Type: "User"},
+			},
+		},
+	}
+
+	actual, err := UpdateServiceFile([]byte(src), md)
+	if err != nil {
+		t.Fatalf("UpdateServiceFile() failed: %v", err)
+	}
+
+	expected := `package test
+
+import (
+	"context"
+	"net/http"
+	"test/gen/api"
+)
+
+type Server struct{}
+
+func (s *Server) CreateUser(ctx context.Context, params api.CreateUserParams, req api.CreateUserRequest) (User, error) {
+	// TODO: Business logic to be executed at every request should go here
+
+	var resp User
+
+	return resp, &api.HTTPError{
+		Message: "CreateUser not yet implemented",
+		Status:  http.StatusNotImplemented,
+	}
+}
+`
+
+	assert.Equal(t, expected, string(actual))
+
+	report, err := CheckServiceFile(actual, metadata.Metadata{})
+	if err != nil {
+		t.Fatalf("CheckServiceFile() failed: %v", err)
+	}
+
+	assert.Equal(t, []string{"CreateUser"}, report.Orphans, "typed handlers should be recognized")
+}
+
+func TestUpdateServiceFile_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "invalid source",
+			src:  "package test\n\nfunc {",
+		},
+		{
+			name: "missing Server",
+			src:  "package test\n\ntype Service struct{}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UpdateServiceFile([]byte(tt.src), testMetadata())
+			assert.Error(t, err)
+		})
+	}
+}
+
+// parseStubExpected executes the expected stub template with the name.
+func parseStubExpected(t *testing.T, filename, name string) string {
+	tmpl, err := template.ParseFiles(filepath.Join("..", "testdata", filename))
+	if err != nil {
+		t.Fatalf("failed parsing template %q: %v", filename, err)
+	}
+
+	var buf bytes.Buffer
+
+	err = tmpl.Execute(&buf, struct{ Name string }{Name: name})
+	if err != nil {
+		t.Fatalf("failed executing template %q: %v", filename, err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,228 @@
+package seed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"seed/files"
+	"seed/generate"
+	"seed/metadata"
+	"seed/openapi"
+)
+
+// Generator creates and updates projects in the folder Root of the
+// filesystem FS. Each project lives in a subfolder of Root named after it.
+type Generator struct {
+	// Root is the folder projects are generated into.
+	Root string
+
+	// FS is the filesystem projects are read from and written to.
+	FS files.FS
+}
+
+// NewGenerator returns a Generator writing projects into root on the
+// operating system's filesystem.
+func NewGenerator(root string) *Generator {
+	return &Generator{Root: root, FS: files.OS{}}
+}
+
+// defaultGenerator returns the Generator used by the package-level functions,
+// which writes projects into the current directory.
+func defaultGenerator() (*Generator, error) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting current directory: %v", err)
+	}
+
+	return NewGenerator(pwd), nil
+}
+
+// InitProject creates a new project in a folder called projectName. Existing
+// files are never overwritten, see InitProjectWith.
+func InitProject(projectName string) error {
+	return InitProjectWith(projectName, InitOptions{})
+}
+
+// InitProjectWith creates a new project in the current directory, see
+// Generator.InitProject.
+func InitProjectWith(projectName string, opts InitOptions) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return fmt.Errorf(initFailed, err)
+	}
+
+	return g.InitProject(projectName, opts)
+}
+
+// Regenerate re-renders the gen folder of a project in the current directory,
+// see Generator.Regenerate.
+func Regenerate(projectName string) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	return g.Regenerate(projectName)
+}
+
+// AddRoute adds the route to a project in the current directory, see
+// Generator.AddRoute.
+func AddRoute(projectName string, route metadata.Route) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return err
+	}
+
+	return g.AddRoute(projectName, route)
+}
+
+// RemoveRoute removes the route from a project in the current directory, see
+// Generator.RemoveRoute.
+func RemoveRoute(projectName, handlerName string) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return err
+	}
+
+	return g.RemoveRoute(projectName, handlerName)
+}
+
+// AddMiddleware adds the middleware to a project in the current directory,
+// see Generator.AddMiddleware.
+func AddMiddleware(projectName string, mw metadata.Middleware) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return err
+	}
+
+	return g.AddMiddleware(projectName, mw)
+}
+
+// RemoveMiddleware removes the middleware from a project in the current
+// directory, see Generator.RemoveMiddleware.
+func RemoveMiddleware(projectName, handlerName string) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return err
+	}
+
+	return g.RemoveMiddleware(projectName, handlerName)
+}
+
+// Validate validates the service descriptor of a project in the current
+// directory, see Generator.Validate.
+func Validate(projectName string) error {
+	g, err := defaultGenerator()
+	if err != nil {
+		return err
+	}
+
+	return g.Validate(projectName)
+}
+
+// Check checks the service file of a project in the current directory, see
+// Generator.Check.
+func Check(projectName string, deprecate bool) (generate.Report, error) {
+	g, err := defaultGenerator()
+	if err != nil {
+		return generate.Report{}, err
+	}
+
+	return g.Check(projectName, deprecate)
+}
+
+// OpenAPI returns the OpenAPI document of a project in the current directory,
+// see Generator.OpenAPI.
+func OpenAPI(projectName string) (*openapi.Document, error) {
+	g, err := defaultGenerator()
+	if err != nil {
+		return nil, err
+	}
+
+	return g.OpenAPI(projectName)
+}
+
+// TypeScriptClient returns the TypeScript client of a project in the current
+// directory, see Generator.TypeScriptClient.
+func TypeScriptClient(projectName string) ([]byte, error) {
+	g, err := defaultGenerator()
+	if err != nil {
+		return nil, err
+	}
+
+	return g.TypeScriptClient(projectName)
+}
+
+// CurrentDir is the project name which initializes the project in the root
+// folder itself, instead of a subfolder. The name of the project is derived
+// from the root folder's name, see metadata.SanitizePackageName.
+const CurrentDir = "."
+
+// project is a project of the generator, resolved from the name given by the
+// user.
+type project struct {
+	// name is the name of the project, which is also its package name.
+	name string
+
+	// dir is the folder of the project.
+	dir string
+
+	// inPlace is set if the project lives in the root folder itself.
+	inPlace bool
+}
+
+// descriptorPath returns the path of the project's service descriptor.
+func (p project) descriptorPath() string {
+	return filepath.Join(p.dir, p.name+".yml")
+}
+
+// serviceFilePath returns the path of the project's service file.
+func (p project) serviceFilePath() string {
+	return filepath.Join(p.dir, p.name+".go")
+}
+
+// rel returns the path of a file of the project as shown to the user.
+func (p project) rel(path string) string {
+	if p.inPlace {
+		return path
+	}
+
+	return filepath.Join(p.name, path)
+}
+
+// project resolves the project called projectName. For CurrentDir, the
+// project is the root folder itself.
+func (g *Generator) project(projectName string) (project, error) {
+	if projectName != CurrentDir {
+		return project{name: projectName, dir: filepath.Join(g.Root, projectName)}, nil
+	}
+
+	name, err := metadata.SanitizePackageName(filepath.Base(g.Root))
+	if err != nil {
+		return project{}, err
+	}
+
+	return project{name: name, dir: g.Root, inPlace: true}, nil
+}
+
+// loadDescriptor reads the service descriptor of the project, validating it
+// if valid is set.
+func (g *Generator) loadDescriptor(p project, valid bool) (metadata.Metadata, error) {
+	path := p.descriptorPath()
+
+	src, err := g.FS.ReadFile(path)
+	if err != nil {
+		return metadata.Metadata{}, fmt.Errorf("failed reading descriptor: %v", err)
+	}
+
+	if valid {
+		return metadata.ParseValid(path, src)
+	}
+
+	md, err := metadata.Parse(src)
+	if err != nil {
+		return md, fmt.Errorf("failed parsing descriptor %s: %v", path, err)
+	}
+
+	return md, nil
+}
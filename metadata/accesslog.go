@@ -0,0 +1,37 @@
+package metadata
+
+import "strings"
+
+// Formats of the entries of the access log, see AccessLog.
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// RequestIDHeader is the header holding the id of a request in the access
+// log. Requests without one are given a random id.
+const RequestIDHeader = "X-Request-Id"
+
+// LogFormat returns the format of the entries of the access log.
+func (a AccessLog) LogFormat() string {
+	if a.Format == "" {
+		return FormatJSON
+	}
+
+	return a.Format
+}
+
+// validateAccessLog checks the access log of the descriptor, and calls add
+// for each problem found.
+func validateAccessLog(a *AccessLog, add func(field, format string, args ...interface{})) {
+	if a == nil {
+		return
+	}
+
+	switch a.LogFormat() {
+	case FormatJSON, FormatLogfmt:
+	default:
+		add("accesslog.format", "%q is not one of %s", a.Format,
+			strings.Join(quoteAll(FormatJSON, FormatLogfmt), ", "))
+	}
+}
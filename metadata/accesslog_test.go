@@ -0,0 +1,12 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog_LogFormat(t *testing.T) {
+	assert.Equal(t, FormatJSON, AccessLog{}.LogFormat())
+	assert.Equal(t, FormatLogfmt, AccessLog{Format: FormatLogfmt}.LogFormat())
+}
@@ -0,0 +1,121 @@
+package metadata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IsTyped reports whether the route is served by a typed handler, which is
+// the case as soon as it declares a request or a response body.
+func (r Route) IsTyped() bool {
+	return r.Request != nil || r.Response != nil
+}
+
+// FieldName returns the name of the Go struct field, e.g. "CreatedAt" for
+// "created_at".
+func (f Field) FieldName() string {
+	return GoName(f.Name)
+}
+
+// ElemType returns the type of the field, or of its elements if it is a
+// list, which defaults to "string".
+func (f Field) ElemType() (elem string, list bool) {
+	elem = strings.TrimPrefix(f.Type, "[]")
+	list = elem != f.Type
+
+	if elem == "" {
+		elem = TypeString
+	}
+
+	return elem, list
+}
+
+// ElemType returns the name of the service package type of the body, or of
+// its elements if it is a list.
+func (b Body) ElemType() (elem string, list bool) {
+	elem = strings.TrimPrefix(b.Type, "[]")
+
+	return elem, elem != b.Type
+}
+
+// fieldTypes are the types a field of a body can have.
+var fieldTypes = map[string]bool{
+	TypeString: true,
+	TypeInt:    true,
+	TypeFloat:  true,
+	TypeBool:   true,
+	TypeTime:   true,
+	TypeUUID:   true,
+}
+
+// validateBody checks the body of the route, and calls add for each problem
+// found.
+func validateBody(field string, b *Body, add func(field, format string, args ...interface{})) {
+	if b == nil {
+		return
+	}
+
+	switch {
+	case b.Type != "" && len(b.Fields) > 0:
+		add(field+".type", "a body cannot have both a type and fields")
+		return
+	case b.Type == "" && len(b.Fields) == 0:
+		add(field, "a body requires either a type or fields")
+		return
+	case b.Type != "":
+		elem, _ := b.ElemType()
+		if !isExportedIdentifier(elem) {
+			add(field+".type", "%q is not an exported Go identifier", elem)
+		}
+
+		return
+	}
+
+	names := make(map[string]string)
+	fields := make(map[string]string)
+
+	for i, f := range b.Fields {
+		fieldField := fmt.Sprintf("%s.fields[%d]", field, i)
+
+		if f.Name == "" {
+			add(fieldField+".name", "a name is required")
+			continue
+		}
+
+		if elem, _ := f.ElemType(); !fieldTypes[elem] {
+			add(fieldField+".type", "%q is not one of %s, optionally prefixed with \"[]\"",
+				f.Type, strings.Join(quoteAll(TypeString, TypeInt, TypeFloat, TypeBool, TypeTime, TypeUUID), ", "))
+		}
+
+		if other, ok := names[f.Name]; ok {
+			add(fieldField+".name", "field %q is already declared by %s", f.Name, other)
+			continue
+		}
+
+		names[f.Name] = fieldField
+
+		name := f.FieldName()
+		if !isExportedIdentifier(name) {
+			add(fieldField+".name", "%q does not make a valid Go field name", f.Name)
+			continue
+		}
+
+		if other, ok := fields[name]; ok {
+			add(fieldField+".name", "%q makes the same Go field name %s as %s", f.Name, name, other)
+			continue
+		}
+
+		fields[name] = fieldField
+	}
+}
+
+func quoteAll(values ...string) []string {
+	quoted := make([]string, len(values))
+
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+
+	return quoted
+}
@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClientCall is a function of the generated clients calling a route on one
+// of its HTTP methods.
+type ClientCall struct {
+	// Name is the name of the function, e.g. "DeleteUserPost".
+	Name string
+
+	// HttpMethod is the HTTP method the route is called on, e.g. "POST".
+	HttpMethod string
+}
+
+// ClientCalls returns a call of the route for each of its HTTP methods. The
+// first method is called by a function named after the handler, and the
+// other ones by functions suffixed with the method, e.g. DeleteUserPost.
+func (r Route) ClientCalls() []ClientCall {
+	calls := make([]ClientCall, len(r.HttpMethods))
+
+	for i, method := range r.HttpMethods {
+		calls[i] = ClientCall{Name: r.HandlerName, HttpMethod: method}
+
+		if i > 0 {
+			calls[i].Name += strings.Title(strings.ToLower(method))
+		}
+	}
+
+	return calls
+}
+
+// validateClientCalls checks that the calls of the routes on their other HTTP
+// methods than the first do not clash with the ones of other routes, and
+// calls add for each problem found.
+func validateClientCalls(routes []Route, add func(field, format string, args ...interface{})) {
+	calls := make(map[string]string)
+
+	for i, r := range routes {
+		calls[r.HandlerName] = fmt.Sprintf("routes[%d]", i)
+	}
+
+	for i, r := range routes {
+		for j, call := range r.ClientCalls() {
+			if j == 0 {
+				continue
+			}
+
+			field := fmt.Sprintf("routes[%d].httpmethods[%d]", i, j)
+
+			if other, ok := calls[call.Name]; ok {
+				add(field, "client call %s of the method is already used by %s", call.Name, other)
+				continue
+			}
+
+			calls[call.Name] = fmt.Sprintf("routes[%d]", i)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package metadata
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute_ClientCalls(t *testing.T) {
+	r := Route{HandlerName: "DeleteUser", HttpMethods: []string{http.MethodDelete, http.MethodPost}}
+
+	expected := []ClientCall{
+		{Name: "DeleteUser", HttpMethod: http.MethodDelete},
+		{Name: "DeleteUserPost", HttpMethod: http.MethodPost},
+	}
+
+	assert.Equal(t, expected, r.ClientCalls())
+}
@@ -0,0 +1,165 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TypeDuration is only available to settings. Durations are formatted like
+// "1m30s", see time.ParseDuration.
+const TypeDuration = "duration"
+
+// ConfigSetting is the name of the flag giving the YAML file the settings are
+// read from, which cannot be used by a setting.
+const ConfigSetting = "config"
+
+// settingTypes holds the parsers checking that a value can be converted to
+// each of the setting types.
+var settingTypes = map[string]func(string) error{
+	TypeString: paramTypes[TypeString],
+	TypeInt:    paramTypes[TypeInt],
+	TypeBool:   paramTypes[TypeBool],
+	TypeFloat: func(v string) error {
+		_, err := strconv.ParseFloat(v, 64)
+		return err
+	},
+	TypeDuration: func(v string) error {
+		_, err := time.ParseDuration(v)
+		return err
+	},
+}
+
+// ServerSettings are the settings of the server every service is served by,
+// which come before the ones of the descriptor's Config.
+var ServerSettings = []Setting{
+	{Name: "addr", Default: ":8080", Description: "Address the service listens on."},
+	{Name: "read_timeout", Type: TypeDuration, Default: "5s",
+		Description: "Maximum duration for reading a whole request, including its body."},
+	{Name: "write_timeout", Type: TypeDuration, Default: "10s",
+		Description: "Maximum duration for writing a response."},
+	{Name: "idle_timeout", Type: TypeDuration, Default: "2m",
+		Description: "Maximum duration to wait for the next request on a keep-alive connection."},
+	{Name: "shutdown_timeout", Type: TypeDuration, Default: "30s",
+		Description: "Maximum duration for in-flight requests to complete on shutdown."},
+}
+
+// Settings returns the server settings followed by the settings declared by
+// the descriptor.
+func (m Metadata) Settings() []Setting {
+	return append(append([]Setting(nil), ServerSettings...), m.Config...)
+}
+
+// SettingType returns the type of the setting, which defaults to "string".
+func (s Setting) SettingType() string {
+	if s.Type == "" {
+		return TypeString
+	}
+
+	return s.Type
+}
+
+// FieldName returns the name of the field of the generated Config struct
+// holding the setting, e.g. "DbURL" for "db_url".
+func (s Setting) FieldName() string {
+	return GoName(s.Name)
+}
+
+// FlagName returns the name of the command line flag setting the value, e.g.
+// "db-url" for "db_url".
+func (s Setting) FlagName() string {
+	return strings.Replace(s.Name, "_", "-", -1)
+}
+
+// EnvVar returns the environment variable holding the setting of the
+// service, e.g. "ADMIRAL_DB_URL" for "db_url" in the admiral service, unless
+// the setting names one.
+func (s Setting) EnvVar(service string) string {
+	if s.Env != "" {
+		return s.Env
+	}
+
+	return EnvName(service + "_" + s.Name)
+}
+
+// EnvName turns a name into an environment variable name, upper-casing its
+// letters and replacing anything but letters and digits with underscores,
+// e.g. "MY_SERVICE_CONFIG" for "my-service_config".
+func EnvName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return '_'
+		}
+
+		return unicode.ToUpper(c)
+	}, name)
+}
+
+var envPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var settingNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// validateConfig checks the settings of the descriptor, and calls add for
+// each problem found.
+func validateConfig(settings []Setting, add func(field, format string, args ...interface{})) {
+	names := make(map[string]string)
+	fields := make(map[string]string)
+
+	for _, s := range ServerSettings {
+		names[s.Name] = "a server setting"
+		fields[s.FieldName()] = "a server setting"
+	}
+
+	names[ConfigSetting] = "the flag of the config file"
+
+	for i, s := range settings {
+		field := fmt.Sprintf("config[%d]", i)
+
+		if !settingNamePattern.MatchString(s.Name) {
+			add(field+".name", "%q is not made of lower case words separated by underscores", s.Name)
+			continue
+		}
+
+		if other, ok := names[s.Name]; ok {
+			add(field+".name", "setting %q is already used by %s", s.Name, other)
+			continue
+		}
+
+		names[s.Name] = field
+
+		name := s.FieldName()
+		if other, ok := fields[name]; ok {
+			add(field+".name", "%q makes the same Go field name %s as %s", s.Name, name, other)
+			continue
+		}
+
+		fields[name] = field
+
+		parse, ok := settingTypes[s.SettingType()]
+		if !ok {
+			add(field+".type", "%q is not one of %s", s.Type,
+				strings.Join(quoteAll(TypeString, TypeInt, TypeFloat, TypeBool, TypeDuration), ", "))
+			continue
+		}
+
+		if s.Env != "" && !envPattern.MatchString(s.Env) {
+			add(field+".env", "%q is not a valid environment variable name", s.Env)
+		}
+
+		if s.Default == "" {
+			continue
+		}
+
+		if s.Required {
+			add(field+".default", "a required setting cannot have a default")
+			continue
+		}
+
+		if err := parse(s.Default); err != nil {
+			add(field+".default", "%q is not a valid %s", s.Default, s.SettingType())
+		}
+	}
+}
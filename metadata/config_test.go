@@ -0,0 +1,50 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetting_names(t *testing.T) {
+	tests := []struct {
+		setting   Setting
+		field     string
+		flag      string
+		env       string
+		valueType string
+	}{
+		{
+			setting:   Setting{Name: "db_url"},
+			field:     "DbURL",
+			flag:      "db-url",
+			env:       "MY_SERVICE_DB_URL",
+			valueType: TypeString,
+		},
+		{
+			setting:   Setting{Name: "cache_ttl", Type: TypeDuration, Env: "TTL"},
+			field:     "CacheTtl",
+			flag:      "cache-ttl",
+			env:       "TTL",
+			valueType: TypeDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.field, tt.setting.FieldName(), tt.setting.Name)
+		assert.Equal(t, tt.flag, tt.setting.FlagName(), tt.setting.Name)
+		assert.Equal(t, tt.env, tt.setting.EnvVar("my-service"), tt.setting.Name)
+		assert.Equal(t, tt.valueType, tt.setting.SettingType(), tt.setting.Name)
+	}
+}
+
+func TestMetadata_Settings(t *testing.T) {
+	m := Metadata{Config: []Setting{{Name: "db_url"}}}
+
+	settings := m.Settings()
+
+	assert.Len(t, settings, len(ServerSettings)+1)
+	assert.Equal(t, "addr", settings[0].Name)
+	assert.Equal(t, "db_url", settings[len(settings)-1].Name)
+	assert.Len(t, ServerSettings, 5, "Settings must not modify ServerSettings")
+}
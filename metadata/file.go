@@ -0,0 +1,26 @@
+package metadata
+
+import (
+	"fmt"
+
+	"github.com/go-yaml/yaml"
+)
+
+// Parse decodes the contents of a service descriptor.
+func Parse(src []byte) (Metadata, error) {
+	var m Metadata
+
+	err := yaml.Unmarshal(src, &m)
+
+	return m, err
+}
+
+// Marshal encodes the service descriptor.
+func (m *Metadata) Marshal() ([]byte, error) {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding descriptor: %v", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,38 @@
+package metadata
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_MarshalParse(t *testing.T) {
+	expected := Base(Info{Name: "test"})
+
+	err := expected.AddRoute(Route{
+		Path:        "/users/{id}",
+		HttpMethods: []string{http.MethodGet, http.MethodDelete},
+		HandlerName: "User",
+	})
+	if err != nil {
+		t.Fatalf("adding route: %v", err)
+	}
+
+	b, err := expected.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	actual, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestParse_invalid(t *testing.T) {
+	_, err := Parse([]byte("routes: {"))
+	assert.Error(t, err)
+}
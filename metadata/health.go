@@ -0,0 +1,95 @@
+package metadata
+
+import "time"
+
+// Defaults of the health endpoints, see Health.
+const (
+	DefaultLiveness     = "/healthz"
+	DefaultReadiness    = "/readyz"
+	DefaultCheckTimeout = time.Second
+)
+
+// LivenessPath returns the path of the liveness endpoint.
+func (h Health) LivenessPath() string {
+	if h.Liveness == "" {
+		return DefaultLiveness
+	}
+
+	return h.Liveness
+}
+
+// ReadinessPath returns the path of the readiness endpoint.
+func (h Health) ReadinessPath() string {
+	if h.Readiness == "" {
+		return DefaultReadiness
+	}
+
+	return h.Readiness
+}
+
+// CheckTimeout returns how long a readiness check may run by default. It
+// falls back to DefaultCheckTimeout if Timeout is not a valid duration.
+func (h Health) CheckTimeout() time.Duration {
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return DefaultCheckTimeout
+	}
+
+	return d
+}
+
+// validateHealth checks the health endpoints of the descriptor, which must
+// not be served on the path of a route, and calls add for each problem found.
+func validateHealth(h *Health, routes []Route, add func(field, format string, args ...interface{})) {
+	if h == nil {
+		return
+	}
+
+	endpoints := []struct {
+		field, path string
+	}{
+		{"health.liveness", h.LivenessPath()},
+		{"health.readiness", h.ReadinessPath()},
+	}
+
+	for i, e := range endpoints {
+		if !validateEndpoint(e.field, e.path, routes, add) {
+			continue
+		}
+
+		if i > 0 && e.path == endpoints[0].path {
+			add(e.field, "path %q is already used by the liveness endpoint", e.path)
+		}
+	}
+
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil || d <= 0 {
+			add("health.timeout", "%q is not a positive duration", h.Timeout)
+		}
+	}
+}
+
+// validateEndpoint checks the path of an endpoint served by the service
+// besides its routes, which must be well-formed, hold no path variables and
+// not be used by a route. It reports whether the path is well-formed.
+func validateEndpoint(field, p string, routes []Route, add func(field, format string, args ...interface{})) bool {
+	vars, err := PathVars(p)
+	if err != nil {
+		add(field, "%v", err)
+		return false
+	}
+
+	if len(vars) > 0 {
+		add(field, "path %q of an endpoint cannot hold path variables", p)
+		return false
+	}
+
+	for i, r := range routes {
+		if r.Path == p {
+			add(field, "path %q is already used by routes[%d]", p, i)
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth_defaults(t *testing.T) {
+	tests := []struct {
+		health    Health
+		liveness  string
+		readiness string
+		timeout   time.Duration
+	}{
+		{
+			health:    Health{},
+			liveness:  DefaultLiveness,
+			readiness: DefaultReadiness,
+			timeout:   DefaultCheckTimeout,
+		},
+		{
+			health:    Health{Liveness: "/live", Readiness: "/ready", Timeout: "250ms"},
+			liveness:  "/live",
+			readiness: "/ready",
+			timeout:   250 * time.Millisecond,
+		},
+		{
+			health:    Health{Timeout: "soon"},
+			liveness:  DefaultLiveness,
+			readiness: DefaultReadiness,
+			timeout:   DefaultCheckTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.liveness, tt.health.LivenessPath())
+		assert.Equal(t, tt.readiness, tt.health.ReadinessPath())
+		assert.Equal(t, tt.timeout, tt.health.CheckTimeout())
+	}
+}
@@ -1,11 +1,19 @@
 package metadata
 
+import "net/http"
+
 // Metadata describes what the service should look like, and generates
 // the output based on it.
 type Metadata struct {
 	// Info holds generic information about the service itself.
 	Info
 
+	// Module is the Go module path of the service, e.g.
+	// "github.com/org/service". It is written to go.mod and used to import
+	// the service's packages, and its last element decides the package name
+	// of the service. Defaults to the service name.
+	Module string
+
 	// Routes is a slice of route objects, which detail the endpoints on
 	// which the service accepts requests, and to which service implementation
 	// methods it should forward them.
@@ -16,6 +24,49 @@ type Metadata struct {
 	// decides the order in which the middlewares are added. By default,
 	// the order corresponds to the order the middlewares were added in.
 	Middlwares []Middleware
+
+	// Config declares the settings of the service, besides the ones of its
+	// server, see ServerSettings. They are loaded when the service starts,
+	// from command line flags, environment variables and an optional YAML
+	// file, in that order of precedence, and passed to New.
+	Config []Setting `yaml:",omitempty"`
+
+	// Health opts in to the health endpoints of the service: a liveness
+	// endpoint answering as long as the service serves requests, and a
+	// readiness endpoint running the checks of the service implementation.
+	Health *Health `yaml:",omitempty"`
+
+	// Metrics opts in to the metrics of the service: the requests served by
+	// each route are counted and timed, and exposed on an endpoint in the
+	// Prometheus text format.
+	Metrics *Metrics `yaml:",omitempty"`
+
+	// AccessLog opts in to the access log of the service: the requests
+	// served by each route are logged as structured entries, with their
+	// status, size and duration. It is enabled in the default descriptor.
+	AccessLog *AccessLog `yaml:",omitempty"`
+}
+
+// Names of the lifecycle hooks the service implementation can optionally
+// declare, which run when the server starts and shuts down.
+const (
+	HookStart    = "OnStart"
+	HookShutdown = "OnShutdown"
+)
+
+// HookReadiness is the name of the method returning the readiness checks the
+// service implementation can optionally declare, see Health.
+const HookReadiness = "ReadinessChecks"
+
+// HookAccessLog is the name of the method through which the service
+// implementation can optionally write the access log itself, see AccessLog.
+const HookAccessLog = "LogAccess"
+
+// IsHook reports whether name is the name of a lifecycle hook, of the
+// readiness hook or of the access log hook, which cannot be used by handlers
+// and middlewares.
+func IsHook(name string) bool {
+	return name == HookStart || name == HookShutdown || name == HookReadiness || name == HookAccessLog
 }
 
 // Route is an object that details an endpoint on which the service should serve
@@ -46,6 +97,155 @@ type Route struct {
 	// when the specified endpoint is requested, and as such, will contain the
 	// business logic
 	HandlerName string
+
+	// Params are the parameters of the requests, which are extracted and
+	// converted to their types before the handler is called. Requests with
+	// missing or malformed parameters are answered with a 400 Bad Request.
+	Params []Param `yaml:",omitempty"`
+
+	// Request describes the JSON body of the requests. Routes declaring a
+	// request or a response body are served by typed handlers, which receive
+	// the decoded request and return the response to encode, instead of
+	// returning an http.HandlerFunc.
+	Request *Body `yaml:",omitempty"`
+
+	// Response describes the JSON body of the responses. Typed handlers
+	// without one only return an error, and successful requests are answered
+	// with a 204 No Content.
+	Response *Body `yaml:",omitempty"`
+}
+
+// Body is an object that details the JSON body of a route's requests or
+// responses, either as a Go type of the service package, or as the fields of
+// a struct generated in the api package.
+type Body struct {
+	// Type is the name of a type declared in the service package, e.g.
+	// "User", or "[]User" for a list. Exclusive with Fields.
+	Type string `yaml:",omitempty"`
+
+	// Fields are the fields of the struct generated for the body, which is
+	// named after the handler, e.g. CreateUserRequest or CreateUserResponse.
+	Fields []Field `yaml:",omitempty"`
+}
+
+// Field is an object that details a field of a generated body.
+type Field struct {
+	// Name is the name of the field in the JSON object.
+	Name string
+
+	// Type is the type of the field: "string", "int", "float", "bool",
+	// "time" or "uuid", optionally prefixed with "[]" for a list. Defaults
+	// to "string".
+	Type string
+
+	// Required decides whether request bodies without the field are
+	// rejected. Ignored for response bodies.
+	Required bool
+}
+
+// Param is an object that details a parameter of a route's requests: a path
+// variable, a query parameter or a header.
+type Param struct {
+	// Name is the name of the path variable, query parameter or header. Path
+	// parameters should match a variable of the route's Path, e.g. "id" for
+	// "/users/{id:[0-9]+}".
+	Name string
+
+	// In is where the parameter is found: "path", "query" or "header".
+	In string
+
+	// Type is the type the parameter is converted to: "string", "int",
+	// "uuid", "bool" or "time". Times should be formatted as RFC 3339.
+	// Defaults to "string".
+	Type string
+
+	// Required decides whether requests without the parameter are rejected.
+	// Path parameters are always required.
+	Required bool
+
+	// Default is the value of an optional parameter missing from the request.
+	// Without a default, the parameter is left to its type's zero value.
+	Default string
+}
+
+// Setting is an object that details a setting of the service, which becomes a
+// field of the generated Config struct.
+type Setting struct {
+	// Name is the key of the setting in the YAML file, made of lower case
+	// words separated by underscores, e.g. "db_url". The command line flag is
+	// named after it with dashes, e.g. "-db-url", and the field of Config in
+	// Go case, e.g. DbURL.
+	Name string
+
+	// Type is the type of the setting: "string", "int", "float", "bool" or
+	// "duration". Defaults to "string".
+	Type string `yaml:",omitempty"`
+
+	// Default is the value of the setting when it is set nowhere. Without a
+	// default, the setting is left to its type's zero value.
+	Default string `yaml:",omitempty"`
+
+	// Env is the environment variable holding the setting. Defaults to the
+	// service name and the setting name in upper case, e.g. ADMIRAL_DB_URL.
+	Env string `yaml:",omitempty"`
+
+	// Required decides whether the service fails to start when the setting
+	// is set nowhere. Required settings cannot have a default.
+	Required bool `yaml:",omitempty"`
+
+	// Description explains what the setting is for, and is used as the usage
+	// of its flag.
+	Description string `yaml:",omitempty"`
+}
+
+// Health is an object that details the health endpoints of the service. They
+// are served on GET requests, and are not wrapped in middlewares so that
+// probes are neither logged nor rejected by them.
+type Health struct {
+	// Liveness is the path of the endpoint reporting that the service is
+	// alive, which always answers with a 200 OK. Defaults to "/healthz".
+	Liveness string `yaml:",omitempty"`
+
+	// Readiness is the path of the endpoint reporting whether the service is
+	// ready to serve requests. It runs the checks returned by the
+	// ReadinessChecks method of the service implementation, if it has one,
+	// and answers with a JSON report of their status, and a 503 Service
+	// Unavailable if any of them failed. Defaults to "/readyz".
+	Readiness string `yaml:",omitempty"`
+
+	// Timeout is how long a check may run before it fails, unless the check
+	// sets its own, e.g. "500ms". Defaults to "1s".
+	Timeout string `yaml:",omitempty"`
+}
+
+// Metrics is an object that details the metrics of the service. They are
+// labelled by the HandlerName of the route serving the request, and by the
+// status of the response.
+type Metrics struct {
+	// Path is the path of the endpoint exposing the metrics, which is served
+	// on GET requests and not wrapped in middlewares. Defaults to "/metrics".
+	Path string `yaml:",omitempty"`
+
+	// Buckets are the upper bounds of the buckets of the request duration
+	// histogram, in seconds and in increasing order. Default to the buckets
+	// of the Prometheus client libraries, from 5ms to 10s.
+	Buckets []float64 `yaml:",omitempty"`
+}
+
+// AccessLog is an object that details the access log of the service. Each
+// request served by a route is logged once answered, outside of the
+// middlewares, with the HandlerName of the route, the status and size of the
+// response, its duration, and the id of the request. Its level is "error"
+// for 5xx responses, "warn" for 4xx ones and "info" otherwise. The entries
+// are written to the standard error, unless the service implementation logs
+// them itself through a LogAccess method. It replaces the LoggerMw
+// middleware that older default descriptors held, which should be removed
+// with "seed middleware rm -handler LoggerMw" when enabling the access log
+// in those projects, so requests are not logged twice.
+type AccessLog struct {
+	// Format is the format of the entries written to the standard error:
+	// "json" or "logfmt". Defaults to "json".
+	Format string `yaml:",omitempty"`
 }
 
 // Middleware is an object that details a middleware to be added to a specific
@@ -55,7 +255,10 @@ type Middleware struct {
 	Info
 
 	// Paths contains the endpoints on which the middleware should be applied.
-	// To apply to all routes, simply specify "*"
+	// To apply to all routes, simply specify "*". A path ending with "*" is
+	// applied to all routes whose Path starts with what precedes it, e.g.
+	// "/users*". Other paths are compared against the route's Path either
+	// exactly, or as a glob, e.g. "/users/*".
 	Paths []string
 
 	// HandlerName is the name of the method that will be called by the server
@@ -91,7 +294,7 @@ type Info struct {
 
 var defMetadata = Metadata{
 	Routes: []Route{
-		/*{
+		{
 			Info: Info{
 				Name:    "Root request handler",
 				Summary: "Responds to GET requests on the root URI",
@@ -100,24 +303,24 @@ var defMetadata = Metadata{
 			HandlerName: "Index",
 			HttpMethods: []string{http.MethodGet},
 			Path:        "/",
-		},*/
-	},
-	Middlwares: []Middleware{
-		/*{
-			HandlerName: "LoggerMW",
-			Priority:    1,
-			Paths:       []string{"/"},
-			Info: Info{
-				Name:    "Logger middleware",
-				Summary: "Logs every request to stdout",
-			},
-		},*/
+		},
 	},
+	Middlwares: []Middleware{},
+	AccessLog:  &AccessLog{},
 }
 
+// Base returns the default service metadata, with the provided info set on
+// it. The default metadata contains the Index route, and enables the access
+// log to log the requests.
 func Base(info Info) Metadata {
 	def := defMetadata
 	def.Info = info
 
+	def.Routes = append([]Route{}, defMetadata.Routes...)
+	def.Middlwares = append([]Middleware{}, defMetadata.Middlwares...)
+
+	accessLog := *defMetadata.AccessLog
+	def.AccessLog = &accessLog
+
 	return def
 }
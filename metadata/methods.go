@@ -1,6 +1,9 @@
 package metadata
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 func (m *Metadata) AddRoute(route Route) error {
 	for _, r := range m.Routes {
@@ -37,3 +40,85 @@ func (m *Metadata) AddMiddleware(mw Middleware) error {
 
 	return nil
 }
+
+// RemoveRoute removes the route with the handler, and the paths of the
+// middlewares which applied to it and match no other route. It fails without
+// removing anything if a middleware would be left without paths.
+func (m *Metadata) RemoveRoute(handlerName string) error {
+	for i, r := range m.Routes {
+		if r.HandlerName != handlerName {
+			continue
+		}
+
+		routes := append(append([]Route{}, m.Routes[:i]...), m.Routes[i+1:]...)
+
+		middlewares, err := pruneMiddlewarePaths(m.Middlwares, r, routes)
+		if err != nil {
+			return err
+		}
+
+		m.Routes, m.Middlwares = routes, middlewares
+
+		return nil
+	}
+
+	return fmt.Errorf("no route with handler %q", handlerName)
+}
+
+// pruneMiddlewarePaths returns the middlewares without the paths which match
+// the removed route and none of the remaining routes.
+func pruneMiddlewarePaths(middlewares []Middleware, removed Route, routes []Route) ([]Middleware, error) {
+	remaining := Metadata{Routes: routes}
+	pruned := make([]Middleware, 0, len(middlewares))
+
+	for _, mw := range middlewares {
+		var paths []string
+
+		for _, p := range mw.Paths {
+			if PathMatches(p, removed.Path) && !remaining.matchesRoute(p) {
+				continue
+			}
+
+			paths = append(paths, p)
+		}
+
+		if len(paths) == 0 && len(mw.Paths) > 0 {
+			return nil, fmt.Errorf(
+				"middleware %q only applies to route %q, remove it first with \"seed middleware rm -handler %s\"",
+				mw.HandlerName, removed.Path, mw.HandlerName,
+			)
+		}
+
+		mw.Paths = paths
+		pruned = append(pruned, mw)
+	}
+
+	return pruned, nil
+}
+
+func (m *Metadata) RemoveMiddleware(handlerName string) error {
+	for i, mw := range m.Middlwares {
+		if mw.HandlerName != handlerName {
+			continue
+		}
+
+		m.Middlwares = append(m.Middlwares[:i], m.Middlwares[i+1:]...)
+
+		return nil
+	}
+
+	return fmt.Errorf("no middleware with handler %q", handlerName)
+}
+
+// SortedMiddlewares returns the middlewares ordered from highest to lowest
+// priority. Middlewares with the same priority keep the order they were
+// declared in.
+func (m *Metadata) SortedMiddlewares() []Middleware {
+	sorted := append([]Middleware{}, m.Middlwares...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	return sorted
+}
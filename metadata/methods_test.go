@@ -135,7 +135,7 @@ func TestServiceDescriptor_AddRoute(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			d := Base(Info{})
+			d := Metadata{}
 
 			d.Routes = append(d.Routes, tt.addRoutes...)
 
@@ -152,6 +152,126 @@ func TestServiceDescriptor_AddRoute(t *testing.T) {
 	}
 }
 
+func TestServiceDescriptor_RemoveRoute(t *testing.T) {
+	routes := []Route{
+		{
+			HandlerName: "first",
+			Path:        "/first",
+			HttpMethods: []string{http.MethodGet},
+		},
+		{
+			HandlerName: "second",
+			Path:        "/second",
+			HttpMethods: []string{http.MethodGet},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		handlerName string
+		wantErr     bool
+		wantRoutes  []string
+	}{
+		{
+			name:        "remove first",
+			handlerName: "first",
+			wantErr:     false,
+			wantRoutes:  []string{"second"},
+		},
+		{
+			name:        "remove second",
+			handlerName: "second",
+			wantErr:     false,
+			wantRoutes:  []string{"first"},
+		},
+		{
+			name:        "missing handler",
+			handlerName: "third",
+			wantErr:     true,
+			wantRoutes:  []string{"first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Metadata{}
+
+			d.Routes = append(d.Routes, routes...)
+
+			if err := d.RemoveRoute(tt.handlerName); (err != nil) != tt.wantErr {
+				t.Errorf("Metadata.RemoveRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var actual []string
+			for _, r := range d.Routes {
+				actual = append(actual, r.HandlerName)
+			}
+
+			assert.Equal(t, tt.wantRoutes, actual)
+		})
+	}
+}
+
+func TestServiceDescriptor_RemoveRoute_middlewarePaths(t *testing.T) {
+	routes := []Route{
+		{HandlerName: "users", Path: "/users", HttpMethods: []string{http.MethodGet}},
+		{HandlerName: "user", Path: "/users/{id}", HttpMethods: []string{http.MethodGet}},
+		{HandlerName: "status", Path: "/status", HttpMethods: []string{http.MethodGet}},
+	}
+
+	tests := []struct {
+		name        string
+		handlerName string
+		paths       []string
+		wantErr     string
+		wantPaths   []string
+	}{
+		{
+			name:        "prunes the removed route",
+			handlerName: "users",
+			paths:       []string{"/users", "/status"},
+			wantPaths:   []string{"/status"},
+		},
+		{
+			name:        "keeps patterns matching other routes",
+			handlerName: "users",
+			paths:       []string{"*", "/users*"},
+			wantPaths:   []string{"*", "/users*"},
+		},
+		{
+			name:        "keeps paths of other routes",
+			handlerName: "status",
+			paths:       []string{"/users"},
+			wantPaths:   []string{"/users"},
+		},
+		{
+			name:        "only path of the middleware",
+			handlerName: "users",
+			paths:       []string{"/users"},
+			wantErr:     `middleware "AuthMw" only applies to route "/users", remove it first with "seed middleware rm -handler AuthMw"`,
+			wantPaths:   []string{"/users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Metadata{
+				Routes:     append([]Route{}, routes...),
+				Middlwares: []Middleware{{HandlerName: "AuthMw", Paths: tt.paths}},
+			}
+
+			err := d.RemoveRoute(tt.handlerName)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Len(t, d.Routes, len(routes))
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, d.Routes, len(routes)-1)
+			}
+
+			assert.Equal(t, tt.wantPaths, d.Middlwares[0].Paths)
+		})
+	}
+}
+
 func TestServiceDescriptor_AddMiddleware(t *testing.T) {
 	defInfo := Info{
 		Name:        "default",
@@ -197,7 +317,7 @@ func TestServiceDescriptor_AddMiddleware(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			d := Base(Info{})
+			d := Metadata{}
 
 			d.Middlwares = append(d.Middlwares, tt.addMiddlewares...)
 
@@ -213,3 +333,39 @@ func TestServiceDescriptor_AddMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceDescriptor_RemoveMiddleware(t *testing.T) {
+	d := Metadata{
+		Middlwares: []Middleware{
+			{HandlerName: "first"},
+			{HandlerName: "second"},
+		},
+	}
+
+	err := d.RemoveMiddleware("third")
+	assert.Error(t, err)
+	assert.Len(t, d.Middlwares, 2)
+
+	err = d.RemoveMiddleware("first")
+	assert.NoError(t, err)
+	assert.Equal(t, []Middleware{{HandlerName: "second"}}, d.Middlwares)
+}
+
+func TestServiceDescriptor_SortedMiddlewares(t *testing.T) {
+	d := Metadata{
+		Middlwares: []Middleware{
+			{HandlerName: "low", Priority: 1},
+			{HandlerName: "firstHigh", Priority: 5},
+			{HandlerName: "none"},
+			{HandlerName: "secondHigh", Priority: 5},
+		},
+	}
+
+	var actual []string
+	for _, mw := range d.SortedMiddlewares() {
+		actual = append(actual, mw.HandlerName)
+	}
+
+	assert.Equal(t, []string{"firstHigh", "secondHigh", "low", "none"}, actual)
+	assert.Equal(t, "low", d.Middlwares[0].HandlerName, "declaration order should be left untouched")
+}
@@ -0,0 +1,58 @@
+package metadata
+
+import "fmt"
+
+// DefaultMetricsPath is the path of the metrics endpoint, see Metrics.
+const DefaultMetricsPath = "/metrics"
+
+// DefaultBuckets are the buckets of the request duration histogram, see
+// Metrics.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricsPath returns the path of the metrics endpoint.
+func (m Metrics) MetricsPath() string {
+	if m.Path == "" {
+		return DefaultMetricsPath
+	}
+
+	return m.Path
+}
+
+// DurationBuckets returns the buckets of the request duration histogram.
+func (m Metrics) DurationBuckets() []float64 {
+	if len(m.Buckets) == 0 {
+		return DefaultBuckets
+	}
+
+	return m.Buckets
+}
+
+// validateMetrics checks the metrics of the descriptor, whose endpoint must
+// not be served on the path of a route or of a health endpoint, and calls add
+// for each problem found.
+func validateMetrics(m *Metrics, health *Health, routes []Route, add func(field, format string, args ...interface{})) {
+	if m == nil {
+		return
+	}
+
+	p := m.MetricsPath()
+
+	if validateEndpoint("metrics.path", p, routes, add) && health != nil {
+		if p == health.LivenessPath() || p == health.ReadinessPath() {
+			add("metrics.path", "path %q is already used by a health endpoint", p)
+		}
+	}
+
+	for i, b := range m.Buckets {
+		field := fmt.Sprintf("metrics.buckets[%d]", i)
+
+		if b <= 0 {
+			add(field, "%v is not a positive duration in seconds", b)
+			continue
+		}
+
+		if i > 0 && b <= m.Buckets[i-1] {
+			add(field, "%v does not follow %v in increasing order", b, m.Buckets[i-1])
+		}
+	}
+}
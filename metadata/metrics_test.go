@@ -0,0 +1,16 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_defaults(t *testing.T) {
+	assert.Equal(t, DefaultMetricsPath, Metrics{}.MetricsPath())
+	assert.Equal(t, DefaultBuckets, Metrics{}.DurationBuckets())
+
+	m := Metrics{Path: "/stats", Buckets: []float64{0.1, 1}}
+	assert.Equal(t, "/stats", m.MetricsPath())
+	assert.Equal(t, []float64{0.1, 1}, m.DurationBuckets())
+}
@@ -0,0 +1,116 @@
+package metadata
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"strings"
+)
+
+// ModulePath returns the Go module path of the service, which defaults to
+// the service name.
+func (m *Metadata) ModulePath() string {
+	if m.Module == "" {
+		return m.Name
+	}
+
+	return m.Module
+}
+
+// PackageName returns the name of the service's Go package. It is derived
+// from the last element of the module path, skipping a major version suffix
+// such as "/v2". Without a module path, it is the service name.
+func (m *Metadata) PackageName() string {
+	if m.Module == "" {
+		return m.Name
+	}
+
+	name, err := SanitizePackageName(lastElement(m.Module))
+	if err != nil {
+		return m.Name
+	}
+
+	return name
+}
+
+// SanitizePackageName turns name into a Go package name: it is lowercased,
+// and anything but ASCII letters and digits is dropped, along with leading
+// digits. For instance, "My-Service2" becomes "myservice2".
+func SanitizePackageName(name string) (string, error) {
+	var b strings.Builder
+
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9' && b.Len() > 0:
+			b.WriteRune(c)
+		}
+	}
+
+	result := b.String()
+	if result == "" || token.Lookup(result).IsKeyword() {
+		return "", fmt.Errorf("cannot derive a package name from %q", name)
+	}
+
+	return result, nil
+}
+
+// lastElement returns the last element of the module path which is not a
+// major version suffix.
+func lastElement(module string) string {
+	elem := path.Base(module)
+
+	if isMajorVersion(elem) && path.Dir(module) != "." {
+		return path.Base(path.Dir(module))
+	}
+
+	return elem
+}
+
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' || elem[1] == '0' {
+		return false
+	}
+
+	for _, c := range elem[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateModulePath checks that the module path is made of non-empty
+// elements separated by slashes, holding only the characters allowed by the
+// go command.
+func validateModulePath(module string) error {
+	for _, elem := range strings.Split(module, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return fmt.Errorf("module path %q has an empty or relative element", module)
+		}
+
+		if strings.HasPrefix(elem, ".") || strings.HasSuffix(elem, ".") {
+			return fmt.Errorf("module path %q has an element starting or ending with a dot", module)
+		}
+
+		for _, c := range elem {
+			if !isModulePathChar(c) {
+				return fmt.Errorf("module path %q contains the invalid character %q", module, c)
+			}
+		}
+	}
+
+	_, err := SanitizePackageName(lastElement(module))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func isModulePathChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.ContainsRune("-._~", c)
+}
@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizePackageName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		err      bool
+	}{
+		{name: "service", expected: "service"},
+		{name: "My-Service2", expected: "myservice2"},
+		{name: "42_users.api", expected: "usersapi"},
+		{name: "func", err: true},
+		{name: "123", err: true},
+		{name: "/", err: true},
+	}
+
+	for _, tt := range tests {
+		actual, err := SanitizePackageName(tt.name)
+		if tt.err {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, actual, tt.name)
+	}
+}
+
+func TestMetadata_PackageName(t *testing.T) {
+	tests := []struct {
+		module      string
+		modulePath  string
+		packageName string
+	}{
+		{module: "", modulePath: "admiral", packageName: "admiral"},
+		{module: "github.com/org/svc", modulePath: "github.com/org/svc", packageName: "svc"},
+		{module: "github.com/org/fleet-api", modulePath: "github.com/org/fleet-api", packageName: "fleetapi"},
+		{module: "github.com/org/svc/v2", modulePath: "github.com/org/svc/v2", packageName: "svc"},
+		{module: "v2", modulePath: "v2", packageName: "v2"},
+	}
+
+	for _, tt := range tests {
+		m := Metadata{Info: Info{Name: "admiral"}, Module: tt.module}
+
+		assert.Equal(t, tt.modulePath, m.ModulePath(), tt.module)
+		assert.Equal(t, tt.packageName, m.PackageName(), tt.module)
+	}
+}
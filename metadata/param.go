@@ -0,0 +1,184 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The places a route's parameter can be found in.
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+)
+
+// The types a route's parameter can be converted to.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeUUID   = "uuid"
+	TypeBool   = "bool"
+	TypeTime   = "time"
+
+	// TypeFloat is only available to the fields of bodies.
+	TypeFloat = "float"
+)
+
+// paramTypes holds the parsers checking that a value can be converted to
+// each of the parameter types.
+var paramTypes = map[string]func(string) error{
+	TypeString: func(string) error { return nil },
+	TypeInt: func(v string) error {
+		_, err := strconv.Atoi(v)
+		return err
+	},
+	TypeUUID: func(v string) error {
+		if !uuidPattern.MatchString(v) {
+			return fmt.Errorf("%q is not a UUID", v)
+		}
+		return nil
+	},
+	TypeBool: func(v string) error {
+		_, err := strconv.ParseBool(v)
+		return err
+	},
+	TypeTime: func(v string) error {
+		_, err := time.Parse(time.RFC3339, v)
+		return err
+	},
+}
+
+// UUIDPattern matches the canonical textual form of a UUID.
+const UUIDPattern = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+
+var uuidPattern = regexp.MustCompile(UUIDPattern)
+
+// initialisms are the words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"api":  true,
+	"http": true,
+	"id":   true,
+	"ip":   true,
+	"json": true,
+	"url":  true,
+	"uuid": true,
+}
+
+// ParamType returns the type of the parameter, which defaults to "string".
+func (p Param) ParamType() string {
+	if p.Type == "" {
+		return TypeString
+	}
+
+	return p.Type
+}
+
+// IsRequired reports whether requests must hold the parameter. Path
+// parameters always are.
+func (p Param) IsRequired() bool {
+	return p.Required || p.In == InPath
+}
+
+// FieldName returns the name of the Go struct field holding the parameter,
+// e.g. "XRequestID" for "X-Request-Id".
+func (p Param) FieldName() string {
+	return GoName(p.Name)
+}
+
+// GoName turns a name made of words separated by anything but letters and
+// digits into an exported Go identifier, e.g. "CreateUser" for "createUser"
+// or "create-user". The result is empty if name holds no letter nor digit.
+func GoName(name string) string {
+	words := strings.FieldsFunc(name, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	var b strings.Builder
+
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+
+		b.WriteString(strings.Title(word))
+	}
+
+	return b.String()
+}
+
+// validateParams checks the parameters of the route, given the variables of
+// its path, and calls add for each problem found.
+func validateParams(field string, params []Param, vars map[string]bool, add func(field, format string, args ...interface{})) {
+	seen := make(map[string]string)
+	fields := make(map[string]string)
+
+	for i, p := range params {
+		paramField := fmt.Sprintf("%s.params[%d]", field, i)
+
+		if p.Name == "" {
+			add(paramField+".name", "a name is required")
+			continue
+		}
+
+		switch p.In {
+		case InPath:
+			if !vars[p.Name] {
+				add(paramField+".name", "the path has no variable %q", p.Name)
+			}
+
+			if p.Default != "" {
+				add(paramField+".default", "path parameters cannot have a default")
+			}
+		case InQuery, InHeader:
+		default:
+			add(paramField+".in", "%q is not one of %q, %q or %q", p.In, InPath, InQuery, InHeader)
+		}
+
+		parse, ok := paramTypes[p.ParamType()]
+		if !ok {
+			add(paramField+".type", "%q is not one of %q, %q, %q, %q or %q",
+				p.Type, TypeString, TypeInt, TypeUUID, TypeBool, TypeTime)
+		}
+
+		if p.Default != "" {
+			if p.Required {
+				add(paramField+".default", "required parameters cannot have a default")
+			} else if ok {
+				err := parse(p.Default)
+				if err != nil {
+					add(paramField+".default", "%q is not a valid %s: %v", p.Default, p.ParamType(), err)
+				}
+			}
+		}
+
+		key := p.In + " " + p.Name
+		if p.In == InHeader {
+			key = p.In + " " + strings.ToLower(p.Name)
+		}
+
+		if other, ok := seen[key]; ok {
+			add(paramField+".name", "%s parameter %q is already declared by %s", p.In, p.Name, other)
+			continue
+		}
+
+		seen[key] = paramField
+
+		name := p.FieldName()
+		if !isExportedIdentifier(name) {
+			add(paramField+".name", "%q does not make a valid Go field name", p.Name)
+			continue
+		}
+
+		if other, ok := fields[name]; ok {
+			add(paramField+".name", "%q makes the same Go field name %s as %s", p.Name, name, other)
+			continue
+		}
+
+		fields[name] = paramField
+	}
+}
@@ -0,0 +1,55 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParam_FieldName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "limit", expected: "Limit"},
+		{name: "id", expected: "ID"},
+		{name: "user_id", expected: "UserID"},
+		{name: "X-Request-Id", expected: "XRequestID"},
+		{name: "callbackUrl", expected: "CallbackUrl"},
+		{name: "page.size", expected: "PageSize"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Param{Name: tt.name}.FieldName(), tt.name)
+	}
+}
+
+func TestParseValid_paramPositions(t *testing.T) {
+	src := `info:
+  name: test
+routes:
+- path: /users/{id}
+  httpmethods: [GET]
+  handlername: User
+  params:
+  - name: id
+    in: path
+    type: int
+  - name: limit
+    in: query
+    type: float
+`
+
+	_, err := ParseValid("test.yml", []byte(src))
+
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "routes[0].params[1].type", errs[0].Field)
+		assert.Equal(t, 13, errs[0].Line)
+		assert.Equal(t, 5, errs[0].Column)
+	}
+}
@@ -0,0 +1,103 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a line and column in the source of a descriptor, both starting
+// at 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// frame is a mapping key or sequence item that is still open while scanning
+// the descriptor, along with the indentation it was found at.
+type frame struct {
+	indent int
+	field  string
+	item   bool
+	items  int
+}
+
+// Positions returns the position of the fields of a block style YAML
+// descriptor, such as the ones returned by Metadata.Marshal, keyed by their
+// path, e.g. "routes[1].handlername". Flow style collections are not
+// descended into, so their contents are not listed.
+func Positions(src []byte) map[string]Position {
+	positions := make(map[string]Position)
+
+	root := &frame{indent: -1}
+	stack := []*frame{root}
+
+	top := func() *frame { return stack[len(stack)-1] }
+
+	// key records the key at the given indentation, under the innermost frame
+	// that has a lower indentation.
+	key := func(line, indent int, text string) {
+		for top().indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		name := strings.TrimSpace(text[:strings.Index(text, ":")])
+		name = strings.Trim(name, `"'`)
+
+		field := name
+		if parent := top().field; parent != "" {
+			field = parent + "." + name
+		}
+
+		positions[field] = Position{Line: line, Column: indent + 1}
+		stack = append(stack, &frame{indent: indent, field: field})
+	}
+
+	for i, line := range strings.Split(string(src), "\n") {
+		text := strings.TrimLeft(line, " ")
+		indent := len(line) - len(text)
+
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "---") {
+			continue
+		}
+
+		for strings.HasPrefix(text, "- ") || text == "-" {
+			// Sequence items may be at the same indentation as their parent
+			// key, so only deeper frames and previous items are closed.
+			for top().indent > indent || (top().item && top().indent == indent) {
+				stack = stack[:len(stack)-1]
+			}
+
+			parent := top()
+			field := fmt.Sprintf("%s[%d]", parent.field, parent.items)
+			parent.items++
+
+			positions[field] = Position{Line: i + 1, Column: indent + 1}
+			stack = append(stack, &frame{indent: indent, field: field, item: true})
+
+			rest := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+			indent += len(text) - len(rest)
+			text = rest
+
+			if text != "" {
+				positions[field] = Position{Line: i + 1, Column: indent + 1}
+			}
+		}
+
+		if isKey(text) {
+			key(i+1, indent, text)
+		}
+	}
+
+	return positions
+}
+
+// isKey reports whether the text starts with a mapping key.
+func isKey(text string) bool {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return false
+	}
+
+	i := strings.Index(text, ":")
+
+	return i > 0 && (i == len(text)-1 || text[i+1] == ' ')
+}
@@ -0,0 +1,407 @@
+package metadata
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// httpMethods are the HTTP methods a route can be served on.
+var httpMethods = map[string]bool{
+	"GET":     true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+	"HEAD":    true,
+	"CONNECT": true,
+	"TRACE":   true,
+}
+
+// ValidationError describes a single problem with a service descriptor. Field
+// is the path of the offending value in the descriptor, e.g.
+// "routes[1].handlername". File, Line and Column are only set if the
+// descriptor was validated from its source.
+type ValidationError struct {
+	File   string
+	Line   int
+	Column int
+	Field  string
+	Msg    string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+	}
+
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Field, e.Msg)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Field, e.Msg)
+}
+
+// ValidationErrors holds every problem found while validating a service
+// descriptor.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Locate sets the position of each error to the position of its field in the
+// source of the descriptor. If a field can not be found, the position of its
+// closest parent is used. The errors are then sorted by position, errors which
+// could not be located coming last.
+func (errs ValidationErrors) Locate(file string, src []byte) {
+	positions := Positions(src)
+
+	for i := range errs {
+		errs[i].File = file
+
+		for field := errs[i].Field; field != ""; field = parentField(field) {
+			if pos, ok := positions[field]; ok {
+				errs[i].Line, errs[i].Column = pos.Line, pos.Column
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i], errs[j]
+		if a.Line == 0 || b.Line == 0 {
+			return b.Line == 0 && a.Line != 0
+		}
+
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+}
+
+// ParseValid decodes the contents of the service descriptor read from file
+// and validates it. Validation errors carry their position in src.
+func ParseValid(file string, src []byte) (Metadata, error) {
+	m, err := Parse(src)
+	if err != nil {
+		return m, fmt.Errorf("failed parsing descriptor %s: %v", file, err)
+	}
+
+	err = m.Validate()
+	if errs, ok := err.(ValidationErrors); ok {
+		errs.Locate(file, src)
+	}
+
+	return m, err
+}
+
+// Validate checks the whole descriptor: the service name must be a valid Go
+// package name, the module path must be well-formed, handler names must be
+// exported Go identifiers, unique and not hooks, paths must start with a
+// slash and hold well-formed path variables, route parameters and body fields
+// must be declared once with a known type, routes must be served on standard
+// HTTP methods without clashing, neither between them nor in the calls of
+// the generated clients, middleware paths must be "*" or match at
+// least one route, settings must be declared once with a known type and a
+// valid default, the health and metrics endpoints must not clash with routes,
+// and the access log format must be known. Every problem found is returned as
+// part of a ValidationErrors.
+func (m *Metadata) Validate() error {
+	var errs ValidationErrors
+
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if !isPackageName(m.Name) {
+		add("info.name", "%q is not a valid Go package name", m.Name)
+	}
+
+	if m.Module != "" {
+		err := validateModulePath(m.Module)
+		if err != nil {
+			add("module", "%v", err)
+		}
+	}
+
+	handlers := make(map[string]string)
+
+	checkHandler := func(field, name string) {
+		if !isExportedIdentifier(name) {
+			add(field, "%q is not an exported Go identifier", name)
+			return
+		}
+
+		if IsHook(name) {
+			add(field, "%q is reserved for the hooks of the service", name)
+			return
+		}
+
+		if other, ok := handlers[name]; ok {
+			add(field, "handler %q is already used by %s", name, other)
+			return
+		}
+
+		handlers[name] = parentField(field)
+	}
+
+	for i, r := range m.Routes {
+		field := fmt.Sprintf("routes[%d]", i)
+
+		checkHandler(field+".handlername", r.HandlerName)
+
+		vars, err := pathVars(r.Path)
+		if err != nil {
+			add(field+".path", "%v", err)
+		}
+
+		validateParams(field, r.Params, vars, add)
+		validateBody(field+".request", r.Request, add)
+		validateBody(field+".response", r.Response, add)
+
+		if len(r.HttpMethods) == 0 {
+			add(field+".httpmethods", "at least one HTTP method is required")
+		}
+
+		for j, method := range r.HttpMethods {
+			methodField := fmt.Sprintf("%s.httpmethods[%d]", field, j)
+
+			if !httpMethods[method] {
+				add(methodField, "%q is not a standard HTTP method", method)
+				continue
+			}
+
+			for k, other := range m.Routes[:i] {
+				if other.Path == r.Path && contains(other.HttpMethods, method) {
+					add(methodField, "path %q and method %q are already used by routes[%d]", r.Path, method, k)
+				}
+			}
+		}
+	}
+
+	for i, mw := range m.Middlwares {
+		field := fmt.Sprintf("middlwares[%d]", i)
+
+		checkHandler(field+".handlername", mw.HandlerName)
+
+		if len(mw.Paths) == 0 {
+			add(field+".paths", "at least one path is required, specify \"*\" to apply to all routes")
+		}
+
+		for j, p := range mw.Paths {
+			if p != "*" && !m.matchesRoute(p) {
+				add(fmt.Sprintf("%s.paths[%d]", field, j), "%q does not match any route", p)
+			}
+		}
+	}
+
+	validateClientCalls(m.Routes, add)
+	validateConfig(m.Config, add)
+	validateHealth(m.Health, m.Routes, add)
+	validateMetrics(m.Metrics, m.Health, m.Routes, add)
+	validateAccessLog(m.AccessLog, add)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// PathMatches reports whether a middleware path matches the path of a route.
+// "*" matches all the routes, a pattern ending with "*" matches the routes
+// starting with what precedes it, and any other pattern is matched either
+// exactly or as a glob.
+func PathMatches(pattern, routePath string) bool {
+	if pattern == "*" || pattern == routePath {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, "*") && strings.HasPrefix(routePath, strings.TrimSuffix(pattern, "*")) {
+		return true
+	}
+
+	ok, _ := path.Match(pattern, routePath)
+
+	return ok
+}
+
+func (m *Metadata) matchesRoute(pattern string) bool {
+	for _, r := range m.Routes {
+		if PathMatches(pattern, r.Path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PathVar is a gorilla/mux variable of a route's path, such as {id} or
+// {id:[0-9]+}.
+type PathVar struct {
+	// Name is the name of the variable, e.g. "id".
+	Name string
+
+	// Pattern is the regular expression the variable should match, e.g.
+	// "[0-9]+". Empty if the variable matches any path segment.
+	Pattern string
+}
+
+// PathVars checks that the path starts with a slash, and that its
+// gorilla/mux variables are well-formed. It returns the variables in the
+// order they appear in the path.
+func PathVars(p string) ([]PathVar, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("path %q should start with a slash", p)
+	}
+
+	var vars []PathVar
+
+	seen := make(map[string]bool)
+
+	for rest := p; ; {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			return vars, nil
+		}
+
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("path %q has an unopened '}'", p)
+		}
+
+		end, err := closingBrace(rest[open:])
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %v", p, err)
+		}
+
+		name, pattern := rest[open+1:open+end], ""
+		if i := strings.Index(name, ":"); i >= 0 {
+			name, pattern = name[:i], name[i+1:]
+		}
+
+		if !isVarName(name) {
+			return nil, fmt.Errorf("path %q has an invalid variable name %q", p, name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("path %q has a duplicated variable %q", p, name)
+		}
+
+		seen[name] = true
+
+		if _, err := regexp.Compile(pattern); pattern != "" && err != nil {
+			return nil, fmt.Errorf("path %q has an invalid pattern for variable %q: %v", p, name, err)
+		}
+
+		vars = append(vars, PathVar{Name: name, Pattern: pattern})
+
+		rest = rest[open+end+1:]
+	}
+}
+
+// pathVars returns the names of the variables of the path, see PathVars.
+func pathVars(p string) (map[string]bool, error) {
+	vars, err := PathVars(p)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, v := range vars {
+		names[v.Name] = true
+	}
+
+	return names, nil
+}
+
+// closingBrace returns the index of the brace closing the one s starts with,
+// allowing nested braces in patterns such as {id:[0-9]{4}}.
+func closingBrace(s string) (int, error) {
+	level := 0
+
+	for i, c := range s {
+		switch c {
+		case '{':
+			level++
+		case '}':
+			level--
+			if level == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unclosed '{'")
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, c := range name {
+		if c != '_' && c != '-' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || token.Lookup(name).IsKeyword() {
+		return false
+	}
+
+	for i, c := range name {
+		if c != '_' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isExportedIdentifier(name string) bool {
+	return isIdentifier(name) && unicode.IsUpper([]rune(name)[0])
+}
+
+// isPackageName reports whether name is a valid Go package name. Besides
+// being an identifier, it should not be the blank identifier.
+func isPackageName(name string) bool {
+	return isIdentifier(name) && name != "_"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parentField returns the path of the field holding the given one, e.g.
+// "routes[1]" for "routes[1].path" and "routes" for "routes[1]".
+func parentField(field string) string {
+	i := strings.LastIndexAny(field, ".[")
+	if i < 0 {
+		return ""
+	}
+
+	return field[:i]
+}
@@ -0,0 +1,444 @@
+package metadata
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validMetadata() Metadata {
+	return Metadata{
+		Info: Info{Name: "test"},
+		Routes: []Route{
+			{Path: "/", HttpMethods: []string{http.MethodGet}, HandlerName: "Index"},
+			{Path: "/users/{id:[0-9]{1,4}}", HttpMethods: []string{http.MethodGet}, HandlerName: "User"},
+		},
+		Middlwares: []Middleware{
+			{Paths: []string{"*"}, HandlerName: "LoggerMw"},
+			{Paths: []string{"/users*"}, HandlerName: "AuthMw"},
+		},
+	}
+}
+
+func TestMetadata_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(m *Metadata)
+		wantFields []string
+	}{
+		{
+			name:   "valid",
+			modify: func(m *Metadata) {},
+		},
+		{
+			name:       "invalid package name",
+			modify:     func(m *Metadata) { m.Name = "my-service" },
+			wantFields: []string{"info.name"},
+		},
+		{
+			name:       "keyword package name",
+			modify:     func(m *Metadata) { m.Name = "func" },
+			wantFields: []string{"info.name"},
+		},
+		{
+			name:   "module path",
+			modify: func(m *Metadata) { m.Module = "github.com/org/test-api/v2" },
+		},
+		{
+			name:       "module path with empty element",
+			modify:     func(m *Metadata) { m.Module = "github.com//test" },
+			wantFields: []string{"module"},
+		},
+		{
+			name:       "module path with invalid character",
+			modify:     func(m *Metadata) { m.Module = "github.com/org/my service" },
+			wantFields: []string{"module"},
+		},
+		{
+			name:       "module path without package name",
+			modify:     func(m *Metadata) { m.Module = "github.com/org/func" },
+			wantFields: []string{"module"},
+		},
+		{
+			name:       "unexported handler",
+			modify:     func(m *Metadata) { m.Routes[0].HandlerName = "index" },
+			wantFields: []string{"routes[0].handlername"},
+		},
+		{
+			name:       "duplicate handler across routes and middlewares",
+			modify:     func(m *Metadata) { m.Middlwares[1].HandlerName = "User" },
+			wantFields: []string{"middlwares[1].handlername"},
+		},
+		{
+			name:       "lifecycle hook handler",
+			modify:     func(m *Metadata) { m.Routes[1].HandlerName = HookShutdown },
+			wantFields: []string{"routes[1].handlername"},
+		},
+		{
+			name:       "readiness hook handler",
+			modify:     func(m *Metadata) { m.Middlwares[0].HandlerName = HookReadiness },
+			wantFields: []string{"middlwares[0].handlername"},
+		},
+		{
+			name:   "default health endpoints",
+			modify: func(m *Metadata) { m.Health = &Health{} },
+		},
+		{
+			name: "health endpoints",
+			modify: func(m *Metadata) {
+				m.Health = &Health{Liveness: "/live", Readiness: "/ready", Timeout: "250ms"}
+			},
+		},
+		{
+			name:       "health endpoint without slash",
+			modify:     func(m *Metadata) { m.Health = &Health{Liveness: "healthz"} },
+			wantFields: []string{"health.liveness"},
+		},
+		{
+			name:       "health endpoint with path variable",
+			modify:     func(m *Metadata) { m.Health = &Health{Readiness: "/readyz/{check}"} },
+			wantFields: []string{"health.readiness"},
+		},
+		{
+			name:       "health endpoints on the same path",
+			modify:     func(m *Metadata) { m.Health = &Health{Liveness: "/health", Readiness: "/health"} },
+			wantFields: []string{"health.readiness"},
+		},
+		{
+			name:       "health endpoint on a route",
+			modify:     func(m *Metadata) { m.Health = &Health{Liveness: "/"} },
+			wantFields: []string{"health.liveness"},
+		},
+		{
+			name:       "invalid health timeout",
+			modify:     func(m *Metadata) { m.Health = &Health{Timeout: "-1s"} },
+			wantFields: []string{"health.timeout"},
+		},
+		{
+			name:   "default metrics",
+			modify: func(m *Metadata) { m.Metrics = &Metrics{} },
+		},
+		{
+			name:   "metrics",
+			modify: func(m *Metadata) { m.Metrics = &Metrics{Path: "/stats", Buckets: []float64{0.1, 1, 10}} },
+		},
+		{
+			name:       "metrics endpoint on a route",
+			modify:     func(m *Metadata) { m.Metrics = &Metrics{Path: "/"} },
+			wantFields: []string{"metrics.path"},
+		},
+		{
+			name: "metrics endpoint on a health endpoint",
+			modify: func(m *Metadata) {
+				m.Health = &Health{}
+				m.Metrics = &Metrics{Path: DefaultReadiness}
+			},
+			wantFields: []string{"metrics.path"},
+		},
+		{
+			name:       "unordered metrics buckets",
+			modify:     func(m *Metadata) { m.Metrics = &Metrics{Buckets: []float64{0, 1, 0.5}} },
+			wantFields: []string{"metrics.buckets[0]", "metrics.buckets[2]"},
+		},
+		{
+			name:       "access log hook handler",
+			modify:     func(m *Metadata) { m.Routes[0].HandlerName = HookAccessLog },
+			wantFields: []string{"routes[0].handlername"},
+		},
+		{
+			name:   "access log",
+			modify: func(m *Metadata) { m.AccessLog = &AccessLog{Format: FormatLogfmt} },
+		},
+		{
+			name:       "unknown access log format",
+			modify:     func(m *Metadata) { m.AccessLog = &AccessLog{Format: "xml"} },
+			wantFields: []string{"accesslog.format"},
+		},
+		{
+			name: "settings",
+			modify: func(m *Metadata) {
+				m.Config = []Setting{
+					{Name: "db_url", Required: true, Env: "DATABASE_URL"},
+					{Name: "cache_ttl", Type: TypeDuration, Default: "1m30s"},
+					{Name: "ratio", Type: TypeFloat, Default: "0.5"},
+				}
+			},
+		},
+		{
+			name:       "setting named after a server setting",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "addr"}} },
+			wantFields: []string{"config[0].name"},
+		},
+		{
+			name:       "setting named after the config flag",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: ConfigSetting}} },
+			wantFields: []string{"config[0].name"},
+		},
+		{
+			name:       "setting name not in snake case",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "db-url"}} },
+			wantFields: []string{"config[0].name"},
+		},
+		{
+			name:       "duplicated setting",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "db_url"}, {Name: "db_url"}} },
+			wantFields: []string{"config[1].name"},
+		},
+		{
+			name:       "unknown setting type",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "since", Type: TypeTime}} },
+			wantFields: []string{"config[0].type"},
+		},
+		{
+			name:       "invalid setting default",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "ttl", Type: TypeDuration, Default: "90"}} },
+			wantFields: []string{"config[0].default"},
+		},
+		{
+			name:       "required setting with default",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "db_url", Required: true, Default: "x"}} },
+			wantFields: []string{"config[0].default"},
+		},
+		{
+			name:       "invalid setting environment variable",
+			modify:     func(m *Metadata) { m.Config = []Setting{{Name: "db_url", Env: "DB-URL"}} },
+			wantFields: []string{"config[0].env"},
+		},
+		{
+			name:       "path without slash",
+			modify:     func(m *Metadata) { m.Routes[0].Path = "users" },
+			wantFields: []string{"routes[0].path"},
+		},
+		{
+			name:       "unclosed path variable",
+			modify:     func(m *Metadata) { m.Routes[1].Path = "/users/{id" },
+			wantFields: []string{"routes[1].path"},
+		},
+		{
+			name:       "empty path variable name",
+			modify:     func(m *Metadata) { m.Routes[1].Path = "/users/{:[0-9]+}" },
+			wantFields: []string{"routes[1].path"},
+		},
+		{
+			name:       "invalid path variable pattern",
+			modify:     func(m *Metadata) { m.Routes[1].Path = "/users/{id:[0-9}" },
+			wantFields: []string{"routes[1].path"},
+		},
+		{
+			name:       "duplicated path variable",
+			modify:     func(m *Metadata) { m.Routes[1].Path = "/users/{id}/{id}" },
+			wantFields: []string{"routes[1].path"},
+		},
+		{
+			name:       "non standard method",
+			modify:     func(m *Metadata) { m.Routes[1].HttpMethods = []string{"GET", "get"} },
+			wantFields: []string{"routes[1].httpmethods[1]"},
+		},
+		{
+			name:       "no methods",
+			modify:     func(m *Metadata) { m.Routes[1].HttpMethods = nil },
+			wantFields: []string{"routes[1].httpmethods"},
+		},
+		{
+			name: "path and method clash",
+			modify: func(m *Metadata) {
+				m.Routes[1].Path = "/"
+				m.Middlwares[1].Paths = []string{"*"}
+			},
+			wantFields: []string{"routes[1].httpmethods[0]"},
+		},
+		{
+			name: "client call clash",
+			modify: func(m *Metadata) {
+				m.Routes[0].HttpMethods = []string{http.MethodGet, http.MethodPost}
+				m.Routes[1].HandlerName = "IndexPost"
+			},
+			wantFields: []string{"routes[0].httpmethods[1]"},
+		},
+		{
+			name:       "middleware path matching no route",
+			modify:     func(m *Metadata) { m.Middlwares[1].Paths = []string{"/admin/*"} },
+			wantFields: []string{"middlwares[1].paths[0]"},
+		},
+		{
+			name:       "middleware without paths",
+			modify:     func(m *Metadata) { m.Middlwares[1].Paths = nil },
+			wantFields: []string{"middlwares[1].paths"},
+		},
+		{
+			name: "params",
+			modify: func(m *Metadata) {
+				m.Routes[1].Params = []Param{
+					{Name: "id", In: InPath, Type: TypeInt},
+					{Name: "limit", In: InQuery, Type: TypeInt, Default: "10"},
+					{Name: "X-Request-Id", In: InHeader, Type: TypeUUID, Required: true},
+					{Name: "since", In: InQuery, Type: TypeTime, Default: "2019-05-01T00:00:00Z"},
+				}
+			},
+		},
+		{
+			name: "bodies",
+			modify: func(m *Metadata) {
+				m.Routes[1].Request = &Body{Fields: []Field{
+					{Name: "email", Required: true},
+					{Name: "tags", Type: "[]string"},
+					{Name: "score", Type: TypeFloat},
+				}}
+				m.Routes[1].Response = &Body{Type: "[]User"}
+			},
+		},
+		{
+			name: "invalid bodies",
+			modify: func(m *Metadata) {
+				m.Routes[0].Request = &Body{}
+				m.Routes[0].Response = &Body{Type: "User", Fields: []Field{{Name: "id"}}}
+				m.Routes[1].Request = &Body{Type: "[]user"}
+				m.Routes[1].Response = &Body{Fields: []Field{
+					{Name: "id", Type: "[]decimal"},
+					{Name: "id"},
+					{Name: "ID"},
+					{},
+				}}
+			},
+			wantFields: []string{
+				"routes[0].request",
+				"routes[0].response.type",
+				"routes[1].request.type",
+				"routes[1].response.fields[0].type",
+				"routes[1].response.fields[1].name",
+				"routes[1].response.fields[2].name",
+				"routes[1].response.fields[3].name",
+			},
+		},
+		{
+			name: "invalid params",
+			modify: func(m *Metadata) {
+				m.Routes[1].Params = []Param{
+					{Name: "name", In: InPath},
+					{Name: "id", In: "body"},
+					{Name: "limit", In: InQuery, Type: "float"},
+					{Name: "id", In: InPath, Default: "1"},
+					{Name: "verbose", In: InQuery, Type: TypeBool, Default: "maybe"},
+					{Name: "page", In: InQuery, Required: true, Default: "1"},
+					{Name: "x-request-id", In: InHeader},
+					{Name: "X-Request-ID", In: InHeader},
+					{Name: "x_request_id", In: InQuery},
+					{Name: "42", In: InQuery},
+				}
+			},
+			wantFields: []string{
+				"routes[1].params[0].name",
+				"routes[1].params[1].in",
+				"routes[1].params[2].type",
+				"routes[1].params[3].default",
+				"routes[1].params[3].name",
+				"routes[1].params[4].default",
+				"routes[1].params[5].default",
+				"routes[1].params[7].name",
+				"routes[1].params[8].name",
+				"routes[1].params[9].name",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validMetadata()
+			tt.modify(&m)
+
+			err := m.Validate()
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestBase_isValid(t *testing.T) {
+	m := Base(Info{Name: "test"})
+
+	assert.NoError(t, m.Validate())
+}
+
+func TestParseValid(t *testing.T) {
+	src := `info:
+  name: test
+routes:
+- path: /
+  httpmethods:
+  - GET
+  handlername: Index
+- path: users
+  httpmethods: [GET, FETCH]
+  handlername: users
+middlwares:
+- handlername: LoggerMw
+  paths:
+  - "*"
+  - /admin
+`
+
+	const path = "test.yml"
+
+	_, err := ParseValid(path, []byte(src))
+
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	expected := ValidationErrors{
+		{File: path, Line: 8, Column: 3, Field: "routes[1].path", Msg: `path "users" should start with a slash`},
+		{File: path, Line: 9, Column: 3, Field: "routes[1].httpmethods[1]", Msg: `"FETCH" is not a standard HTTP method`},
+		{File: path, Line: 10, Column: 3, Field: "routes[1].handlername", Msg: `"users" is not an exported Go identifier`},
+		{File: path, Line: 15, Column: 5, Field: "middlwares[0].paths[1]", Msg: `"/admin" does not match any route`},
+	}
+
+	assert.Equal(t, expected, errs)
+	assert.Equal(t, path+`:8:3: routes[1].path: path "users" should start with a slash`, errs[0].Error())
+}
+
+func TestPositions(t *testing.T) {
+	src := `# comment
+info:
+  name: test
+routes:
+  - info:
+      name: ""
+    path: /
+    httpmethods:
+    - GET
+  -   path: /users
+      handlername: Users
+`
+
+	expected := map[string]Position{
+		"info":                     {Line: 2, Column: 1},
+		"info.name":                {Line: 3, Column: 3},
+		"routes":                   {Line: 4, Column: 1},
+		"routes[0]":                {Line: 5, Column: 5},
+		"routes[0].info":           {Line: 5, Column: 5},
+		"routes[0].info.name":      {Line: 6, Column: 7},
+		"routes[0].path":           {Line: 7, Column: 5},
+		"routes[0].httpmethods":    {Line: 8, Column: 5},
+		"routes[0].httpmethods[0]": {Line: 9, Column: 7},
+		"routes[1]":                {Line: 10, Column: 7},
+		"routes[1].path":           {Line: 10, Column: 7},
+		"routes[1].handlername":    {Line: 11, Column: 7},
+	}
+
+	assert.Equal(t, expected, Positions([]byte(src)))
+}
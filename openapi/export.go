@@ -0,0 +1,296 @@
+package openapi
+
+import (
+	"fmt"
+	"seed/metadata"
+	"strconv"
+	"strings"
+)
+
+// DefaultVersion is the version of the API documented by FromMetadata, as
+// service descriptors do not carry one.
+const DefaultVersion = "1.0.0"
+
+const (
+	jsonMediaType = "application/json"
+
+	// errorSchema is the name of the component describing the body of the
+	// error responses written by the generated code.
+	errorSchema = "Error"
+)
+
+// FromMetadata returns the OpenAPI document describing the routes of the
+// service descriptor. Path variables are documented as path parameters even
+// if the route does not declare them, and bodies declared as fields are
+// documented as schema components named after the generated structs, e.g.
+// CreateUserRequest. Bodies declared as Go types of the service package are
+// documented as objects, as their fields are not known. The operation of each
+// method of a route is identified by the name of its client call, e.g.
+// DeleteUserPost for its second method. Routes served on CONNECT are left
+// out, as OpenAPI cannot describe them. The descriptor is expected to be
+// valid.
+func FromMetadata(md metadata.Metadata) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       md.Name,
+			Description: joinNonEmpty("\n\n", md.Summary, md.Description),
+			Version:     DefaultVersion,
+		},
+		Paths:      make(map[string]*PathItem),
+		Components: &Components{Schemas: make(map[string]*Schema)},
+	}
+
+	for _, r := range md.Routes {
+		vars, _ := metadata.PathVars(r.Path)
+
+		path := templatePath(r.Path, vars)
+
+		item, ok := d.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			d.Paths[path] = item
+		}
+
+		for _, call := range r.ClientCalls() {
+			op := item.Operation(call.HttpMethod)
+			if op == nil {
+				continue
+			}
+
+			*op = d.operation(r, call, vars)
+		}
+	}
+
+	if len(d.Components.Schemas) == 0 {
+		d.Components = nil
+	}
+
+	return d
+}
+
+// operation returns the operation describing the route on the method of the
+// call, identified by the name of the call, as in the generated clients.
+func (d *Document) operation(r metadata.Route, call metadata.ClientCall, vars []metadata.PathVar) *Operation {
+	op := &Operation{
+		OperationID: call.Name,
+		Summary:     r.Summary,
+		Description: r.Description,
+		Responses:   make(map[string]*Response),
+	}
+
+	declared := make(map[string]bool)
+
+	for _, v := range vars {
+		p := &Parameter{Name: v.Name, In: metadata.InPath, Required: true}
+
+		for _, param := range r.Params {
+			if param.In == metadata.InPath && param.Name == v.Name {
+				p.Schema = paramSchema(param)
+				declared[param.Name] = true
+			}
+		}
+
+		if p.Schema == nil {
+			p.Schema = &Schema{Type: "string"}
+		}
+
+		// Patterns only apply to strings, typed variables are already
+		// constrained by their type.
+		if v.Pattern != "" && p.Schema.Type == "string" && p.Schema.Format == "" {
+			p.Schema.Pattern = "^" + v.Pattern + "$"
+		}
+
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	for _, param := range r.Params {
+		if param.In == metadata.InPath {
+			continue
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     param.Name,
+			In:       param.In,
+			Required: param.IsRequired(),
+			Schema:   paramSchema(param),
+		})
+	}
+
+	if r.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(d.bodySchema(r, r.Request, "Request", true)),
+		}
+	}
+
+	switch {
+	case r.Response != nil:
+		op.Responses["200"] = &Response{
+			Description: "OK",
+			Content:     jsonContent(d.bodySchema(r, r.Response, "Response", false)),
+		}
+	case r.IsTyped():
+		op.Responses["204"] = &Response{Description: "No Content"}
+	default:
+		op.Responses["default"] = &Response{Description: fmt.Sprintf("Response of the %s handler.", r.HandlerName)}
+	}
+
+	if len(r.Params) > 0 || r.Request != nil {
+		op.Responses["400"] = d.errorResponse("Bad Request")
+	}
+
+	if r.Request != nil {
+		op.Responses["415"] = d.errorResponse("Unsupported Media Type")
+	}
+
+	if r.IsTyped() {
+		op.Responses["500"] = d.errorResponse("Internal Server Error")
+	}
+
+	return op
+}
+
+// bodySchema adds the component describing the body to the document, and
+// returns a schema referencing it.
+func (d *Document) bodySchema(r metadata.Route, b *metadata.Body, suffix string, request bool) *Schema {
+	if b.Type != "" {
+		elem, list := b.ElemType()
+
+		d.Components.Schemas[elem] = &Schema{
+			Type:        "object",
+			Description: fmt.Sprintf("Declared by the Go type %s of the service package.", elem),
+		}
+
+		if list {
+			return &Schema{Type: "array", Items: ref(elem)}
+		}
+
+		return ref(elem)
+	}
+
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, f := range b.Fields {
+		s.Properties[f.Name] = fieldSchema(f)
+
+		if request && f.Required {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+
+	name := r.HandlerName + suffix
+	d.Components.Schemas[name] = s
+
+	return ref(name)
+}
+
+// errorResponse adds the component describing the body of the error
+// responses to the document, and returns a response referencing it.
+func (d *Document) errorResponse(description string) *Response {
+	d.Components.Schemas[errorSchema] = &Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*Schema{
+			"error": {
+				Type:     "object",
+				Required: []string{"message"},
+				Properties: map[string]*Schema{
+					"param":   {Type: "string", Description: "Name of the parameter or field at fault, if any."},
+					"in":      {Type: "string", Description: `Where the parameter is found: "path", "query", "header" or "body".`},
+					"message": {Type: "string", Description: "Explains what is wrong."},
+				},
+			},
+		},
+	}
+
+	return &Response{Description: description, Content: jsonContent(ref(errorSchema))}
+}
+
+// paramSchema returns the schema of the parameter.
+func paramSchema(p metadata.Param) *Schema {
+	s := typeSchema(p.ParamType())
+
+	if p.Default != "" {
+		s.Default = defaultValue(p.ParamType(), p.Default)
+	}
+
+	return s
+}
+
+// fieldSchema returns the schema of the field of a body.
+func fieldSchema(f metadata.Field) *Schema {
+	elem, list := f.ElemType()
+	if list {
+		return &Schema{Type: "array", Items: typeSchema(elem)}
+	}
+
+	return typeSchema(elem)
+}
+
+// typeSchema returns the schema of a parameter or field type.
+func typeSchema(t string) *Schema {
+	switch t {
+	case metadata.TypeInt:
+		return &Schema{Type: "integer"}
+	case metadata.TypeFloat:
+		return &Schema{Type: "number"}
+	case metadata.TypeBool:
+		return &Schema{Type: "boolean"}
+	case metadata.TypeTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	case metadata.TypeUUID:
+		return &Schema{Type: "string", Format: "uuid"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// defaultValue converts the default of a parameter to its type, so that it is
+// not documented as a string.
+func defaultValue(t, value string) interface{} {
+	switch t {
+	case metadata.TypeInt:
+		if v, err := strconv.Atoi(value); err == nil {
+			return v
+		}
+	case metadata.TypeBool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+
+	return value
+}
+
+// templatePath returns the path with its gorilla/mux variables stripped of
+// their patterns, e.g. "/users/{id}" for "/users/{id:[0-9]+}".
+func templatePath(path string, vars []metadata.PathVar) string {
+	for _, v := range vars {
+		if v.Pattern != "" {
+			path = strings.Replace(path, "{"+v.Name+":"+v.Pattern+"}", "{"+v.Name+"}", 1)
+		}
+	}
+
+	return path
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{jsonMediaType: {Schema: s}}
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var nonEmpty []string
+
+	for _, v := range values {
+		if v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+
+	return strings.Join(nonEmpty, sep)
+}
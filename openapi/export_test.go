@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"seed/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportMetadata() metadata.Metadata {
+	return metadata.Metadata{
+		Info: metadata.Info{Name: "users", Summary: "Manages users.", Description: "Users belong to teams."},
+		Routes: []metadata.Route{
+			{
+				Info:        metadata.Info{Summary: "Responds to GET requests on the root URI"},
+				Path:        "/",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "Index",
+			},
+			{
+				Path:        "/teams/{team:[a-z]+}/users",
+				HttpMethods: []string{http.MethodPost},
+				HandlerName: "CreateUser",
+				Request: &metadata.Body{Fields: []metadata.Field{
+					{Name: "email", Required: true},
+					{Name: "tags", Type: "[]string"},
+				}},
+				Response: &metadata.Body{Type: "User"},
+			},
+			{
+				Path:        "/teams/{team}/users",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "Users",
+				Params: []metadata.Param{
+					{Name: "limit", In: metadata.InQuery, Type: metadata.TypeInt, Default: "10"},
+					{Name: "X-Request-Id", In: metadata.InHeader, Type: metadata.TypeUUID, Required: true},
+				},
+				Response: &metadata.Body{Type: "[]User"},
+			},
+			{
+				Info:        metadata.Info{Summary: "Reads or renames a team"},
+				Path:        "/teams/{team}",
+				HttpMethods: []string{http.MethodGet, http.MethodPost},
+				HandlerName: "Team",
+				Response:    &metadata.Body{Fields: []metadata.Field{{Name: "name"}}},
+			},
+			{
+				Path:        "/users/{id:[0-9]+}",
+				HttpMethods: []string{http.MethodDelete, http.MethodConnect},
+				HandlerName: "DeleteUser",
+				Params: []metadata.Param{
+					{Name: "id", In: metadata.InPath, Type: metadata.TypeInt},
+				},
+				Request: &metadata.Body{Fields: []metadata.Field{{Name: "reason"}}},
+			},
+		},
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	md := exportMetadata()
+
+	assert.NoError(t, md.Validate())
+
+	actual, err := FromMetadata(md).Marshal(FormatYAML)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	expected, err := ioutil.ReadFile(filepath.Join("..", "testdata", "openapi.expected"))
+	if err != nil {
+		t.Fatalf("reading expected file: %v", err)
+	}
+
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestDocument_Marshal(t *testing.T) {
+	d := FromMetadata(exportMetadata())
+
+	b, err := d.Marshal(FormatJSON)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	var decoded Document
+
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatalf("decoding JSON document: %v", err)
+	}
+
+	assert.Equal(t, "DeleteUser", decoded.Paths["/users/{id}"].Delete.OperationID)
+	assert.Equal(t, "integer", decoded.Paths["/users/{id}"].Delete.Parameters[0].Schema.Type)
+	assert.Equal(t, "Team", decoded.Paths["/teams/{team}"].Get.OperationID)
+	assert.Equal(t, "TeamPost", decoded.Paths["/teams/{team}"].Post.OperationID)
+
+	_, err = d.Marshal("xml")
+	assert.Error(t, err)
+}
+
+func TestFromMetadata_methods(t *testing.T) {
+	d := FromMetadata(exportMetadata())
+
+	team := d.Paths["/teams/{team}"]
+
+	assert.Equal(t, "Team", team.Get.OperationID)
+	assert.Equal(t, "TeamPost", team.Post.OperationID)
+	assert.False(t, team.Get == team.Post, "each method should have its own operation")
+
+	team.Post.Summary = "Renames a team"
+	assert.Equal(t, "Reads or renames a team", team.Get.Summary)
+}
+
+func TestFromMetadata_noComponents(t *testing.T) {
+	d := FromMetadata(metadata.Base(metadata.Info{Name: "test"}))
+
+	assert.Nil(t, d.Components)
+	assert.Equal(t, "Index", d.Paths["/"].Get.OperationID)
+}
@@ -0,0 +1,516 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"seed/metadata"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-yaml/yaml"
+)
+
+const (
+	schemasRef    = "#/components/schemas/"
+	parametersRef = "#/components/parameters/"
+)
+
+// methods are the HTTP methods a path item can hold operations for, in the
+// order routes are imported.
+var methods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodTrace,
+}
+
+// Warning describes a construct of an OpenAPI document that could not be
+// imported as is into a service descriptor.
+type Warning struct {
+	// Field is the path of the construct in the document, e.g.
+	// "paths./users.get.parameters[1]".
+	Field string
+
+	// Msg explains what was done with the construct.
+	Msg string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Field, w.Msg)
+}
+
+// Parse decodes an OpenAPI 3 document, written either in YAML or in JSON.
+func Parse(src []byte) (*Document, error) {
+	var d Document
+
+	err := yaml.Unmarshal(src, &d)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing OpenAPI document: %v", err)
+	}
+
+	if !strings.HasPrefix(d.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, expected 3.x", d.OpenAPI)
+	}
+
+	return &d, nil
+}
+
+// ToMetadata returns the service descriptor serving the operations of the
+// document. Each operation becomes a route handled by the method named after
+// its operationId, and its path, query and header parameters as well as its
+// JSON request body and successful JSON response are imported when their
+// schemas can be described by the descriptor. Bodies referencing a schema
+// component which can not be described as fields reference the Go type of
+// the service package named after the component, which TypesFile declares.
+// Everything else is left out, and reported as a Warning.
+func ToMetadata(d *Document) (metadata.Metadata, []Warning) {
+	imp := importer{doc: d, handlers: make(map[string]bool)}
+
+	md := metadata.Metadata{
+		Info: metadata.Info{Description: d.Info.Description},
+	}
+
+	name, err := metadata.SanitizePackageName(d.Info.Title)
+	if err != nil {
+		imp.warn("info.title", "%v, a project name should be specified", err)
+	}
+
+	md.Name = name
+
+	if len(d.Security) > 0 {
+		imp.warn("security", "security requirements are not supported, they should be implemented as middlewares")
+	}
+
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := d.Paths[path]
+		if item == nil {
+			continue
+		}
+
+		for _, method := range methods {
+			op := *item.Operation(method)
+			if op == nil {
+				continue
+			}
+
+			field := fmt.Sprintf("paths.%s.%s", path, strings.ToLower(method))
+
+			md.Routes = append(md.Routes, imp.route(field, path, method, item, op))
+		}
+	}
+
+	return md, imp.warnings
+}
+
+// importer holds the state of the conversion of a document.
+type importer struct {
+	doc      *Document
+	warnings []Warning
+
+	// handlers holds the handler names already given to routes.
+	handlers map[string]bool
+}
+
+// warn adds a warning, unless it was already given, as path item parameters
+// are imported once per operation.
+func (imp *importer) warn(field, format string, args ...interface{}) {
+	w := Warning{Field: field, Msg: fmt.Sprintf(format, args...)}
+
+	for _, other := range imp.warnings {
+		if other == w {
+			return
+		}
+	}
+
+	imp.warnings = append(imp.warnings, w)
+}
+
+// route returns the route serving the operation.
+func (imp *importer) route(field, path, method string, item *PathItem, op *Operation) metadata.Route {
+	r := metadata.Route{
+		Info: metadata.Info{
+			Summary:     op.Summary,
+			Description: op.Description,
+		},
+		Path:        path,
+		HttpMethods: []string{method},
+		HandlerName: imp.handlerName(field, path, method, op.OperationID),
+	}
+
+	if len(op.Security) > 0 {
+		imp.warn(field+".security", "security requirements are not supported, they should be implemented as middlewares")
+	}
+
+	for _, p := range imp.parameters(field, item, op) {
+		param, ok := imp.parameter(p.field, p.Parameter)
+		if !ok {
+			continue
+		}
+
+		r.Params = append(r.Params, param)
+
+		if param.In == metadata.InPath && p.Schema != nil && p.Schema.Pattern != "" {
+			pattern := strings.TrimSuffix(strings.TrimPrefix(p.Schema.Pattern, "^"), "$")
+			r.Path = strings.Replace(r.Path, "{"+param.Name+"}", "{"+param.Name+":"+pattern+"}", 1)
+		}
+	}
+
+	if op.RequestBody != nil {
+		r.Request = imp.requestBody(field+".requestBody", op.RequestBody)
+	}
+
+	r.Response = imp.responseBody(field+".responses", op.Responses)
+
+	return r
+}
+
+// handlerName returns the name of the handler of the operation, derived from
+// its operationId, or from its method and path if it has none. Names are made
+// unique by numbering them.
+func (imp *importer) handlerName(field, path, method, operationID string) string {
+	name := metadata.GoName(operationID)
+
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = metadata.GoName(strings.ToLower(method) + " " + path)
+
+		if operationID == "" {
+			imp.warn(field, "the operation has no operationId, its handler is named %s", name)
+		} else {
+			imp.warn(field+".operationId", "%q does not make a Go identifier, the handler is named %s", operationID, name)
+		}
+	}
+
+	unique := name
+	for i := 2; imp.handlers[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	if unique != name {
+		imp.warn(field+".operationId", "handler %s is already used, the handler is named %s", name, unique)
+	}
+
+	imp.handlers[unique] = true
+
+	return unique
+}
+
+// fieldParameter is a parameter along with its path in the document.
+type fieldParameter struct {
+	*Parameter
+	field string
+}
+
+// parameters returns the parameters of the operation, including those of its
+// path item it does not override, with references resolved.
+func (imp *importer) parameters(field string, item *PathItem, op *Operation) []fieldParameter {
+	var (
+		params []fieldParameter
+		seen   = make(map[string]bool)
+	)
+
+	add := func(field string, list []*Parameter) {
+		for i, p := range list {
+			paramField := fmt.Sprintf("%s.parameters[%d]", field, i)
+
+			if p == nil {
+				imp.warn(paramField, "the parameter is empty, it is left out")
+				continue
+			}
+
+			p = imp.resolveParameter(paramField, p)
+			if p == nil || seen[p.In+" "+p.Name] {
+				continue
+			}
+
+			seen[p.In+" "+p.Name] = true
+			params = append(params, fieldParameter{Parameter: p, field: paramField})
+		}
+	}
+
+	add(field, op.Parameters)
+	add(field[:strings.LastIndex(field, ".")], item.Parameters)
+
+	return params
+}
+
+// resolveParameter returns the parameter, or the component it references.
+func (imp *importer) resolveParameter(field string, p *Parameter) *Parameter {
+	if p.Ref == "" {
+		return p
+	}
+
+	name := strings.TrimPrefix(p.Ref, parametersRef)
+
+	if imp.doc.Components != nil && name != p.Ref {
+		if resolved := imp.doc.Components.Parameters[name]; resolved != nil {
+			return resolved
+		}
+	}
+
+	imp.warn(field, "reference %q can not be resolved, the parameter is left out", p.Ref)
+
+	return nil
+}
+
+// parameter converts the parameter, reporting whether it is supported.
+func (imp *importer) parameter(field string, p *Parameter) (metadata.Param, bool) {
+	param := metadata.Param{Name: p.Name, In: p.In}
+
+	switch p.In {
+	case metadata.InPath:
+	case metadata.InQuery, metadata.InHeader:
+		param.Required = p.Required
+	default:
+		imp.warn(field+".in", "%s parameters are not supported, %q is left out", p.In, p.Name)
+		return param, false
+	}
+
+	if p.Schema == nil {
+		return param, true
+	}
+
+	t, ok := scalarType(p.Schema)
+	if !ok || t == metadata.TypeFloat {
+		imp.warn(field+".schema", "%s is not supported for parameters, %q is imported as a string", schemaName(p.Schema), p.Name)
+		t = metadata.TypeString
+	}
+
+	if t != metadata.TypeString {
+		param.Type = t
+	}
+
+	if p.Schema.Default != nil && !param.Required && p.In != metadata.InPath {
+		param.Default = fmt.Sprint(p.Schema.Default)
+	}
+
+	return param, true
+}
+
+// requestBody converts the JSON body of the requests.
+func (imp *importer) requestBody(field string, rb *RequestBody) *metadata.Body {
+	if rb.Ref != "" {
+		imp.warn(field, "request body references are not supported, the body is left out")
+		return nil
+	}
+
+	s, ok := imp.jsonSchema(field+".content", rb.Content)
+	if !ok {
+		return nil
+	}
+
+	return imp.body(field+".content.application/json.schema", s, true)
+}
+
+// responseBody converts the JSON body of the first successful response
+// declaring one. Error responses are left to the generated code.
+func (imp *importer) responseBody(field string, responses map[string]*Response) *metadata.Body {
+	var codes []string
+
+	for code, resp := range responses {
+		if strings.HasPrefix(code, "2") && resp != nil && len(resp.Content) > 0 {
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes[1:] {
+		imp.warn(field+"."+code, "only one successful response body is supported, the body of %s is used", codes[0])
+	}
+
+	field += "." + codes[0]
+
+	s, ok := imp.jsonSchema(field+".content", responses[codes[0]].Content)
+	if !ok {
+		return nil
+	}
+
+	return imp.body(field+".content.application/json.schema", s, false)
+}
+
+// jsonSchema returns the schema of the JSON media type of the content.
+func (imp *importer) jsonSchema(field string, content map[string]MediaType) (*Schema, bool) {
+	var others []string
+
+	for mediaType := range content {
+		if mediaType != jsonMediaType {
+			others = append(others, mediaType)
+		}
+	}
+
+	sort.Strings(others)
+
+	mt, ok := content[jsonMediaType]
+
+	switch {
+	case !ok:
+		imp.warn(field, "only %s bodies are supported, %s is left out", jsonMediaType, strings.Join(others, ", "))
+		return nil, false
+	case len(others) > 0:
+		imp.warn(field, "only %s bodies are supported, %s is ignored", jsonMediaType, strings.Join(others, ", "))
+	}
+
+	if mt.Schema == nil {
+		imp.warn(field+"."+jsonMediaType, "the body has no schema, it is left out")
+		return nil, false
+	}
+
+	return mt.Schema, true
+}
+
+// body converts the schema of a body, either to fields if it is an object
+// holding scalars or lists of scalars, or to the Go type of the service
+// package named after the component it references.
+func (imp *importer) body(field string, s *Schema, request bool) *metadata.Body {
+	if s.Ref != "" {
+		name, component := imp.component(field, s.Ref)
+		if component == nil {
+			return nil
+		}
+
+		if fields, ok := bodyFields(component, request); ok {
+			return &metadata.Body{Fields: fields}
+		}
+
+		if len(component.Properties) > 0 {
+			imp.warn(field, "the fields of %s can not be described in the descriptor, "+
+				"it is declared as a Go type of the service package instead", name)
+		}
+
+		return &metadata.Body{Type: name}
+	}
+
+	if s.Type == "array" && s.Items != nil && s.Items.Ref != "" {
+		name, component := imp.component(field+".items", s.Items.Ref)
+		if component == nil {
+			return nil
+		}
+
+		return &metadata.Body{Type: "[]" + name}
+	}
+
+	if fields, ok := bodyFields(s, request); ok {
+		return &metadata.Body{Fields: fields}
+	}
+
+	imp.warn(field, "%s bodies are only supported as components, the body is left out", schemaName(s))
+
+	return nil
+}
+
+// component returns the Go type name of the schema component referenced, and
+// the component itself.
+func (imp *importer) component(field, ref string) (string, *Schema) {
+	name := strings.TrimPrefix(ref, schemasRef)
+
+	if imp.doc.Components != nil && name != ref {
+		if s := imp.doc.Components.Schemas[name]; s != nil {
+			return metadata.GoName(name), s
+		}
+	}
+
+	imp.warn(field, "reference %q can not be resolved, the body is left out", ref)
+
+	return "", nil
+}
+
+// bodyFields returns the fields of an object schema, reporting whether every
+// property holds a scalar or a list of scalars. Properties without a schema
+// hold neither.
+func bodyFields(s *Schema, request bool) ([]metadata.Field, bool) {
+	if s.Type != "object" || len(s.Properties) == 0 {
+		return nil, false
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var fields []metadata.Field
+
+	for _, name := range names {
+		prop, prefix := s.Properties[name], ""
+		if prop == nil {
+			return nil, false
+		}
+
+		if prop.Type == "array" && prop.Items != nil {
+			prop, prefix = prop.Items, "[]"
+		}
+
+		t, ok := scalarType(prop)
+		if !ok {
+			return nil, false
+		}
+
+		f := metadata.Field{Name: name}
+		if t != metadata.TypeString || prefix != "" {
+			f.Type = prefix + t
+		}
+
+		if request {
+			for _, required := range s.Required {
+				f.Required = f.Required || required == name
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, true
+}
+
+// scalarType returns the descriptor type of a scalar schema, reporting
+// whether it has one.
+func scalarType(s *Schema) (string, bool) {
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "uuid":
+			return metadata.TypeUUID, true
+		case "date-time":
+			return metadata.TypeTime, true
+		default:
+			return metadata.TypeString, true
+		}
+	case "integer":
+		return metadata.TypeInt, true
+	case "number":
+		return metadata.TypeFloat, true
+	case "boolean":
+		return metadata.TypeBool, true
+	default:
+		return "", false
+	}
+}
+
+// schemaName describes the schema in warnings.
+func schemaName(s *Schema) string {
+	switch {
+	case s.Ref != "":
+		return fmt.Sprintf("reference %q", s.Ref)
+	case s.Type == "":
+		return "an untyped schema"
+	default:
+		return fmt.Sprintf("type %q", s.Type)
+	}
+}
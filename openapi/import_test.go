@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"seed/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMetadata(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("..", "testdata", "import.yml"))
+	if err != nil {
+		t.Fatalf("reading document: %v", err)
+	}
+
+	d, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	md, warnings := ToMetadata(d)
+
+	expected := metadata.Metadata{
+		Info: metadata.Info{Name: "petstore", Description: "Sells pets."},
+		Routes: []metadata.Route{
+			{
+				Info:        metadata.Info{Summary: "Lists the pets"},
+				Path:        "/pets",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "ListPets",
+				Params: []metadata.Param{
+					{Name: "limit", In: metadata.InQuery, Type: metadata.TypeInt, Default: "20"},
+					{Name: "min_price", In: metadata.InQuery},
+					{Name: "X-Request-Id", In: metadata.InHeader, Type: metadata.TypeUUID, Required: true},
+				},
+				Response: &metadata.Body{Type: "[]Pet"},
+			},
+			{
+				Path:        "/pets",
+				HttpMethods: []string{http.MethodPost},
+				HandlerName: "CreatePet",
+				Request: &metadata.Body{Fields: []metadata.Field{
+					{Name: "name", Required: true},
+					{Name: "tags", Type: "[]string"},
+					{Name: "weight", Type: metadata.TypeFloat},
+				}},
+				Response: &metadata.Body{Type: "Pet"},
+			},
+			{
+				Info:        metadata.Info{Description: "Returns a single pet."},
+				Path:        "/pets/{petId:[0-9]+}",
+				HttpMethods: []string{http.MethodGet},
+				HandlerName: "GetPetsPetId",
+				Params: []metadata.Param{
+					{Name: "petId", In: metadata.InPath, Type: metadata.TypeInt},
+				},
+				Response: &metadata.Body{Fields: []metadata.Field{
+					{Name: "born", Type: metadata.TypeTime},
+					{Name: "name"},
+				}},
+			},
+			{
+				Path:        "/pets/{petId:[0-9]+}",
+				HttpMethods: []string{http.MethodDelete},
+				HandlerName: "ListPets2",
+				Params: []metadata.Param{
+					{Name: "petId", In: metadata.InPath, Type: metadata.TypeInt},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, md)
+	assert.NoError(t, md.Validate())
+
+	var actual []string
+	for _, w := range warnings {
+		actual = append(actual, w.String())
+	}
+
+	assert.Equal(t, []string{
+		`security: security requirements are not supported, they should be implemented as middlewares`,
+		`paths./pets.get.parameters[1].schema: type "number" is not supported for parameters, "min_price" is imported as a string`,
+		`paths./pets.post.requestBody.content: only application/json bodies are supported, application/xml is ignored`,
+		`paths./pets.post.responses.202: only one successful response body is supported, the body of 201 is used`,
+		`paths./pets.post.responses.201.content.application/json.schema: ` +
+			`the fields of Pet can not be described in the descriptor, it is declared as a Go type of the service package instead`,
+		`paths./pets/{petId}.get: the operation has no operationId, its handler is named GetPetsPetId`,
+		`paths./pets/{petId}.parameters[1].in: cookie parameters are not supported, "session" is left out`,
+		`paths./pets/{petId}.delete.operationId: handler ListPets is already used, the handler is named ListPets2`,
+		`paths./pets/{petId}.delete.security: security requirements are not supported, they should be implemented as middlewares`,
+		`paths./pets/{petId}.delete.requestBody.content: only application/json bodies are supported, text/plain is left out`,
+	}, actual)
+}
+
+func TestToMetadata_roundTrip(t *testing.T) {
+	md := exportMetadata()
+
+	src, err := FromMetadata(md).Marshal(FormatJSON)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	d, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	imported, warnings := ToMetadata(d)
+
+	assert.Empty(t, warnings)
+	assert.NoError(t, imported.Validate())
+
+	for _, r := range md.Routes {
+		for _, i := range imported.Routes {
+			if i.HandlerName != r.HandlerName {
+				continue
+			}
+
+			assert.Equal(t, r.Request, i.Request, r.HandlerName)
+			assert.Equal(t, r.Response, i.Response, r.HandlerName)
+		}
+	}
+}
+
+func TestToMetadata_emptyEntries(t *testing.T) {
+	src := `
+openapi: 3.0.3
+info:
+  title: empty
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      -
+      - $ref: '#/components/parameters/Limit'
+      responses:
+        "200":
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+components:
+  parameters:
+    Limit:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+        age:
+          type: integer
+`
+
+	d, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	md, warnings := ToMetadata(d)
+
+	if assert.Len(t, md.Routes, 2) {
+		assert.Empty(t, md.Routes[0].Params)
+		assert.Nil(t, md.Routes[0].Response)
+		assert.Equal(t, &metadata.Body{Type: "Pet"}, md.Routes[1].Request)
+		assert.Nil(t, md.Routes[1].Response)
+	}
+
+	var actual []string
+	for _, w := range warnings {
+		actual = append(actual, w.String())
+	}
+
+	assert.Equal(t, []string{
+		`paths./pets.get.parameters[0]: the parameter is empty, it is left out`,
+		`paths./pets.get.parameters[1]: reference "#/components/parameters/Limit" can not be resolved, the parameter is left out`,
+		`paths./pets.post.requestBody.content.application/json.schema: the fields of Pet can not be described in the descriptor, it is declared as a Go type of the service package instead`,
+		`paths./pets.post.responses.201.content.application/json.schema: type "object" bodies are only supported as components, the body is left out`,
+	}, actual)
+
+	types, err := d.TypesFile(md)
+	assert.NoError(t, err)
+	assert.Contains(t, string(types), "Name interface{} `json:\"name\"`")
+}
+
+func TestParse_version(t *testing.T) {
+	_, err := Parse([]byte(`swagger: "2.0"`))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`{"openapi": "3.0.0", "info": {"title": "json"}, "paths": {}}`))
+	assert.NoError(t, err)
+}
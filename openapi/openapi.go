@@ -0,0 +1,155 @@
+// Package openapi holds the subset of the OpenAPI 3.0 specification seed
+// understands, and converts service descriptors to OpenAPI documents.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-yaml/yaml"
+)
+
+// Version is the version of the OpenAPI specification documents are written
+// in.
+const Version = "3.0.3"
+
+// The formats a document can be marshaled to.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Document is the root object of an OpenAPI document.
+type Document struct {
+	OpenAPI    string                `yaml:"openapi" json:"openapi"`
+	Info       Info                  `yaml:"info" json:"info"`
+	Paths      map[string]*PathItem  `yaml:"paths" json:"paths"`
+	Components *Components           `yaml:"components,omitempty" json:"components,omitempty"`
+	Security   []map[string][]string `yaml:"security,omitempty" json:"security,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Version     string `yaml:"version" json:"version"`
+}
+
+// PathItem holds the operations served on a path, one per HTTP method.
+type PathItem struct {
+	Summary     string       `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string       `yaml:"description,omitempty" json:"description,omitempty"`
+	Get         *Operation   `yaml:"get,omitempty" json:"get,omitempty"`
+	Put         *Operation   `yaml:"put,omitempty" json:"put,omitempty"`
+	Post        *Operation   `yaml:"post,omitempty" json:"post,omitempty"`
+	Delete      *Operation   `yaml:"delete,omitempty" json:"delete,omitempty"`
+	Options     *Operation   `yaml:"options,omitempty" json:"options,omitempty"`
+	Head        *Operation   `yaml:"head,omitempty" json:"head,omitempty"`
+	Patch       *Operation   `yaml:"patch,omitempty" json:"patch,omitempty"`
+	Trace       *Operation   `yaml:"trace,omitempty" json:"trace,omitempty"`
+	Parameters  []*Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// Operation returns a pointer to the operation of the path item served on the
+// HTTP method, or nil if OpenAPI does not support the method.
+func (p *PathItem) Operation(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	default:
+		return nil
+	}
+}
+
+// Operation describes a single operation of the API.
+type Operation struct {
+	OperationID string                `yaml:"operationId,omitempty" json:"operationId,omitempty"`
+	Summary     string                `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string                `yaml:"description,omitempty" json:"description,omitempty"`
+	Parameters  []*Parameter          `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBody *RequestBody          `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `yaml:"responses" json:"responses"`
+	Security    []map[string][]string `yaml:"security,omitempty" json:"security,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter of an operation.
+type Parameter struct {
+	Ref         string  `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Name        string  `yaml:"name,omitempty" json:"name,omitempty"`
+	In          string  `yaml:"in,omitempty" json:"in,omitempty"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Schema      *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of the requests of an operation.
+type RequestBody struct {
+	Ref         string               `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Description string               `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool                 `yaml:"required,omitempty" json:"required,omitempty"`
+	Content     map[string]MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string               `yaml:"description" json:"description"`
+	Content     map[string]MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body in a specific media type.
+type MediaType struct {
+	Schema *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+// Components holds the reusable objects of the document.
+type Components struct {
+	Schemas    map[string]*Schema    `yaml:"schemas,omitempty" json:"schemas,omitempty"`
+	Parameters map[string]*Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// Schema describes the type of a value. Schemas referencing a component only
+// set Ref, e.g. "#/components/schemas/User".
+type Schema struct {
+	Ref         string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Type        string             `yaml:"type,omitempty" json:"type,omitempty"`
+	Format      string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Pattern     string             `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Description string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Items       *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	Properties  map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required    []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     interface{}        `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// Marshal encodes the document in the format, either FormatYAML or
+// FormatJSON.
+func (d *Document) Marshal(format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(d)
+	case FormatJSON:
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(b, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected %s or %s", format, FormatYAML, FormatJSON)
+	}
+}
@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"seed/metadata"
+	"sort"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+)
+
+// TypesFile generates the Go types of the service package which the bodies of
+// md reference, declared from the schema components of the document they are
+// named after, along with the components those reference in turn. Objects
+// become structs whose fields are named after their properties, and fields
+// referencing a component are pointers, so components may reference
+// themselves. It returns no contents if no body references a Go type.
+func (d *Document) TypesFile(md metadata.Metadata) ([]byte, error) {
+	t := typeDecls{components: make(map[string]string), decls: make(map[string]*Statement)}
+
+	if d.Components != nil {
+		names := make([]string, 0, len(d.Components.Schemas))
+		for name := range d.Components.Schemas {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			goName := metadata.GoName(name)
+			if _, ok := t.components[goName]; !ok {
+				t.components[goName] = name
+			}
+		}
+	}
+
+	for _, r := range md.Routes {
+		for _, b := range []*metadata.Body{r.Request, r.Response} {
+			if b != nil && b.Type != "" {
+				t.pending = append(t.pending, strings.TrimPrefix(b.Type, "[]"))
+			}
+		}
+	}
+
+	if len(t.pending) == 0 {
+		return nil, nil
+	}
+
+	for len(t.pending) > 0 {
+		goName := t.pending[0]
+		t.pending = t.pending[1:]
+
+		if _, ok := t.decls[goName]; ok {
+			continue
+		}
+
+		name, ok := t.components[goName]
+		if !ok || d.Components.Schemas[name] == nil {
+			return nil, fmt.Errorf("type %s does not match any schema component", goName)
+		}
+
+		t.decls[goName] = Commentf("// %s is declared by the %q schema component.", goName, name).Line().
+			Type().Id(goName).Add(t.goType(d.Components.Schemas[name]))
+	}
+
+	names := make([]string, 0, len(t.decls))
+	for name := range t.decls {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	f := NewFile(md.PackageName())
+
+	for _, name := range names {
+		f.Add(t.decls[name])
+		f.Line()
+	}
+
+	var buf bytes.Buffer
+
+	err := f.Render(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("rendering file: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// typeDecls holds the state of the declaration of the Go types of components.
+type typeDecls struct {
+	// components maps Go type names to the names of the components.
+	components map[string]string
+
+	// decls holds the declarations of the types, by name.
+	decls map[string]*Statement
+
+	// pending holds the names of the types left to declare.
+	pending []string
+}
+
+// component returns the Go type name of the component referenced, reporting
+// whether the reference can be resolved.
+func (t *typeDecls) component(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, schemasRef) {
+		return "", false
+	}
+
+	goName := metadata.GoName(strings.TrimPrefix(ref, schemasRef))
+	_, ok := t.components[goName]
+
+	return goName, ok
+}
+
+// goType returns the Go type of a schema, queuing the components it
+// references for declaration. Schemas which can not be described, such as
+// unresolved references, are left untyped.
+func (t *typeDecls) goType(s *Schema) *Statement {
+	if s.Ref != "" {
+		goName, ok := t.component(s.Ref)
+		if !ok {
+			return Interface()
+		}
+
+		t.pending = append(t.pending, goName)
+
+		return Id(goName)
+	}
+
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			return Qual("time", "Time")
+		}
+
+		return String()
+	case "integer":
+		return Int()
+	case "number":
+		return Float64()
+	case "boolean":
+		return Bool()
+	case "array":
+		if s.Items == nil {
+			return Index().Interface()
+		}
+
+		return Index().Add(t.goType(s.Items))
+	case "object":
+		if len(s.Properties) == 0 {
+			return Map(String()).Interface()
+		}
+
+		return t.goStruct(s)
+	default:
+		return Interface()
+	}
+}
+
+// goStruct returns the struct of an object schema, with a field per
+// property.
+func (t *typeDecls) goStruct(s *Schema) *Statement {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fields := make([]Code, 0, len(names))
+
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop == nil {
+			prop = &Schema{}
+		}
+
+		field := Id(metadata.GoName(name))
+		if _, ok := t.component(prop.Ref); ok {
+			field.Op("*")
+		}
+
+		fields = append(fields, field.Add(t.goType(prop)).Tag(map[string]string{"json": name}))
+	}
+
+	// The fields are built eagerly, so the components they reference are
+	// queued before the file is rendered.
+	return Struct(fields...)
+}
@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"seed/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_TypesFile(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("..", "testdata", "import.yml"))
+	if err != nil {
+		t.Fatalf("reading document: %v", err)
+	}
+
+	d, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	md, _ := ToMetadata(d)
+
+	actual, err := d.TypesFile(md)
+	if err != nil {
+		t.Fatalf("TypesFile() failed = %v", err)
+	}
+
+	expected, err := ioutil.ReadFile(filepath.Join("..", "testdata", "import_types.expected"))
+	if err != nil {
+		t.Fatalf("reading expected file: %v", err)
+	}
+
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestDocument_TypesFile_references(t *testing.T) {
+	d := &Document{
+		Components: &Components{
+			Schemas: map[string]*Schema{
+				"node": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"parent":   {Ref: schemasRef + "node"},
+						"children": {Type: "array", Items: &Schema{Ref: schemasRef + "node"}},
+						"label":    {Ref: schemasRef + "label"},
+						"extra":    {Ref: schemasRef + "missing"},
+					},
+				},
+				"label":  {Type: "string"},
+				"unused": {Type: "integer"},
+			},
+		},
+	}
+
+	md := metadata.Metadata{
+		Info:   metadata.Info{Name: "tree"},
+		Routes: []metadata.Route{{Response: &metadata.Body{Type: "[]Node"}}},
+	}
+
+	b, err := d.TypesFile(md)
+	if err != nil {
+		t.Fatalf("TypesFile() failed = %v", err)
+	}
+
+	actual := string(b)
+
+	assert.Contains(t, actual, "package tree")
+	assert.Contains(t, actual, "type Label string")
+	assert.Contains(t, actual, "Parent   *Node       `json:\"parent\"`")
+	assert.Contains(t, actual, "Children []Node      `json:\"children\"`")
+	assert.Contains(t, actual, "Label    *Label      `json:\"label\"`")
+	assert.Contains(t, actual, "Extra    interface{} `json:\"extra\"`")
+	assert.NotContains(t, actual, "Unused")
+}
+
+func TestDocument_TypesFile_none(t *testing.T) {
+	d := &Document{Components: &Components{Schemas: map[string]*Schema{"pet": {Type: "object"}}}}
+
+	md := metadata.Metadata{
+		Info:   metadata.Info{Name: "pets"},
+		Routes: []metadata.Route{{Response: &metadata.Body{Fields: []metadata.Field{{Name: "name"}}}}},
+	}
+
+	b, err := d.TypesFile(md)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+
+	md.Routes[0].Response = &metadata.Body{Type: "Cat"}
+
+	_, err = d.TypesFile(md)
+	assert.EqualError(t, err, "type Cat does not match any schema component")
+}
@@ -3,80 +3,558 @@ package seed
 import (
 	"fmt"
 	"go/format"
-	"io/ioutil"
+	"go/parser"
+	"go/token"
+	"io"
 	"os"
 	"path/filepath"
 	"seed/consts"
+	"seed/diff"
 	"seed/files"
 	"seed/generate"
+	"seed/metadata"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 const (
-	initFailed = "init failed: %v"
+	initFailed  = "init failed: %v"
+	regenFailed = "regenerate failed: %v"
 )
 
-func InitProject(projectName string) error {
-	err := generate.ProjectStructure(projectName)
+// task generates the contents of a single file and saves it to the given
+// path, relative to the project's folder.
+type task struct {
+	exec   func(metadata.Metadata) ([]byte, error)
+	saveTo string
+}
+
+// InitOptions configure how InitProjectWith generates a project, and how it
+// treats the files of an existing project.
+type InitOptions struct {
+	// Force allows overwriting the files of an existing project.
+	Force bool
+
+	// DryRun writes a unified diff of what each file of the project would
+	// become to Out, without changing anything on disk.
+	DryRun bool
+
+	// Out receives the diff of a dry run. Defaults to os.Stdout.
+	Out io.Writer
+
+	// Module is the Go module path of the project, e.g.
+	// "github.com/org/service". Defaults to the module of Descriptor, then
+	// to the module of the go.mod file already in the project's folder, and
+	// to the project name otherwise.
+	Module string
+
+	// Descriptor is the service descriptor the project is generated from,
+	// e.g. one imported from an OpenAPI document. Its name is replaced by
+	// the project's name, and it must be valid. Defaults to
+	// generate.DefaultDescriptor.
+	Descriptor *metadata.Metadata
+
+	// Files generates additional files of the project from its final
+	// descriptor, by path relative to the project's folder, e.g. the Go
+	// types of the service package an imported descriptor references. A
+	// function returning no contents creates no file.
+	Files map[string]func(metadata.Metadata) ([]byte, error)
+}
+
+// ConflictError is returned when initializing a project would overwrite
+// existing files.
+type ConflictError struct {
+	// Files are the paths of the existing files.
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%d files already exist, use force to overwrite them: %s",
+		len(e.Files), strings.Join(e.Files, ", "))
+}
+
+// InitProject creates a new project in a folder of the root called
+// projectName. The project is generated into a hidden staging folder next to
+// it first, and only moved into place once every file was generated
+// successfully. On failure, the staging folder is removed, so no
+// half-initialized project is left behind.
+//
+// If projectName is CurrentDir, the project is created in the root folder
+// itself and named after it. This is refused if the folder already holds Go
+// files of another package than the project's.
+//
+// If the folder already holds a go.mod file, its module path is kept, and
+// asking for another one is refused even with opts.Force.
+//
+// If the folder already exists, a *ConflictError listing the files that
+// would be overwritten is returned, unless opts.Force is set.
+func (g *Generator) InitProject(projectName string, opts InitOptions) error {
+	p, err := g.project(projectName)
 	if err != nil {
 		return fmt.Errorf(initFailed, err)
 	}
 
-	err = generate.ServiceDescriptor(projectName)
+	md := generate.DefaultDescriptor(p.name)
+	if opts.Descriptor != nil {
+		md = *opts.Descriptor
+		md.Name = p.name
+	}
+
+	if opts.Module != "" {
+		md.Module = opts.Module
+	}
+
+	existing, err := g.existingModule(p.dir)
 	if err != nil {
 		return fmt.Errorf(initFailed, err)
 	}
 
-	tasks := []struct {
-		exec   func(string) ([]byte, error)
-		saveTo string
-	}{
+	if existing != "" {
+		if md.Module != "" && md.Module != existing {
+			return fmt.Errorf(initFailed, fmt.Errorf("folder already holds module %s, "+
+				"which conflicts with module %s", existing, md.Module))
+		}
+
+		md.Module = existing
+	}
+
+	if md.Module == "" {
+		md.Module = p.name
+	}
+
+	if opts.Descriptor != nil {
+		err = md.Validate()
+		if err != nil {
+			return fmt.Errorf(initFailed, err)
+		}
+	}
+
+	if p.inPlace {
+		err = g.checkPackage(p.dir, md.PackageName())
+		if err != nil {
+			return fmt.Errorf(initFailed, err)
+		}
+	}
+
+	staging, err := g.FS.TempDir(g.Root, "."+p.name+"-")
+	if err != nil {
+		return fmt.Errorf(initFailed, fmt.Errorf("creating staging folder: %v", err))
+	}
+	defer g.FS.RemoveAll(staging)
+
+	err = g.initProject(staging, md, opts.Files)
+	if err != nil {
+		return fmt.Errorf(initFailed, err)
+	}
+
+	generated, err := g.listFiles(staging)
+	if err != nil {
+		return fmt.Errorf(initFailed, err)
+	}
+
+	if opts.DryRun {
+		out := opts.Out
+		if out == nil {
+			out = os.Stdout
+		}
+
+		return g.printDiffs(out, staging, p.dir, generated)
+	}
+
+	var conflicts []string
+
+	for _, rel := range generated {
+		_, err := g.FS.Stat(filepath.Join(p.dir, rel))
+		if err == nil {
+			conflicts = append(conflicts, p.rel(rel))
+		}
+	}
+
+	if len(conflicts) > 0 && !opts.Force {
+		return &ConflictError{Files: conflicts}
+	}
+
+	err = g.commit(staging, p.dir, generated)
+	if err != nil {
+		return fmt.Errorf(initFailed, fmt.Errorf("moving project into place: %v", err))
+	}
+
+	return nil
+}
+
+// existingModule returns the module path declared by the go.mod file of dir,
+// or an empty string if there is none.
+func (g *Generator) existingModule(dir string) (string, error) {
+	src, err := g.FS.ReadFile(filepath.Join(dir, "go.mod"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(src), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+
+		module := fields[1]
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+
+		return module, nil
+	}
+
+	return "", fmt.Errorf("go.mod of the folder does not declare a module")
+}
+
+// checkPackage makes sure that the Go files already in dir belong to the
+// package called name, or to its external test package.
+func (g *Generator) checkPackage(dir, name string) error {
+	return g.FS.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != dir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		src, err := g.FS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), path, src, parser.PackageClauseOnly)
+		if err != nil {
+			return fmt.Errorf("folder holds an invalid Go file: %v", err)
+		}
+
+		pkg := f.Name.Name
+		if pkg != name && pkg != name+"_test" {
+			return fmt.Errorf("folder already holds package %s in %s, "+
+				"which conflicts with package %s", pkg, info.Name(), name)
+		}
+
+		return nil
+	})
+}
+
+// commit moves the generated files from the staging folder to the target.
+// If the target does not exist yet, the staging folder itself is renamed,
+// which is atomic. Otherwise the files are moved one by one, the existing
+// ones being backed up in the staging folder first. If a file can not be
+// moved, the files already moved are restored from their backups, or removed
+// if they did not exist before.
+func (g *Generator) commit(staging, target string, generated []string) error {
+	_, err := g.FS.Stat(target)
+	if os.IsNotExist(err) {
+		return g.FS.Rename(staging, target)
+	}
+
+	backup, err := g.FS.TempDir(staging, ".backup-")
+	if err != nil {
+		return err
+	}
+
+	var moved []string
+	backedUp := make(map[string]bool)
+
+	rollback := func(err error) error {
+		for i := len(moved) - 1; i >= 0; i-- {
+			rel := moved[i]
+			dest := filepath.Join(target, rel)
+
+			if backedUp[rel] {
+				g.FS.Rename(filepath.Join(backup, rel), dest)
+			} else {
+				g.FS.RemoveAll(dest)
+			}
+		}
+
+		return err
+	}
+
+	for _, rel := range generated {
+		dest := filepath.Join(target, rel)
+
+		err := g.FS.MkdirAll(filepath.Dir(dest), files.DefaultPerm)
+		if err != nil {
+			return rollback(err)
+		}
+
+		_, err = g.FS.Stat(dest)
+		if err == nil {
+			saved := filepath.Join(backup, rel)
+
+			err = g.FS.MkdirAll(filepath.Dir(saved), files.DefaultPerm)
+			if err != nil {
+				return rollback(err)
+			}
+
+			err = g.FS.Rename(dest, saved)
+			if err != nil {
+				return rollback(err)
+			}
+
+			backedUp[rel] = true
+		}
+
+		moved = append(moved, rel)
+
+		err = g.FS.Rename(filepath.Join(staging, rel), dest)
+		if err != nil {
+			return rollback(err)
+		}
+	}
+
+	return nil
+}
+
+// printDiffs writes the unified diff between each existing file of the
+// target and its generated counterpart in the staging folder.
+func (g *Generator) printDiffs(out io.Writer, staging, target string, generated []string) error {
+	for _, rel := range generated {
+		contents, err := g.FS.ReadFile(filepath.Join(staging, rel))
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(target, rel)
+		oldName := path
+
+		existing, err := g.FS.ReadFile(path)
+		if os.IsNotExist(err) {
+			oldName = os.DevNull
+		} else if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(out, diff.Unified(oldName, path, existing, contents))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listFiles returns the paths of the files in dir and its subfolders,
+// relative to dir.
+func (g *Generator) listFiles(dir string) ([]string, error) {
+	var result []string
+
+	err := g.FS.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, rel)
+
+		return nil
+	})
+
+	return result, err
+}
+
+// initProject generates every file of the project described by desc into
+// dir, along with the additional files.
+func (g *Generator) initProject(dir string, desc metadata.Metadata, files map[string]func(metadata.Metadata) ([]byte, error)) error {
+	err := generate.ProjectStructure(g.FS, dir)
+	if err != nil {
+		return err
+	}
+
+	err = generate.ServiceDescriptor(g.FS, dir, desc)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, desc.Name+".yml")
+
+	src, err := g.FS.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading descriptor: %v", err)
+	}
+
+	md, err := metadata.ParseValid(path, src)
+	if err != nil {
+		return err
+	}
+
+	tasks := initTasks(desc.Name)
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		tasks = append(tasks, task{exec: files[path], saveTo: path})
+	}
+
+	err = g.runTasks(dir, md, tasks)
+	if err != nil {
+		return err
+	}
+
+	return g.formatFiles(dir)
+}
+
+// initTasks returns the tasks that generate the files of a new project.
+var initTasks = func(projectName string) []task {
+	tasks := []task{
 		{
 			exec:   generate.ServiceFile,
-			saveTo: filepath.Join(files.Pwd, projectName, projectName+".go"),
+			saveTo: projectName + ".go",
+		},
+		{
+			exec:   generate.MainFile,
+			saveTo: filepath.Join(consts.CmdFolder, consts.MainFile),
+		},
+		{
+			exec:   generate.GoModule,
+			saveTo: "go.mod",
 		},
+		{
+			exec:   generate.GoSum,
+			saveTo: "go.sum",
+		},
+	}
+
+	return append(tasks, genTasks()...)
+}
+
+// Regenerate re-renders the contents of the gen folder of an existing project
+// based on its service descriptor. Files owned by the user, such as the
+// service implementation and the executable, are left untouched. Nothing is
+// written if the descriptor is not valid. The files are generated into a
+// staging folder first, as in InitProject, so the previous ones are kept if
+// generating or moving any of them fails.
+func (g *Generator) Regenerate(projectName string) error {
+	p, err := g.project(projectName)
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	return g.regenerate(p)
+}
+
+func (g *Generator) regenerate(p project) error {
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	staging, err := g.FS.TempDir(g.Root, "."+p.name+"-")
+	if err != nil {
+		return fmt.Errorf(regenFailed, fmt.Errorf("creating staging folder: %v", err))
+	}
+	defer g.FS.RemoveAll(staging)
+
+	err = g.runTasks(staging, md, genTasks())
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	err = g.formatFiles(staging)
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	generated, err := g.listFiles(staging)
+	if err != nil {
+		return fmt.Errorf(regenFailed, err)
+	}
+
+	err = g.commit(staging, p.dir, generated)
+	if err != nil {
+		return fmt.Errorf(regenFailed, fmt.Errorf("moving files into place: %v", err))
+	}
+
+	return nil
+}
+
+// genTasks returns the tasks that generate the contents of the gen folder.
+func genTasks() []task {
+	return []task{
 		{
 			exec:   generate.InterfaceFile,
-			saveTo: filepath.Join(files.Pwd, projectName, consts.GenFolder, consts.InterfaceFile),
+			saveTo: filepath.Join(consts.GenFolder, consts.InterfaceFile),
 		},
 		{
 			exec:   generate.BootstrapFile,
-			saveTo: filepath.Join(files.Pwd, projectName, consts.GenFolder, consts.BootstrapFile),
+			saveTo: filepath.Join(consts.GenFolder, consts.BootstrapFile),
 		},
 		{
-			exec:   generate.MainFile,
-			saveTo: filepath.Join(files.Pwd, projectName, consts.CmdFolder, consts.MainFile),
+			exec:   generate.ConfigFile,
+			saveTo: filepath.Join(consts.GenFolder, consts.ConfigFile),
 		},
 		{
-			exec:   generate.GoModule,
-			saveTo: filepath.Join(files.Pwd, projectName, "go.mod"),
+			exec:   generate.ParamsFile,
+			saveTo: filepath.Join(consts.GenFolder, consts.ApiFolder, consts.ParamsFile),
+		},
+		{
+			exec:   generate.TypesFile,
+			saveTo: filepath.Join(consts.GenFolder, consts.ApiFolder, consts.TypesFile),
+		},
+		{
+			exec:   generate.ClientFile,
+			saveTo: filepath.Join(consts.ClientFolder, consts.ClientFile),
 		},
 	}
+}
 
+// runTasks executes the tasks, saving the files they generate in dir. Missing
+// folders are created, so files added to the gen folder by newer versions
+// of seed are generated for existing projects too. Tasks generating no
+// contents save no file.
+func (g *Generator) runTasks(dir string, md metadata.Metadata, tasks []task) error {
 	for _, task := range tasks {
-		contents, err := task.exec(projectName)
+		contents, err := task.exec(md)
 		if err != nil {
-			return fmt.Errorf(initFailed, err)
+			return err
+		}
+
+		if contents == nil {
+			continue
 		}
 
-		err = ioutil.WriteFile(task.saveTo, contents, files.DefaultPerm)
+		err = g.FS.MkdirAll(filepath.Dir(filepath.Join(dir, task.saveTo)), files.DefaultPerm)
 		if err != nil {
-			return fmt.Errorf(initFailed, err)
+			return err
 		}
-	}
 
-	err = formatFiles(projectName)
-	if err != nil {
-		return fmt.Errorf(initFailed, err)
+		err = g.FS.WriteFile(filepath.Join(dir, task.saveTo), contents, files.DefaultPerm)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func formatFiles(projectName string) error {
-	project := filepath.Join(files.Pwd, projectName)
-
-	err := filepath.Walk(project, formatFile)
+// formatFiles formats every Go source file in dir and its subfolders.
+func (g *Generator) formatFiles(dir string) error {
+	err := g.FS.Walk(dir, g.formatFile)
 	if err != nil {
 		return fmt.Errorf("source format: %v", err)
 	}
@@ -84,7 +562,7 @@ func formatFiles(projectName string) error {
 	return nil
 }
 
-func formatFile(path string, info os.FileInfo, err error) error {
+func (g *Generator) formatFile(path string, info os.FileInfo, err error) error {
 	if err != nil {
 		return err
 	}
@@ -93,7 +571,7 @@ func formatFile(path string, info os.FileInfo, err error) error {
 		return nil
 	}
 
-	contents, err := ioutil.ReadFile(path)
+	contents, err := g.FS.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed reading file %s: %v",
 			path, err)
@@ -105,7 +583,7 @@ func formatFile(path string, info os.FileInfo, err error) error {
 			path, err)
 	}
 
-	err = ioutil.WriteFile(path, contents, files.DefaultPerm)
+	err = g.FS.WriteFile(path, contents, files.DefaultPerm)
 	if err != nil {
 		return fmt.Errorf("failed writing file %s: %v",
 			path, err)
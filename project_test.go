@@ -2,16 +2,23 @@ package seed
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"html/template"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"seed/consts"
 	"seed/files"
 	"seed/metadata"
 	"strings"
+	"syscall"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/go-yaml/yaml"
 
@@ -20,8 +27,15 @@ import (
 
 const name = "example2"
 
+var (
+	// root is the folder the test projects are generated into.
+	root string
+
+	testGen *Generator
+)
+
 func TestMain(m *testing.M) {
-	//setup()
+	setup()
 	code := m.Run()
 	teardown()
 	os.Exit(code)
@@ -30,31 +44,31 @@ func TestMain(m *testing.M) {
 // TestInitProject___doInit needs to be the first, alphabetically. This was the
 // cleanest way I could find to do an initialization within the test framework.
 func TestInitProject___doInit(t *testing.T) {
-	err := InitProject(name)
+	err := testGen.InitProject(name, InitOptions{})
 	if err != nil {
 		t.Errorf("InitProject(%q) failed = %v", name, err)
 	}
 }
 
 func TestInitProject_foldersAreCreated(t *testing.T) {
-	err := checkIfFolderExists(name)
+	err := checkIfFolderExists(filepath.Join(root, name))
 	if err != nil {
 		t.Errorf("project: %v", err)
 	}
 
-	err = checkIfFolderExists(filepath.Join(name, consts.CmdFolder))
+	err = checkIfFolderExists(filepath.Join(root, name, consts.CmdFolder))
 	if err != nil {
 		t.Errorf("project/%s: %v", consts.CmdFolder, err)
 	}
 
-	err = checkIfFolderExists(filepath.Join(name, consts.GenFolder))
+	err = checkIfFolderExists(filepath.Join(root, name, consts.GenFolder))
 	if err != nil {
 		t.Errorf("project/%s: %v", consts.GenFolder, err)
 	}
 }
 
 func TestInitProject_executableFile(t *testing.T) {
-	mainPath := filepath.Join(files.Pwd, name, consts.CmdFolder, consts.MainFile)
+	mainPath := filepath.Join(root, name, consts.CmdFolder, consts.MainFile)
 
 	f, err := os.Stat(mainPath)
 	if err != nil {
@@ -73,7 +87,7 @@ func TestInitProject_executableFile(t *testing.T) {
 }
 
 func TestInitProject_executableContents(t *testing.T) {
-	path := filepath.Join(files.Pwd, name, consts.CmdFolder, consts.MainFile)
+	path := filepath.Join(root, name, consts.CmdFolder, consts.MainFile)
 
 	actual, err := readFile(path)
 	if err != nil {
@@ -89,7 +103,7 @@ func TestInitProject_executableContents(t *testing.T) {
 }
 
 func TestInitProject_bootstrapFile(t *testing.T) {
-	bootstrapFile := filepath.Join(files.Pwd, name, consts.GenFolder, consts.BootstrapFile)
+	bootstrapFile := filepath.Join(root, name, consts.GenFolder, consts.BootstrapFile)
 
 	f, err := os.Stat(bootstrapFile)
 	if err != nil {
@@ -108,7 +122,7 @@ func TestInitProject_bootstrapFile(t *testing.T) {
 }
 
 func TestInitProject_bootstrapContents(t *testing.T) {
-	path := filepath.Join(files.Pwd, name, consts.GenFolder, consts.BootstrapFile)
+	path := filepath.Join(root, name, consts.GenFolder, consts.BootstrapFile)
 
 	actual, err := readFile(path)
 	if err != nil {
@@ -125,7 +139,7 @@ func TestInitProject_bootstrapContents(t *testing.T) {
 
 func TestInitProject_projectDescriptor(t *testing.T) {
 	filename := fmt.Sprintf("%s.yml", name)
-	d := filepath.Join(files.Pwd, name, filename)
+	d := filepath.Join(root, name, filename)
 
 	f, err := os.Stat(d)
 	if err != nil {
@@ -146,7 +160,7 @@ func TestInitProject_projectDescriptor(t *testing.T) {
 
 func TestInitProject_projectDescriptorContents(t *testing.T) {
 	filename := fmt.Sprintf("%s.yml", name)
-	d := filepath.Join(files.Pwd, name, filename)
+	d := filepath.Join(root, name, filename)
 
 	b, err := ioutil.ReadFile(d)
 	if err != nil {
@@ -166,12 +180,13 @@ func TestInitProject_projectDescriptorContents(t *testing.T) {
 	}
 
 	expected := metadata.Base(info)
+	expected.Module = name
 
 	assert.Equal(t, expected, sd)
 }
 
 func TestInitProject_goModFile(t *testing.T) {
-	d := filepath.Join(files.Pwd, name, "go.mod")
+	d := filepath.Join(root, name, "go.mod")
 
 	f, err := os.Stat(d)
 	if err != nil {
@@ -190,7 +205,7 @@ func TestInitProject_goModFile(t *testing.T) {
 	}
 }
 func TestInitProject_goModFileContents(t *testing.T) {
-	path := filepath.Join(files.Pwd, name, "go.mod")
+	path := filepath.Join(root, name, "go.mod")
 
 	actual, err := readFile(path)
 	if err != nil {
@@ -206,7 +221,7 @@ func TestInitProject_goModFileContents(t *testing.T) {
 }
 
 func TestInitProject_serviceFile(t *testing.T) {
-	serviceFile := filepath.Join(files.Pwd, name, name+".go")
+	serviceFile := filepath.Join(root, name, name+".go")
 
 	f, err := os.Stat(serviceFile)
 	if err != nil {
@@ -224,7 +239,7 @@ func TestInitProject_serviceFile(t *testing.T) {
 }
 
 func TestInitProject_serviceFileContents(t *testing.T) {
-	path := filepath.Join(files.Pwd, name, name+".go")
+	path := filepath.Join(root, name, name+".go")
 
 	actual, err := readFile(path)
 	if err != nil {
@@ -240,7 +255,7 @@ func TestInitProject_serviceFileContents(t *testing.T) {
 }
 
 func TestInitProject_interfaceFile(t *testing.T) {
-	interfaceFile := filepath.Join(files.Pwd, name, consts.GenFolder, consts.InterfaceFile)
+	interfaceFile := filepath.Join(root, name, consts.GenFolder, consts.InterfaceFile)
 
 	f, err := os.Stat(interfaceFile)
 	if err != nil {
@@ -258,7 +273,7 @@ func TestInitProject_interfaceFile(t *testing.T) {
 }
 
 func TestInitProject_interfaceContents(t *testing.T) {
-	path := filepath.Join(files.Pwd, name, consts.GenFolder, consts.InterfaceFile)
+	path := filepath.Join(root, name, consts.GenFolder, consts.InterfaceFile)
 
 	actual, err := readFile(path)
 	if err != nil {
@@ -273,6 +288,270 @@ func TestInitProject_interfaceContents(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestInitProject_paramsContents(t *testing.T) {
+	path := filepath.Join(root, name, consts.GenFolder, consts.ApiFolder, consts.ParamsFile)
+
+	actual, err := readFile(path)
+	if err != nil {
+		t.Errorf("reading result file for %q: %v", consts.ParamsFile, err)
+	}
+
+	expected, err := parseExpected("params.expected", name)
+	if err != nil {
+		t.Errorf("parsing expected file: %v", err)
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestInitProject_typesContents(t *testing.T) {
+	path := filepath.Join(root, name, consts.GenFolder, consts.ApiFolder, consts.TypesFile)
+
+	actual, err := readFile(path)
+	if err != nil {
+		t.Errorf("reading result file for %q: %v", consts.TypesFile, err)
+	}
+
+	expected, err := parseExpected("types.expected", name)
+	if err != nil {
+		t.Errorf("parsing expected file: %v", err)
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestInitProject_clientContents(t *testing.T) {
+	path := filepath.Join(root, name, consts.ClientFolder, consts.ClientFile)
+
+	actual, err := readFile(path)
+	if err != nil {
+		t.Errorf("reading result file for %q: %v", consts.ClientFile, err)
+	}
+
+	expected, err := parseExpected("client.expected", name)
+	if err != nil {
+		t.Errorf("parsing expected file: %v", err)
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestInitProject_configContents(t *testing.T) {
+	path := filepath.Join(root, name, consts.GenFolder, consts.ConfigFile)
+
+	actual, err := readFile(path)
+	if err != nil {
+		t.Errorf("reading result file for %q: %v", consts.ConfigFile, err)
+	}
+
+	expected, err := parseExpected("config.expected", name)
+	if err != nil {
+		t.Errorf("parsing expected file: %v", err)
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestInitProject_rollback(t *testing.T) {
+	const rollbackName = "rollbacktest"
+
+	original := initTasks
+	defer func() { initTasks = original }()
+
+	initTasks = func(projectName string) []task {
+		failing := task{
+			exec: func(metadata.Metadata) ([]byte, error) {
+				return nil, fmt.Errorf("generation failed")
+			},
+			saveTo: "failing.go",
+		}
+
+		return append(original(projectName), failing)
+	}
+
+	err := testGen.InitProject(rollbackName, InitOptions{})
+	if err == nil {
+		os.RemoveAll(filepath.Join(root, rollbackName))
+		t.Fatalf("InitProject(%q) should have failed", rollbackName)
+	}
+
+	assertNoLeftovers(t, rollbackName, false)
+}
+
+func TestInitProject_invalidName(t *testing.T) {
+	const invalidName = "invalid-name"
+
+	err := testGen.InitProject(invalidName, InitOptions{})
+	if err == nil {
+		os.RemoveAll(filepath.Join(root, invalidName))
+		t.Fatalf("InitProject(%q) should have failed", invalidName)
+	}
+
+	assertNoLeftovers(t, invalidName, false)
+}
+
+func TestInitProject_existing(t *testing.T) {
+	const existingName = "existingtest"
+
+	dir := filepath.Join(root, existingName)
+
+	err := os.MkdirAll(dir, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating project folder: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	userCode := []byte("package existingtest\n")
+	servicePath := filepath.Join(dir, existingName+".go")
+
+	err = ioutil.WriteFile(servicePath, userCode, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("writing service file: %v", err)
+	}
+
+	err = testGen.InitProject(existingName, InitOptions{})
+
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected a *ConflictError, got %v", err)
+	}
+
+	assert.Equal(t, []string{filepath.Join(existingName, existingName+".go")}, conflict.Files)
+	assertUnchanged(t, servicePath, userCode)
+
+	var out bytes.Buffer
+
+	err = testGen.InitProject(existingName, InitOptions{DryRun: true, Out: &out})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	assert.Contains(t, out.String(), "--- "+servicePath+"\n+++ "+servicePath+"\n")
+	assert.Contains(t, out.String(), "+type Server struct{}\n")
+	assert.Contains(t, out.String(), "--- "+os.DevNull+"\n+++ "+filepath.Join(dir, "go.mod")+"\n")
+	assertUnchanged(t, servicePath, userCode)
+
+	_, err = os.Stat(filepath.Join(dir, "go.mod"))
+	assert.True(t, os.IsNotExist(err), "dry run should not write anything")
+
+	err = testGen.InitProject(existingName, InitOptions{Force: true})
+	if err != nil {
+		t.Fatalf("forced init failed: %v", err)
+	}
+
+	service, err := readFile(servicePath)
+	if err != nil {
+		t.Fatalf("reading service file: %v", err)
+	}
+
+	assert.Contains(t, service, "type Server struct{}")
+
+	err = checkIfFolderExists(filepath.Join(dir, consts.GenFolder))
+	assert.NoError(t, err)
+
+	assertNoLeftovers(t, existingName, true)
+}
+
+func assertUnchanged(t *testing.T, path string, expected []byte) {
+	actual, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+
+	assert.Equal(t, string(expected), string(actual), "%s should be left untouched", path)
+}
+
+// assertNoLeftovers checks that the staging folder of the project does not
+// exist, nor does the project itself unless it is expected to.
+func assertNoLeftovers(t *testing.T, projectName string, projectExists bool) {
+	_, err := os.Stat(filepath.Join(root, projectName))
+	if !projectExists {
+		assert.True(t, os.IsNotExist(err), "project folder should not exist")
+	}
+
+	staging, err := filepath.Glob(filepath.Join(root, "."+projectName+"-*"))
+	if err != nil {
+		t.Fatalf("looking for staging folders: %v", err)
+	}
+
+	assert.Empty(t, staging, "staging folder should be removed")
+}
+
+func TestRegenerate(t *testing.T) {
+	const regenName = "regentest"
+
+	err := testGen.InitProject(regenName, InitOptions{})
+	if err != nil {
+		t.Fatalf("InitProject(%q) failed = %v", regenName, err)
+	}
+	defer os.RemoveAll(filepath.Join(root, regenName))
+
+	servicePath := filepath.Join(root, regenName, regenName+".go")
+	userCode := []byte("package regentest\n\n// user owned code\n")
+
+	err = ioutil.WriteFile(servicePath, userCode, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("overwriting service file: %v", err)
+	}
+
+	descriptor := filepath.Join(root, regenName, regenName+".yml")
+
+	src, err := ioutil.ReadFile(descriptor)
+	if err != nil {
+		t.Fatalf("reading descriptor: %v", err)
+	}
+
+	md, err := metadata.Parse(src)
+	if err != nil {
+		t.Fatalf("parsing descriptor: %v", err)
+	}
+
+	err = md.AddRoute(metadata.Route{
+		Path:        "/users",
+		HttpMethods: []string{"POST"},
+		HandlerName: "CreateUser",
+	})
+	if err != nil {
+		t.Fatalf("adding route: %v", err)
+	}
+
+	src, err = md.Marshal()
+	if err != nil {
+		t.Fatalf("encoding descriptor: %v", err)
+	}
+
+	err = ioutil.WriteFile(descriptor, src, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("saving descriptor: %v", err)
+	}
+
+	err = testGen.Regenerate(regenName)
+	if err != nil {
+		t.Fatalf("Regenerate(%q) failed = %v", regenName, err)
+	}
+
+	iface, err := readFile(filepath.Join(root, regenName, consts.GenFolder, consts.InterfaceFile))
+	if err != nil {
+		t.Fatalf("reading interface: %v", err)
+	}
+
+	assert.Contains(t, iface, "CreateUser() http.HandlerFunc")
+
+	bootstrap, err := readFile(filepath.Join(root, regenName, consts.GenFolder, consts.BootstrapFile))
+	if err != nil {
+		t.Fatalf("reading bootstrap: %v", err)
+	}
+
+	assert.Contains(t, bootstrap, "s.serviceImpl.CreateUser()")
+
+	service, err := readFile(servicePath)
+	if err != nil {
+		t.Fatalf("reading service file: %v", err)
+	}
+
+	assert.Equal(t, string(userCode), service)
+}
+
 func checkFileIsCorrect(f os.FileInfo) error {
 	fileMode := f.Mode()
 	if fileMode.IsDir() {
@@ -305,33 +584,26 @@ func checkIfFolderExists(path string) error {
 	return nil
 }
 
-func teardown() {
-	dirName, err := getDirName(name)
-	if err != nil {
-		panic(fmt.Sprintf("teardown failed: %v", err))
-	}
+func setup() {
+	var err error
 
-	err = os.RemoveAll(dirName)
+	root, err = ioutil.TempDir("", "seed-test-")
 	if err != nil {
-		panic(fmt.Sprintf("failed removing all: %v", err))
+		panic(fmt.Sprintf("setup failed: %v", err))
 	}
-}
 
-func getDirName(name string) (string, error) {
-	if name != "." {
-		return name, nil
-	}
+	testGen = NewGenerator(root)
+}
 
-	pwd, err := os.Getwd()
+func teardown() {
+	err := os.RemoveAll(root)
 	if err != nil {
-		return "", fmt.Errorf("failed project setup: %v", err)
+		panic(fmt.Sprintf("failed removing all: %v", err))
 	}
-
-	return filepath.Base(pwd), nil
 }
 
 func parseExpected(filename, projectName string) (string, error) {
-	path := filepath.Join(files.Pwd, "testdata", filename)
+	path := filepath.Join("testdata", filename)
 
 	tmpl, err := template.ParseFiles(path)
 	if err != nil {
@@ -366,3 +638,513 @@ func readFile(path string) (string, error) {
 
 	return strings.ReplaceAll(string(b), "\r\n", "\n"), nil
 }
+
+// failingRename is a files.FS failing the first time something is renamed to
+// dest.
+type failingRename struct {
+	files.FS
+
+	dest   string
+	failed bool
+}
+
+func (fs *failingRename) Rename(oldpath, newpath string) error {
+	if newpath == fs.dest && !fs.failed {
+		fs.failed = true
+		return fmt.Errorf("rename %s: disk full", newpath)
+	}
+
+	return fs.FS.Rename(oldpath, newpath)
+}
+
+func TestRegenerate_rollback(t *testing.T) {
+	const projectName = "rollbackregen"
+
+	g := &Generator{Root: "/projects", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	err = g.InitProject(projectName, InitOptions{})
+	if err != nil {
+		t.Fatalf("InitProject(%q) failed = %v", projectName, err)
+	}
+
+	err = g.AddRoute(projectName, metadata.Route{
+		Path:        "/users",
+		HttpMethods: []string{http.MethodGet},
+		HandlerName: "Users",
+	})
+	if err != nil {
+		t.Fatalf("AddRoute() failed = %v", err)
+	}
+
+	dir := filepath.Join(g.Root, projectName)
+
+	generated := make(map[string][]byte)
+	for _, task := range genTasks() {
+		path := filepath.Join(dir, task.saveTo)
+
+		generated[path], err = g.FS.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+	}
+
+	descriptor := filepath.Join(dir, projectName+".yml")
+
+	src, err := g.FS.ReadFile(descriptor)
+	if err != nil {
+		t.Fatalf("reading descriptor: %v", err)
+	}
+
+	src = bytes.Replace(src, []byte("/users"), []byte("/people"), 1)
+
+	err = g.FS.WriteFile(descriptor, src, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("writing descriptor: %v", err)
+	}
+
+	failing := &Generator{
+		Root: g.Root,
+		FS:   &failingRename{FS: g.FS, dest: filepath.Join(dir, consts.GenFolder, consts.InterfaceFile)},
+	}
+
+	err = failing.Regenerate(projectName)
+	assert.Error(t, err)
+
+	for path, expected := range generated {
+		actual, err := g.FS.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+
+		assert.Equal(t, string(expected), string(actual), "%s should be restored", path)
+	}
+
+	bootstrap := filepath.Join(dir, consts.GenFolder, consts.BootstrapFile)
+	assert.Contains(t, string(generated[bootstrap]), `"/users"`, "the test should regenerate changed files")
+
+	leftovers, err := g.listFiles(g.Root)
+	if err != nil {
+		t.Fatalf("listing files: %v", err)
+	}
+
+	for _, rel := range leftovers {
+		assert.False(t, strings.HasPrefix(rel, "."), "staging folder %s should be removed", rel)
+	}
+}
+
+func TestInitProject_currentDir(t *testing.T) {
+	g := &Generator{Root: "/work/My-Service", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	err = g.FS.WriteFile(filepath.Join(g.Root, "README.md"), []byte("# readme\n"), files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("writing readme: %v", err)
+	}
+
+	err = g.InitProject(CurrentDir, InitOptions{})
+	if err != nil {
+		t.Fatalf("InitProject(%q) failed = %v", CurrentDir, err)
+	}
+
+	for _, path := range []string{
+		"myservice.go",
+		"myservice.yml",
+		"go.mod",
+		"README.md",
+		filepath.Join(consts.CmdFolder, consts.MainFile),
+		filepath.Join(consts.GenFolder, consts.BootstrapFile),
+	} {
+		_, err := g.FS.Stat(filepath.Join(g.Root, path))
+		assert.NoError(t, err, "%s should exist", path)
+	}
+
+	var walked []string
+
+	err = g.FS.Walk("/work", func(path string, info os.FileInfo, err error) error {
+		walked = append(walked, path)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, strings.Join(walked, "\n"), "/.myservice-", "staging folder should be removed")
+
+	err = g.Validate(CurrentDir)
+	assert.NoError(t, err)
+
+	err = g.InitProject(CurrentDir, InitOptions{})
+
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected a *ConflictError, got %v", err)
+	}
+
+	assert.Contains(t, conflict.Files, "go.mod")
+	assert.Contains(t, conflict.Files, "myservice.go")
+}
+
+func TestInitProject_currentDirConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		opts     InitOptions
+		conflict []string
+		err      bool
+		module   string
+	}{
+		{
+			name:     "existing module",
+			files:    map[string]string{"go.mod": "module other\n"},
+			conflict: []string{"go.mod"},
+		},
+		{
+			name:   "forced existing module",
+			files:  map[string]string{"go.mod": "module github.com/x/other\n\ngo 1.12\n"},
+			opts:   InitOptions{Force: true},
+			module: "github.com/x/other",
+		},
+		{
+			name:  "forced other module",
+			files: map[string]string{"go.mod": "module github.com/x/other\n"},
+			opts:  InitOptions{Force: true, Module: "github.com/x/service"},
+			err:   true,
+		},
+		{
+			name:  "go.mod without module",
+			files: map[string]string{"go.mod": "go 1.12\n"},
+			opts:  InitOptions{Force: true},
+			err:   true,
+		},
+		{
+			name:  "same package",
+			files: map[string]string{"helpers.go": "package service\n", "helpers_test.go": "package service_test\n"},
+		},
+		{
+			name:  "other package",
+			files: map[string]string{"main.go": "package main\n"},
+			opts:  InitOptions{Force: true},
+			err:   true,
+		},
+		{
+			name:  "invalid Go file",
+			files: map[string]string{"broken.go": "not go\n"},
+			err:   true,
+		},
+		{
+			name:  "other package in a subfolder",
+			files: map[string]string{"tools/tools.go": "package tools\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{Root: "/service", FS: files.NewMemory()}
+
+			for path, contents := range tt.files {
+				path = filepath.Join(g.Root, path)
+
+				err := g.FS.MkdirAll(filepath.Dir(path), files.DefaultPerm)
+				if err != nil {
+					t.Fatalf("creating folder: %v", err)
+				}
+
+				err = g.FS.WriteFile(path, []byte(contents), files.DefaultPerm)
+				if err != nil {
+					t.Fatalf("writing %s: %v", path, err)
+				}
+			}
+
+			err := g.InitProject(CurrentDir, tt.opts)
+
+			switch {
+			case tt.err:
+				assert.Error(t, err)
+				_, ok := err.(*ConflictError)
+				assert.False(t, ok, "package conflicts cannot be forced")
+			case tt.conflict != nil:
+				conflict, ok := err.(*ConflictError)
+				if !ok {
+					t.Fatalf("expected a *ConflictError, got %v", err)
+				}
+
+				assert.Equal(t, tt.conflict, conflict.Files)
+			default:
+				assert.NoError(t, err)
+			}
+
+			if tt.module != "" {
+				goMod, err := g.FS.ReadFile(filepath.Join(g.Root, "go.mod"))
+				if err != nil {
+					t.Fatalf("reading go.mod: %v", err)
+				}
+
+				assert.Contains(t, string(goMod), "module "+tt.module+"\n")
+			}
+		})
+	}
+}
+
+func TestInitProject_module(t *testing.T) {
+	const module = "github.com/org/fleet-api"
+
+	g := &Generator{Root: "/work", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	err = g.InitProject("fleet", InitOptions{Module: module})
+	if err != nil {
+		t.Fatalf("InitProject() failed = %v", err)
+	}
+
+	p, err := g.project("fleet")
+	if err != nil {
+		t.Fatalf("resolving project: %v", err)
+	}
+
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Equal(t, module, md.Module)
+
+	goMod, err := g.FS.ReadFile(filepath.Join(p.dir, "go.mod"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(goMod), "module "+module+"\n")
+
+	service, err := g.FS.ReadFile(p.serviceFilePath())
+	assert.NoError(t, err)
+	assert.Contains(t, string(service), "package fleetapi\n")
+
+	main, err := g.FS.ReadFile(filepath.Join(p.dir, consts.CmdFolder, consts.MainFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(main), `gen "`+module+`/gen"`)
+}
+
+func TestInitProject_descriptor(t *testing.T) {
+	g := &Generator{Root: "/work", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	desc := metadata.Metadata{
+		Info: metadata.Info{Name: "imported", Description: "Imported from a specification."},
+		Routes: []metadata.Route{
+			{
+				Path:        "/pets",
+				HttpMethods: []string{http.MethodPost},
+				HandlerName: "CreatePet",
+				Request:     &metadata.Body{Fields: []metadata.Field{{Name: "name", Required: true}}},
+			},
+		},
+	}
+
+	invalid := desc
+	invalid.Routes = []metadata.Route{{Path: "pets", HttpMethods: []string{http.MethodGet}, HandlerName: "Pets"}}
+
+	err = g.InitProject("pets", InitOptions{Descriptor: &invalid})
+	assert.Error(t, err, "invalid descriptors should be refused")
+
+	_, err = g.FS.Stat(filepath.Join(g.Root, "pets"))
+	assert.True(t, os.IsNotExist(err), "nothing should be written for an invalid descriptor")
+
+	err = g.InitProject("pets", InitOptions{Descriptor: &desc})
+	if err != nil {
+		t.Fatalf("InitProject() failed = %v", err)
+	}
+
+	p, err := g.project("pets")
+	if err != nil {
+		t.Fatalf("resolving project: %v", err)
+	}
+
+	md, err := g.loadDescriptor(p, true)
+	if err != nil {
+		t.Fatalf("loading descriptor: %v", err)
+	}
+
+	assert.Equal(t, "pets", md.Name, "the project name should replace the descriptor's")
+	assert.Equal(t, "pets", md.Module)
+	assert.Equal(t, desc.Routes, md.Routes)
+	assert.Empty(t, md.Middlwares)
+
+	service, err := g.FS.ReadFile(p.serviceFilePath())
+	assert.NoError(t, err)
+	assert.Contains(t, string(service), "func (s *Server) CreatePet(ctx context.Context, req api.CreatePetRequest) error {")
+}
+
+func TestInitProject_files(t *testing.T) {
+	g := &Generator{Root: "/work", FS: files.NewMemory()}
+
+	err := g.FS.MkdirAll(g.Root, files.DefaultPerm)
+	if err != nil {
+		t.Fatalf("creating root: %v", err)
+	}
+
+	err = g.InitProject("pets", InitOptions{
+		Files: map[string]func(metadata.Metadata) ([]byte, error){
+			"types.go": func(md metadata.Metadata) ([]byte, error) {
+				return []byte("package " + md.PackageName() + "\n"), nil
+			},
+			"empty.go": func(metadata.Metadata) ([]byte, error) {
+				return nil, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InitProject() failed = %v", err)
+	}
+
+	contents, err := g.FS.ReadFile(filepath.Join(g.Root, "pets", "types.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package pets\n", string(contents), "files should be generated from the final descriptor")
+
+	_, err = g.FS.Stat(filepath.Join(g.Root, "pets", "empty.go"))
+	assert.True(t, os.IsNotExist(err), "files without contents should not be created")
+
+	err = g.InitProject("cats", InitOptions{
+		Files: map[string]func(metadata.Metadata) ([]byte, error){
+			"types.go": func(metadata.Metadata) ([]byte, error) {
+				return nil, errors.New("unknown component")
+			},
+		},
+	})
+	assert.EqualError(t, err, "init failed: unknown component")
+
+	_, err = g.FS.Stat(filepath.Join(g.Root, "cats"))
+	assert.True(t, os.IsNotExist(err), "nothing should be written when a file fails")
+}
+
+// TestInitProject_builds scaffolds a project into a temp dir and builds and
+// vets it with the go tool, resolving modules from the local module cache
+// only.
+func TestInitProject_builds(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	dir, err := ioutil.TempDir("", "seed-build-")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name   string
+		module string
+	}{
+		{name: "plain"},
+		{name: "hosted", module: "github.com/org/hosted-api/v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGenerator(dir).InitProject(tt.name, InitOptions{Module: tt.module})
+			if err != nil {
+				t.Fatalf("InitProject(%q) failed = %v", tt.name, err)
+			}
+
+			for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+				cmd := exec.Command(goBin, args...)
+				cmd.Dir = filepath.Join(dir, tt.name)
+				cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off", "GOWORK=off")
+
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Errorf("go %s failed: %v\n%s", strings.Join(args, " "), err, out)
+				}
+			}
+
+			testShutdown(t, goBin, filepath.Join(dir, tt.name))
+		})
+	}
+}
+
+// testShutdown runs the executable of the project in dir, and checks that it
+// serves requests until it receives SIGTERM, then exits successfully.
+func testShutdown(t *testing.T, goBin, dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	bin := filepath.Join(dir, "service")
+
+	build := exec.Command(goBin, "build", "-o", bin, "./"+consts.CmdFolder)
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off", "GOWORK=off")
+
+	out, err := build.CombinedOutput()
+	if err != nil {
+		t.Fatalf("building executable failed: %v\n%s", err, out)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding a free port: %v", err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	var logs bytes.Buffer
+
+	cmd := exec.Command(bin, "-addr", addr, "-shutdown-timeout", "5s")
+	cmd.Stdout = &logs
+	cmd.Stderr = &logs
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatalf("starting executable failed: %v", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	served := false
+	for deadline := time.Now().Add(10 * time.Second); !served && time.Now().Before(deadline); {
+		res, err := http.Get("http://" + addr + "/")
+		if err == nil {
+			res.Body.Close()
+			served = res.StatusCode == http.StatusOK
+			continue
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if !served {
+		cmd.Process.Kill()
+		<-exited
+		t.Fatalf("executable never served requests:\n%s", logs.String())
+	}
+
+	err = cmd.Process.Signal(syscall.SIGTERM)
+	if err != nil {
+		t.Fatalf("sending SIGTERM failed: %v", err)
+	}
+
+	select {
+	case err := <-exited:
+		if err != nil {
+			t.Errorf("executable exited with %v:\n%s", err, logs.String())
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatalf("executable did not exit after SIGTERM:\n%s", logs.String())
+	}
+
+	assert.Contains(t, logs.String(), "received terminated, shutting down")
+}
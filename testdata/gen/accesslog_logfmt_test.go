@@ -0,0 +1,59 @@
+package gen
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logfmtService implements the generated service.
+type logfmtService struct{}
+
+func (logfmtService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestNewAccessLogger_logfmt(t *testing.T) {
+	var buf bytes.Buffer
+
+	NewAccessLogger(&buf).LogAccess(AccessEntry{
+		Time:       time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:      "info",
+		RequestID:  "abc",
+		Handler:    "Index",
+		Method:     http.MethodGet,
+		URI:        `/?q=a b"c`,
+		RemoteAddr: "10.0.0.1:1234",
+		Status:     http.StatusOK,
+		Bytes:      5,
+		Duration:   1500 * time.Millisecond,
+	})
+
+	want := `time=2020-01-02T03:04:05Z level=info request_id=abc handler=Index method=GET ` +
+		`uri="/?q=a b\"c" remote_addr=10.0.0.1:1234 status=200 bytes=5 duration=1.5` + "\n"
+
+	if buf.String() != want {
+		t.Errorf("entry =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestService_accessLogLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+
+	service := New(logfmtService{}, Config{})
+	service.accessLogger = NewAccessLogger(&buf)
+
+	service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	line := buf.String()
+	for _, field := range []string{"level=info", "handler=Index", "status=204", "bytes=0", `uri=/`} {
+		if !strings.Contains(line, field) {
+			t.Errorf("entry %q misses %s", line, field)
+		}
+	}
+}
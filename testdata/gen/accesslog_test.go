@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// loggedService implements the generated service, writing its access log
+// through the default logger into a buffer. DenyMw rejects the requests it
+// applies to.
+type loggedService struct {
+	logger AccessLogger
+}
+
+func (loggedService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}
+}
+
+func (loggedService) Broken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}
+}
+
+func (loggedService) Panic() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}
+}
+
+func (loggedService) Denied() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (loggedService) DenyMw(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+}
+
+func (s loggedService) LogAccess(entry AccessEntry) {
+	s.logger.LogAccess(entry)
+}
+
+func TestService_accessLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	service := New(loggedService{logger: NewAccessLogger(&buf)}, Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/?name=x", nil)
+	req.Header.Set("X-Request-Id", "abc")
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, req)
+
+	if id := rec.Header().Get("X-Request-Id"); id != "abc" {
+		t.Errorf("X-Request-Id = %q, want the one of the request", id)
+	}
+
+	for _, path := range []string{"/broken", "/denied", "/missing"} {
+		service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the panic of the handler should be propagated")
+			}
+		}()
+
+		service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+
+	var entries []map[string]interface{}
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding entry: %v", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 4 {
+		t.Fatalf("logged %d entries, want one per request served by a route: %v", len(entries), entries)
+	}
+
+	first := entries[0]
+
+	if _, err := time.Parse(time.RFC3339Nano, first["time"].(string)); err != nil {
+		t.Errorf("time = %v: %v", first["time"], err)
+	}
+
+	if d, ok := first["duration"].(float64); !ok || d < 0 {
+		t.Errorf("duration = %v, want seconds", first["duration"])
+	}
+
+	want := map[string]interface{}{
+		"level":       "info",
+		"request_id":  "abc",
+		"handler":     "Index",
+		"method":      "GET",
+		"uri":         "/?name=x",
+		"remote_addr": "10.0.0.1:1234",
+		"status":      float64(200),
+		"bytes":       float64(5),
+	}
+
+	for key, value := range want {
+		if first[key] != value {
+			t.Errorf("%s = %v, want %v", key, first[key], value)
+		}
+	}
+
+	levels := []struct {
+		handler string
+		status  float64
+		level   string
+	}{
+		{"Broken", 500, "error"},
+		{"Denied", 403, "warn"},
+		{"Panic", 500, "error"},
+	}
+
+	for i, l := range levels {
+		entry := entries[i+1]
+
+		if entry["handler"] != l.handler || entry["status"] != l.status || entry["level"] != l.level {
+			t.Errorf("entry = %v, want handler %s, status %v and level %s", entry, l.handler, l.status, l.level)
+		}
+
+		if id, _ := entry["request_id"].(string); len(id) != 16 {
+			t.Errorf("request_id = %q, want a random id", id)
+		}
+	}
+}
+
+func TestAccessLoggerOf(t *testing.T) {
+	impl := loggedService{}
+	if l := accessLoggerOf(impl); l != AccessLogger(impl) {
+		t.Errorf("accessLoggerOf() = %v, want the service implementation", l)
+	}
+
+	if _, ok := accessLoggerOf(struct{}{}).(*writerLogger); !ok {
+		t.Errorf("accessLoggerOf() without LogAccess is not the default logger")
+	}
+}
@@ -0,0 +1,186 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/client"
+	"test/gen/api"
+	"testing"
+	"time"
+)
+
+// clientService implements the generated service, echoing what each handler
+// receives.
+type clientService struct {
+	deleted []string
+}
+
+func (*clientService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "I'm alive!")
+	}
+}
+
+func (*clientService) CreateUser(ctx context.Context, params api.CreateUserParams, req api.CreateUserRequest) (api.CreateUserResponse, error) {
+	if req.Email == "taken@example.com" {
+		return api.CreateUserResponse{}, &api.HTTPError{Status: http.StatusConflict, Message: "email already taken"}
+	}
+
+	return api.CreateUserResponse{Team: params.Team, Email: req.Email, Notified: params.Notify}, nil
+}
+
+func (s *clientService) DeleteUser(ctx context.Context, req api.DeleteUserRequest) error {
+	s.deleted = append(s.deleted, req.Reason)
+	return nil
+}
+
+func (*clientService) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := api.GetSearchParams(r)
+		fmt.Fprintf(w, "%s %d %s", p.Q, p.Limit, p.Since.Format("2006-01-02"))
+	}
+}
+
+func TestClient(t *testing.T) {
+	const requestID = "2b4f1a2e-6c3d-4e5f-8a9b-0c1d2e3f4a5b"
+
+	service := &clientService{}
+
+	var paths []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		New(service, Config{}).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL + "/")
+	c.HTTPClient = srv.Client()
+
+	ctx := context.Background()
+
+	res, err := c.Index(ctx)
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "I'm alive!" {
+		t.Errorf("Index() body = %q", body)
+	}
+
+	params := api.NewCreateUserParams()
+	params.Team, params.XRequestID = "core team", requestID
+
+	user, err := c.CreateUser(ctx, params, api.CreateUserRequest{Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() failed: %v", err)
+	}
+
+	if want := (api.CreateUserResponse{Team: "core team", Email: "jane@example.com", Notified: true}); user != want {
+		t.Errorf("CreateUser() = %+v, want %+v", user, want)
+	}
+
+	quiet := params
+	quiet.Notify = false
+
+	user, err = c.CreateUser(ctx, quiet, api.CreateUserRequest{Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() without notification failed: %v", err)
+	}
+
+	if user.Notified {
+		t.Errorf("CreateUser() = %+v, want notify=false to be sent", user)
+	}
+
+	_, err = c.CreateUser(ctx, params, api.CreateUserRequest{Email: "taken@example.com"})
+
+	e, ok := err.(*client.Error)
+	if !ok || e.StatusCode != http.StatusConflict || e.Message != "email already taken" {
+		t.Errorf("CreateUser() error = %v, want a 409 *client.Error", err)
+	}
+
+	_, err = c.CreateUser(ctx, api.CreateUserParams{Team: "core", XRequestID: "42"}, api.CreateUserRequest{Email: "jane@example.com"})
+
+	e, ok = err.(*client.Error)
+	if !ok || e.StatusCode != http.StatusBadRequest || e.Param != "X-Request-Id" || e.In != "header" {
+		t.Errorf("CreateUser() error = %v, want a 400 *client.Error on the X-Request-Id header", err)
+	}
+
+	if want := `400 Bad Request: header X-Request-Id "42" is not a UUID`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	err = c.DeleteUser(ctx, "12", "soft", api.DeleteUserRequest{Reason: "left"})
+	if err != nil {
+		t.Fatalf("DeleteUser() failed: %v", err)
+	}
+
+	err = c.DeleteUserPost(ctx, "13", "hard", api.DeleteUserRequest{Reason: "spam"})
+	if err != nil {
+		t.Fatalf("DeleteUserPost() failed: %v", err)
+	}
+
+	if len(service.deleted) != 2 || service.deleted[0] != "left" || service.deleted[1] != "spam" {
+		t.Errorf("DeleteUser() and DeleteUserPost() received %v", service.deleted)
+	}
+
+	err = c.DeleteUser(ctx, "abc", "soft", api.DeleteUserRequest{})
+
+	e, ok = err.(*client.Error)
+	if !ok || e.StatusCode != http.StatusNotFound || e.Message != "" {
+		t.Errorf("DeleteUser() error = %v, want a 404 *client.Error", err)
+	}
+
+	since := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	res, err = c.Search(ctx, api.SearchParams{Q: "a&b", Since: since})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "a&b 0 2019-05-01" {
+		t.Errorf("Search() body = %q, want limit=0 to be sent", body)
+	}
+
+	search := api.NewSearchParams()
+	search.Q = "c"
+
+	res, err = c.Search(ctx, search)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "c 10 0001-01-01" {
+		t.Errorf("Search() with the defaults body = %q", body)
+	}
+
+	expected := []string{
+		"GET /",
+		"POST /teams/core%20team/users",
+		"POST /teams/core%20team/users",
+		"POST /teams/core%20team/users",
+		"POST /teams/core/users",
+		"DELETE /users/12/soft",
+		"POST /users/13/hard",
+		"DELETE /users/abc/soft",
+		"GET /search",
+		"GET /search",
+	}
+
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("requests =\n%s\nwant\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
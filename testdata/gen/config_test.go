@@ -0,0 +1,178 @@
+package gen
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configService implements the generated service.
+type configService struct{}
+
+func (configService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.yml")
+
+	err = ioutil.WriteFile(file, []byte("db_url: postgres://file\nworkers: 8\ncache_ttl: 1m\nratio:\n"), 0644)
+	if err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    Config
+		wantErr string
+	}{
+		{
+			name:    "missing required setting",
+			wantErr: "missing required settings: db_url (-db-url or $TEST_DB_URL)",
+		},
+		{
+			name: "defaults",
+			args: []string{"-db-url", "postgres://flag"},
+			want: Config{
+				Addr:            ":8080",
+				ReadTimeout:     5 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     2 * time.Minute,
+				ShutdownTimeout: 30 * time.Second,
+				DbURL:           "postgres://flag",
+				Workers:         4,
+				Ratio:           0.5,
+			},
+		},
+		{
+			name: "file",
+			args: []string{"-config", file},
+			want: Config{
+				Addr:            ":8080",
+				ReadTimeout:     5 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     2 * time.Minute,
+				ShutdownTimeout: 30 * time.Second,
+				DbURL:           "postgres://file",
+				Workers:         8,
+				Ratio:           0.5,
+				CacheTtl:        time.Minute,
+			},
+		},
+		{
+			name: "environment overrides file",
+			env: map[string]string{
+				"TEST_CONFIG":  file,
+				"TEST_WORKERS": "16",
+				"TEST_ADDR":    ":9090",
+				"CACHE_TTL":    "2m",
+			},
+			want: Config{
+				Addr:            ":9090",
+				ReadTimeout:     5 * time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     2 * time.Minute,
+				ShutdownTimeout: 30 * time.Second,
+				DbURL:           "postgres://file",
+				Workers:         16,
+				Ratio:           0.5,
+				CacheTtl:        2 * time.Minute,
+			},
+		},
+		{
+			name: "flags override environment",
+			args: []string{"-config", file, "-workers", "32", "-debug", "-read-timeout", "1s"},
+			env:  map[string]string{"TEST_WORKERS": "16", "TEST_DEBUG": "false"},
+			want: Config{
+				Addr:            ":8080",
+				ReadTimeout:     time.Second,
+				WriteTimeout:    10 * time.Second,
+				IdleTimeout:     2 * time.Minute,
+				ShutdownTimeout: 30 * time.Second,
+				DbURL:           "postgres://file",
+				Workers:         32,
+				Debug:           true,
+				Ratio:           0.5,
+				CacheTtl:        time.Minute,
+			},
+		},
+		{
+			name:    "invalid value",
+			args:    []string{"-db-url", "x"},
+			env:     map[string]string{"TEST_WORKERS": "many"},
+			wantErr: `invalid workers from $TEST_WORKERS`,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"-config", filepath.Join(dir, "missing.yml")},
+			wantErr: "reading config file",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"-port", "80"},
+			wantErr: "flag provided but not defined: -port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			cfg, err := LoadConfig(tt.args)
+
+			switch {
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("LoadConfig() failed: %v", err)
+			case cfg != tt.want:
+				t.Errorf("LoadConfig() =\n%+v\nwant\n%+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_unknownSetting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.yml")
+
+	err = ioutil.WriteFile(file, []byte("db_url: x\nport: 80\n"), 0644)
+	if err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	_, err = LoadConfig([]string{"-config", file})
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "port"`) {
+		t.Errorf("LoadConfig() error = %v, want an unknown setting", err)
+	}
+}
+
+func TestService_Config(t *testing.T) {
+	cfg := Config{Addr: ":9090", DbURL: "postgres://db"}
+
+	if got := New(configService{}, cfg).Config(); got != cfg {
+		t.Errorf("Config() = %+v, want %+v", got, cfg)
+	}
+}
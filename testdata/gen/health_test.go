@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// unreadyService implements the generated service, with a middleware rejecting
+// every request, and no readiness checks. Its Page route would capture the
+// health endpoints if they were registered after it.
+type unreadyService struct{}
+
+func (unreadyService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (unreadyService) Page() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (unreadyService) DenyMw(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+}
+
+// checkedService declares readiness checks.
+type checkedService struct {
+	unreadyService
+
+	checks []Check
+}
+
+func (s checkedService) ReadinessChecks() []Check {
+	return s.checks
+}
+
+// sleep returns a check function sleeping for d, unless its context is done
+// first.
+func sleep(d time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return errors.New("interrupted")
+		}
+	}
+}
+
+func serveHealth(t *testing.T, service TestService, method, path string) (int, HealthReport) {
+	rec := httptest.NewRecorder()
+	New(service, Config{}).ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+
+	var report HealthReport
+
+	if rec.Code != http.StatusMethodNotAllowed && rec.Code != http.StatusForbidden {
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("decoding report %q: %v", rec.Body.String(), err)
+		}
+	}
+
+	return rec.Code, report
+}
+
+func TestService_liveness(t *testing.T) {
+	code, report := serveHealth(t, unreadyService{}, http.MethodGet, "/healthz")
+	if code != http.StatusOK || report.Status != StatusOK {
+		t.Errorf("liveness = %d %+v, want 200 and status ok", code, report)
+	}
+
+	for _, path := range []string{"/", "/about"} {
+		if code, _ := serveHealth(t, unreadyService{}, http.MethodGet, path); code != http.StatusForbidden {
+			t.Errorf("route %s = %d, want the middleware to answer with 403", path, code)
+		}
+	}
+
+	if code, _ := serveHealth(t, unreadyService{}, http.MethodPost, "/healthz"); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST liveness = %d, want 405", code)
+	}
+}
+
+func TestService_readiness(t *testing.T) {
+	code, report := serveHealth(t, unreadyService{}, http.MethodGet, "/ready")
+	if code != http.StatusOK || report.Status != StatusOK || len(report.Checks) != 0 {
+		t.Errorf("readiness without checks = %d %+v, want 200 and status ok", code, report)
+	}
+
+	ok := checkedService{checks: []Check{
+		{Name: "cache", Func: func(context.Context) error { return nil }},
+		{Name: "queue", Timeout: time.Second, Func: sleep(100 * time.Millisecond)},
+	}}
+
+	code, report = serveHealth(t, ok, http.MethodGet, "/ready")
+	if code != http.StatusOK || report.Status != StatusOK || len(report.Checks) != 2 {
+		t.Errorf("readiness with passing checks = %d %+v, want 200 and status ok", code, report)
+	}
+
+	failing := checkedService{checks: []Check{
+		{Name: "cache", Func: func(context.Context) error { return nil }},
+		{Name: "database", Func: func(context.Context) error { return errors.New("database down") }},
+		{Name: "queue", Func: sleep(time.Second)},
+	}}
+
+	start := time.Now()
+
+	code, report = serveHealth(t, failing, http.MethodGet, "/ready")
+	if code != http.StatusServiceUnavailable || report.Status != StatusFailed {
+		t.Errorf("readiness with failing checks = %d %+v, want 503 and status failed", code, report)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("readiness took %v, want the slow check to time out after 50ms", elapsed)
+	}
+
+	want := []CheckResult{
+		{Name: "cache", Status: StatusOK},
+		{Name: "database", Status: StatusFailed, Error: "database down"},
+		{Name: "queue", Status: StatusFailed, Error: context.DeadlineExceeded.Error()},
+	}
+
+	if len(report.Checks) != len(want) {
+		t.Fatalf("checks = %+v, want %+v", report.Checks, want)
+	}
+
+	for i, check := range report.Checks {
+		if check.Duration == "" {
+			t.Errorf("check %s has no duration", check.Name)
+		}
+
+		check.Duration = ""
+		if check != want[i] {
+			t.Errorf("checks[%d] = %+v, want %+v", i, check, want[i])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// plainService implements the generated service without any lifecycle hook.
+type plainService struct{}
+
+func (plainService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+// hookedService implements both lifecycle hooks, recording the contexts they
+// receive.
+type hookedService struct {
+	plainService
+
+	started, stopped context.Context
+	stopErr          error
+}
+
+func (s *hookedService) OnStart(ctx context.Context) error {
+	s.started = ctx
+	return nil
+}
+
+func (s *hookedService) OnShutdown(ctx context.Context) error {
+	s.stopped = ctx
+	return s.stopErr
+}
+
+func TestService_hooks(t *testing.T) {
+	plain := New(plainService{}, Config{})
+
+	if err := plain.OnStart(context.Background()); err != nil {
+		t.Errorf("OnStart() without hook = %v", err)
+	}
+
+	if err := plain.OnShutdown(context.Background()); err != nil {
+		t.Errorf("OnShutdown() without hook = %v", err)
+	}
+
+	impl := &hookedService{stopErr: errors.New("closing database")}
+	hooked := New(impl, Config{})
+
+	if err := hooked.OnStart(context.Background()); err != nil || impl.started == nil {
+		t.Errorf("OnStart() = %v, hook called: %v", err, impl.started != nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := hooked.OnShutdown(ctx); err != impl.stopErr {
+		t.Errorf("OnShutdown() = %v, want %v", err, impl.stopErr)
+	}
+
+	if _, ok := impl.stopped.Deadline(); !ok {
+		t.Errorf("OnShutdown() did not pass the deadline of the shutdown to the hook")
+	}
+}
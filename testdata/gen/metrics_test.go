@@ -0,0 +1,208 @@
+package gen
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// metricsService implements the generated service. Its Slow handler blocks
+// until release is closed, and DenyMw rejects the requests it applies to.
+type metricsService struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (metricsService) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (metricsService) Denied() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (metricsService) Users() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no users", http.StatusNotFound)
+	}
+}
+
+func (s metricsService) Slow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.entered <- struct{}{}
+		<-s.release
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (metricsService) Panic() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		panic("broken handler")
+	}
+}
+
+func (metricsService) Stream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "not a flusher", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte("chunk"))
+		flusher.Flush()
+	}
+}
+
+func (metricsService) Hijack() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "not a hijacker", http.StatusInternalServerError)
+			return
+		}
+
+		conn, buf, err := hijacker.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		buf.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+		buf.Flush()
+	}
+}
+
+func (metricsService) DenyMw(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+}
+
+func scrape(t *testing.T, service *Service) string {
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scraping metrics = %d, want 200", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+
+	b, err := ioutil.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %v", err)
+	}
+
+	return string(b)
+}
+
+func assertLines(t *testing.T, metrics string, lines ...string) {
+	for _, line := range lines {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("metrics miss %q:\n%s", line, metrics)
+		}
+	}
+}
+
+func TestService_metrics(t *testing.T) {
+	impl := metricsService{entered: make(chan struct{}), release: make(chan struct{})}
+	service := New(impl, Config{})
+
+	assertLines(t, scrape(t, service),
+		`# TYPE http_requests_total counter`,
+		`# TYPE http_request_duration_seconds histogram`,
+		`# TYPE http_requests_in_flight gauge`,
+		`http_requests_in_flight{handler="Index"} 0`,
+		`http_requests_in_flight{handler="Slow"} 0`,
+	)
+
+	for _, path := range []string{"/", "/", "/users", "/denied", "/missing"} {
+		service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+		close(done)
+	}()
+
+	<-impl.entered
+
+	metrics := scrape(t, service)
+	assertLines(t, metrics,
+		`http_requests_total{handler="Index",status="200"} 2`,
+		`http_requests_total{handler="Users",status="404"} 1`,
+		`http_requests_total{handler="Denied",status="403"} 1`,
+		`http_request_duration_seconds_bucket{handler="Index",status="200",le="0.05"} 2`,
+		`http_request_duration_seconds_bucket{handler="Index",status="200",le="1"} 2`,
+		`http_request_duration_seconds_bucket{handler="Index",status="200",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{handler="Index",status="200"} 2`,
+		`http_requests_in_flight{handler="Slow"} 1`,
+	)
+
+	if strings.Contains(metrics, "/missing") || strings.Contains(metrics, `handler="Slow",status`) {
+		t.Errorf("metrics hold requests which were not served by a route:\n%s", metrics)
+	}
+
+	close(impl.release)
+	<-done
+
+	assertLines(t, scrape(t, service),
+		`http_requests_total{handler="Slow",status="202"} 1`,
+		`http_requests_in_flight{handler="Slow"} 0`,
+	)
+}
+
+func TestService_metricsPanic(t *testing.T) {
+	service := New(metricsService{}, Config{})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the panic of the handler should be propagated")
+			}
+		}()
+
+		service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+
+	assertLines(t, scrape(t, service),
+		`http_requests_total{handler="Panic",status="500"} 1`,
+		`http_requests_in_flight{handler="Panic"} 0`,
+	)
+}
+
+func TestService_metricsFlushHijack(t *testing.T) {
+	service := New(metricsService{}, Config{})
+
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Errorf("GET /stream = %d, flushed %v, want a flushed 200", rec.Code, rec.Flushed)
+	}
+
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/hijack")
+	if err != nil {
+		t.Fatalf("GET /hijack failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("GET /hijack = %d, want 204", resp.StatusCode)
+	}
+
+	assertLines(t, scrape(t, service),
+		`http_requests_total{handler="Stream",status="200"} 1`,
+		`http_requests_in_flight{handler="Hijack"} 0`,
+	)
+}
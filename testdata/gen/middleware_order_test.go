@@ -0,0 +1,74 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// recorder implements the generated service, recording the name of every
+// middleware and handler in the order they are executed.
+type recorder struct {
+	calls []string
+}
+
+func (rec *recorder) handler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec.calls = append(rec.calls, name)
+	}
+}
+
+func (rec *recorder) middleware(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.calls = append(rec.calls, name)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rec *recorder) Index() http.HandlerFunc { return rec.handler("Index") }
+func (rec *recorder) Users() http.HandlerFunc { return rec.handler("Users") }
+func (rec *recorder) User() http.HandlerFunc  { return rec.handler("User") }
+func (rec *recorder) Admin() http.HandlerFunc { return rec.handler("Admin") }
+
+func (rec *recorder) LogMw(next http.Handler) http.Handler   { return rec.middleware("LogMw", next) }
+func (rec *recorder) AuthMw(next http.Handler) http.Handler  { return rec.middleware("AuthMw", next) }
+func (rec *recorder) TraceMw(next http.Handler) http.Handler { return rec.middleware("TraceMw", next) }
+func (rec *recorder) AuditMw(next http.Handler) http.Handler { return rec.middleware("AuditMw", next) }
+
+func TestMiddlewareOrder(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{
+			path:     "/",
+			expected: []string{"LogMw", "Index"},
+		},
+		{
+			path:     "/users",
+			expected: []string{"AuthMw", "LogMw", "Users"},
+		},
+		{
+			path:     "/users/12",
+			expected: []string{"AuthMw", "AuditMw", "TraceMw", "LogMw", "User"},
+		},
+		{
+			path:     "/admin/settings",
+			expected: []string{"TraceMw", "LogMw", "Admin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := &recorder{}
+			service := New(rec, Config{})
+
+			service.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if !reflect.DeepEqual(tt.expected, rec.calls) {
+				t.Errorf("expected calls %v, got %v", tt.expected, rec.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package gen
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/gen/api"
+	"testing"
+)
+
+// params implements the generated service, writing the parameters each
+// handler receives to the response.
+type params struct{}
+
+func (params) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (params) User() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := api.GetUserParams(r)
+		fmt.Fprintf(w, "%d %t %s", p.ID, p.Verbose, p.Since.Format("2006-01-02"))
+	}
+}
+
+func (params) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := api.GetSearchParams(r)
+		fmt.Fprintf(w, "%s %d %s", p.Q, p.Limit, p.XRequestID)
+	}
+}
+
+func TestParams(t *testing.T) {
+	const requestID = "2b4f1a2e-6c3d-4e5f-8a9b-0c1d2e3f4a5b"
+
+	tests := []struct {
+		url    string
+		header string
+		status int
+		body   string
+	}{
+		{
+			url:    "/users/12?verbose=true",
+			status: http.StatusOK,
+			body:   "12 true 2019-01-01",
+		},
+		{
+			url:    "/users/12?since=2020-02-03T04:05:06Z",
+			status: http.StatusOK,
+			body:   "12 false 2020-02-03",
+		},
+		{
+			url:    "/users/12?verbose=maybe",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"param":"verbose","in":"query","message":"\"maybe\" is not a boolean"}}`,
+		},
+		{
+			url:    "/users/12?since=yesterday",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"param":"since","in":"query","message":"\"yesterday\" is not an RFC 3339 time"}}`,
+		},
+		{
+			url:    "/users/99999999999999999999",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"param":"id","in":"path","message":"\"99999999999999999999\" is not an integer"}}`,
+		},
+		{
+			url:    "/search",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"param":"q","in":"query","message":"is required"}}`,
+		},
+		{
+			url:    "/search?q=seed",
+			status: http.StatusOK,
+			body:   "seed 10",
+		},
+		{
+			url:    "/search?q=seed&limit=5",
+			header: requestID,
+			status: http.StatusOK,
+			body:   "seed 5 " + requestID,
+		},
+		{
+			url:    "/search?q=seed",
+			header: "42",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"param":"X-Request-Id","in":"header","message":"\"42\" is not a UUID"}}`,
+		},
+	}
+
+	service := New(params{}, Config{})
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		if tt.header != "" {
+			req.Header.Set("x-request-id", tt.header)
+		}
+
+		rec := httptest.NewRecorder()
+		service.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.url, tt.status, rec.Code)
+		}
+
+		body := strings.TrimSpace(rec.Body.String())
+		if body != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.url, tt.body, body)
+		}
+
+		if tt.status == http.StatusBadRequest && rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a JSON error, got %q", tt.url, rec.Header().Get("Content-Type"))
+		}
+	}
+}
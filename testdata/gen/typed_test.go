@@ -0,0 +1,175 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/gen/api"
+	"testing"
+)
+
+// typed implements the generated service with typed handlers.
+type typed struct {
+	healthy bool
+}
+
+func (typed) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (typed) CreateUser(ctx context.Context, params api.CreateUserParams, req api.CreateUserRequest) (api.CreateUserResponse, error) {
+	switch req.Email {
+	case "taken@example.com":
+		return api.CreateUserResponse{}, &api.HTTPError{Status: http.StatusConflict, Message: "email already taken"}
+	case "broken@example.com":
+		return api.CreateUserResponse{}, errors.New("database is down")
+	}
+
+	return api.CreateUserResponse{
+		ID:    "2b4f1a2e-6c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		Team:  params.Team,
+		Email: req.Email,
+	}, nil
+}
+
+func (typed) DeleteUser(ctx context.Context, params api.DeleteUserParams, req api.DeleteUserRequest) error {
+	return nil
+}
+
+func (t typed) Status(ctx context.Context) (api.StatusResponse, error) {
+	return api.StatusResponse{Healthy: t.healthy}, nil
+}
+
+func TestTyped(t *testing.T) {
+	const (
+		id          = "2b4f1a2e-6c3d-4e5f-8a9b-0c1d2e3f4a5b"
+		contentType = "application/json"
+	)
+
+	tests := []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+		status      int
+		response    string
+	}{
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":"jane@example.com","age":42,"tags":["admin"]}`,
+			status:      http.StatusOK,
+			response:    `{"id":"` + id + `","team":"core","email":"jane@example.com"}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"email":"jane@example.com","manager_id":"` + id + `"}`,
+			status:      http.StatusOK,
+			response:    `{"id":"` + id + `","team":"core","email":"jane@example.com"}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: "text/plain",
+			body:        `{"email":"jane@example.com"}`,
+			status:      http.StatusUnsupportedMediaType,
+			response:    `{"error":{"message":"expected an application/json body, got \"text/plain\""}}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"age":42}`,
+			status:      http.StatusBadRequest,
+			response:    `{"error":{"param":"email","in":"body","message":"is required"}}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":null}`,
+			status:      http.StatusBadRequest,
+			response:    `{"error":{"param":"email","in":"body","message":"is required"}}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":"jane@example.com","age":"old"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":"jane@example.com","manager_id":"42"}`,
+			status:      http.StatusBadRequest,
+			response:    `{"error":{"param":"manager_id","in":"body","message":"\"42\" is not a UUID"}}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":"taken@example.com"}`,
+			status:      http.StatusConflict,
+			response:    `{"error":{"message":"email already taken"}}`,
+		},
+		{
+			method:      http.MethodPost,
+			url:         "/teams/core/users",
+			contentType: contentType,
+			body:        `{"email":"broken@example.com"}`,
+			status:      http.StatusInternalServerError,
+			response:    `{"error":{"message":"Internal Server Error"}}`,
+		},
+		{
+			method:      http.MethodDelete,
+			url:         "/users/" + id,
+			contentType: contentType,
+			body:        `{"reason":"left"}`,
+			status:      http.StatusNoContent,
+		},
+		{
+			method:      http.MethodDelete,
+			url:         "/users/42",
+			contentType: contentType,
+			body:        `{}`,
+			status:      http.StatusBadRequest,
+			response:    `{"error":{"param":"id","in":"path","message":"\"42\" is not a UUID"}}`,
+		},
+		{
+			method:   http.MethodGet,
+			url:      "/status",
+			status:   http.StatusOK,
+			response: `{"healthy":true}`,
+		},
+	}
+
+	s := New(typed{healthy: true}, Config{})
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+
+			if tt.response != "" && strings.TrimSpace(w.Body.String()) != tt.response {
+				t.Errorf("expected body %s, got %s", tt.response, w.Body)
+			}
+		})
+	}
+}